@@ -10,6 +10,7 @@ import (
 
 	"github.com/DanielRenne/GoCore/core/app"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 //private local variables
@@ -45,7 +46,14 @@ func SocketAPICallback(conn *app.WebSocketConnection, c *gin.Context, messageTyp
 	if strings.Contains(string(data), "\"Thank\"") {
 		return
 	}
-	processSocketAPI(c, data, conn)
+
+	encoding := app.EncodingJSON
+	if messageType == websocket.BinaryMessage && app.IsBinaryEncoding(conn.Encoding) {
+		encoding = conn.Encoding
+	} else if isJSONRPC(data) {
+		return
+	}
+	processSocketAPI(c, data, conn, encoding)
 }
 
 //RegisterController registers a controller object to be registered by the name of the object.
@@ -0,0 +1,259 @@
+//apitest records controller calls to golden files and replays them through api.ProcessRequest as regression tests.
+package apitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/app/api"
+)
+
+//Case is a single recorded controller call stored as a golden file.  JSON payloads are stored in Payload.  MessagePack and CBOR
+//payloads are stored base64 encoded in BinaryPayload with their app.Encoding.
+type Case struct {
+	Name          string          `json:"name"`
+	Controller    string          `json:"controller"`
+	Action        string          `json:"action"`
+	Encoding      string          `json:"encoding,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	BinaryPayload []byte          `json:"binaryPayload,omitempty"`
+	Status        int             `json:"status"`
+	Response      json.RawMessage `json:"response"`
+}
+
+//GetPayload returns the payload of the call in its encoding.
+func (c Case) GetPayload() []byte {
+	if c.BinaryPayload != nil {
+		return c.BinaryPayload
+	}
+	return c.Payload
+}
+
+//Options configures Replay.
+type Options struct {
+	//Masks are response paths ignored when comparing, ie "id", "items.*.createdAt" or "**.Id".
+	//"*" matches any single key or array index and "**" matches any number of them.
+	Masks []string
+	//Update rewrites the golden files with the current responses instead of comparing them.
+	Update bool
+}
+
+//Recorder writes every controller call routed by the api package to golden files while it is enabled.
+type Recorder struct {
+	Dir     string
+	enabled int32
+}
+
+/*Record starts capturing every controller call to golden files in dir, typically in a development build running against a dev database.
+Calls with the same controller, action and payload overwrite the same file.
+Implementation example-----------
+if os.Getenv("APITEST_RECORD") != "" {
+	apitest.Record("testdata/api")
+}
+---------------------------------
+*/
+func Record(dir string) *Recorder {
+	r := &Recorder{Dir: dir, enabled: 1}
+	api.RegisterInterceptor(api.Interceptor{After: r.after})
+	return r
+}
+
+//Stop stops capturing calls.  Interceptors cannot be removed so the recorder stays registered but does nothing.
+func (r *Recorder) Stop() {
+	atomic.StoreInt32(&r.enabled, 0)
+}
+
+//Call runs a controller action through api.ProcessRequest and records it regardless of whether the recorder is enabled.
+func (r *Recorder) Call(controller string, action string, payload interface{}) (c Case, err error) {
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	c, err = Invoke(controller, action, data)
+	if err != nil {
+		return
+	}
+	err = r.save(c)
+	return
+}
+
+func (r *Recorder) after(rc *api.RequestContext, y interface{}, e api.ErrorResponse, httpStatus int) {
+	if atomic.LoadInt32(&r.enabled) == 0 {
+		return
+	}
+
+	c, err := newCase(rc.Controller, rc.Action, rc.Encoding, rc.Data, y, e, httpStatus)
+	if err == nil {
+		err = r.save(c)
+	}
+	if err != nil {
+		log.Println("apitest failed to record " + rc.Controller + "." + rc.Action + ":  " + err.Error())
+	}
+}
+
+func (r *Recorder) save(c Case) error {
+	return writeCase(filepath.Join(r.Dir, c.Name+".json"), c)
+}
+
+//Invoke runs a controller action with a json payload through api.ProcessRequest and returns the call as a Case.
+func Invoke(controller string, action string, payload []byte) (c Case, err error) {
+	return InvokeEncoded(controller, action, app.EncodingJSON, payload)
+}
+
+//InvokeEncoded runs a controller action with a payload in an app.Encoding through api.ProcessEncodedRequest and returns the call as a Case.
+func InvokeEncoded(controller string, action string, encoding string, payload []byte) (c Case, err error) {
+
+	var y interface{}
+	var e api.ErrorResponse
+	var httpStatus int
+
+	api.ProcessEncodedRequest(controller, action, payload, encoding, func(result interface{}, errResponse api.ErrorResponse, status int) {
+		y = result
+		e = errResponse
+		httpStatus = status
+	})
+
+	return newCase(controller, action, encoding, payload, y, e, httpStatus)
+}
+
+func newCase(controller string, action string, encoding string, payload []byte, y interface{}, e api.ErrorResponse, httpStatus int) (c Case, err error) {
+
+	c.Controller = strings.Title(controller)
+	c.Action = strings.Title(action)
+	c.Status = httpStatus
+	if len(payload) > 0 {
+		if app.IsBinaryEncoding(encoding) || !json.Valid(payload) {
+			c.Encoding = encoding
+			c.BinaryPayload = payload
+		} else {
+			c.Payload = json.RawMessage(payload)
+		}
+	}
+
+	hash := sha256.Sum256(c.GetPayload())
+	c.Name = c.Controller + "." + c.Action + "." + hex.EncodeToString(hash[:])[:12]
+
+	if y == nil {
+		c.Response, err = json.Marshal(e)
+	} else {
+		c.Response, err = json.Marshal(y)
+	}
+	return
+}
+
+//Load reads every golden file in dir sorted by name.
+func Load(dir string) (cases []Case, err error) {
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		var data []byte
+		data, err = ioutil.ReadFile(file)
+		if err != nil {
+			return
+		}
+		var c Case
+		err = json.Unmarshal(data, &c)
+		if err != nil {
+			return
+		}
+		cases = append(cases, c)
+	}
+	return
+}
+
+/*Replay runs every golden file in dir through api.ProcessRequest as a sub test and fails when the status or the masked response changed.
+Controllers must be registered before it is called.
+Implementation example-----------
+func TestAPI(t *testing.T) {
+	api.RegisterController(&controllers.Users{})
+	apitest.Replay(t, "testdata/api", apitest.Options{Masks: []string{"**.Id", "**.CreateDate"}})
+}
+---------------------------------
+*/
+func Replay(t *testing.T, dir string, options Options) {
+
+	cases, err := Load(dir)
+	if err != nil {
+		t.Fatalf("apitest failed to load golden files from %s:  %v", dir, err)
+	}
+	if len(cases) == 0 {
+		t.Fatalf("apitest found no golden files in %s", dir)
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			actual, err := InvokeEncoded(c.Controller, c.Action, c.Encoding, c.GetPayload())
+			if err != nil {
+				t.Fatalf("apitest failed to invoke %s.%s:  %v", c.Controller, c.Action, err)
+			}
+
+			if options.Update {
+				err = writeCase(filepath.Join(dir, c.Name+".json"), actual)
+				if err != nil {
+					t.Fatalf("apitest failed to update %s:  %v", c.Name, err)
+				}
+				return
+			}
+
+			for _, diff := range Compare(c, actual, options.Masks) {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+//Compare returns the differences between an expected and actual case ignoring the masked response paths.
+func Compare(expected Case, actual Case, masks []string) (diffs []string) {
+
+	if expected.Status != actual.Status {
+		diffs = append(diffs, "status:  expected "+strconv.Itoa(expected.Status)+", got "+strconv.Itoa(actual.Status))
+	}
+
+	expectedResponse, err := decodeResponse(expected.Response)
+	if err != nil {
+		return append(diffs, "expected response is not valid json:  "+err.Error())
+	}
+	actualResponse, err := decodeResponse(actual.Response)
+	if err != nil {
+		return append(diffs, "actual response is not valid json:  "+err.Error())
+	}
+
+	parsedMasks := parseMasks(masks)
+	expectedResponse = applyMasks(expectedResponse, nil, parsedMasks)
+	actualResponse = applyMasks(actualResponse, nil, parsedMasks)
+
+	return diffValues("response", expectedResponse, actualResponse, diffs)
+}
+
+func writeCase(path string, c Case) error {
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0777)
+}
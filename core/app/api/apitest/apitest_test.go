@@ -0,0 +1,115 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/app/api"
+)
+
+type echoRequest struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type ApitestEcho struct{}
+
+func (ApitestEcho) Echo(request echoRequest) echoRequest {
+	return request
+}
+
+func init() {
+	api.RegisterController(&ApitestEcho{})
+}
+
+func TestRecordAndReplayJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apitestJSON")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	recorder := &Recorder{Dir: dir}
+	c, err := recorder.Call("ApitestEcho", "Echo", echoRequest{Name: "a", Count: 2})
+	if err != nil {
+		t.Fatalf("failed to record:  %v", err)
+	}
+	if c.Status != 200 || c.Encoding != "" || c.BinaryPayload != nil {
+		t.Fatalf("unexpected json case:  %+v", c)
+	}
+
+	cases, err := Load(dir)
+	if err != nil || len(cases) != 1 {
+		t.Fatalf("expected 1 golden file, got %d:  %v", len(cases), err)
+	}
+	var payload bytes.Buffer
+	json.Compact(&payload, cases[0].Payload)
+	if payload.String() != `{"name":"a","count":2}` {
+		t.Errorf("payload was not stored as json:  %s", cases[0].Payload)
+	}
+
+	Replay(t, dir, Options{})
+}
+
+func TestRecordAndReplayBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apitestBinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, encoding := range []string{app.EncodingMessagePack, app.EncodingCBOR} {
+		payload, err := app.MarshalEncoding(encoding, echoRequest{Name: encoding, Count: 3})
+		if err != nil {
+			t.Fatalf("failed to encode %s:  %v", encoding, err)
+		}
+
+		c, err := InvokeEncoded("ApitestEcho", "Echo", encoding, payload)
+		if err != nil {
+			t.Fatalf("failed to invoke with %s:  %v", encoding, err)
+		}
+		if c.Status != 200 {
+			t.Fatalf("%s call failed:  %d %s", encoding, c.Status, c.Response)
+		}
+		if c.Encoding != encoding || !bytes.Equal(c.BinaryPayload, payload) || c.Payload != nil {
+			t.Fatalf("%s payload was not stored as binary:  %+v", encoding, c)
+		}
+
+		err = writeCase(filepath.Join(dir, c.Name+".json"), c)
+		if err != nil {
+			t.Fatalf("failed to write %s case:  %v", encoding, err)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, c.Name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw map[string]interface{}
+		err = json.Unmarshal(data, &raw)
+		if err != nil {
+			t.Fatalf("%s golden file is not valid json:  %v", encoding, err)
+		}
+		if _, ok := raw["binaryPayload"].(string); !ok {
+			t.Errorf("%s golden file does not hold a base64 binaryPayload:  %s", encoding, data)
+		}
+	}
+
+	cases, err := Load(dir)
+	if err != nil || len(cases) != 2 {
+		t.Fatalf("expected 2 golden files, got %d:  %v", len(cases), err)
+	}
+	for _, c := range cases {
+		var response echoRequest
+		json.Unmarshal(c.Response, &response)
+		if response.Name != c.Encoding || response.Count != 3 {
+			t.Errorf("%s response was not decoded from the binary payload:  %s", c.Encoding, c.Response)
+		}
+	}
+
+	Replay(t, dir, Options{})
+}
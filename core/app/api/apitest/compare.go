@@ -0,0 +1,123 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maskedValue = "<masked>"
+
+//decodeResponse keeps numbers as json.Number so large ids are compared exactly.
+func decodeResponse(data []byte) (value interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	return
+}
+
+func parseMasks(masks []string) (parsed [][]string) {
+	for _, mask := range masks {
+		if mask != "" {
+			parsed = append(parsed, strings.Split(mask, "."))
+		}
+	}
+	return
+}
+
+//applyMasks replaces the values at masked paths with a placeholder.
+func applyMasks(value interface{}, path []string, masks [][]string) interface{} {
+
+	for _, mask := range masks {
+		if matchMask(mask, path) {
+			return maskedValue
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			typed[key] = applyMasks(child, append(path, key), masks)
+		}
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = applyMasks(child, append(path, strconv.Itoa(i)), masks)
+		}
+	}
+	return value
+}
+
+func matchMask(mask []string, path []string) bool {
+	if len(mask) == 0 {
+		return len(path) == 0
+	}
+
+	if mask[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchMask(mask[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 || (mask[0] != "*" && mask[0] != path[0]) {
+		return false
+	}
+	return matchMask(mask[1:], path[1:])
+}
+
+//diffValues appends a line for every path where the decoded json values differ.
+func diffValues(path string, expected interface{}, actual interface{}, diffs []string) []string {
+
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	actualMap, actualIsMap := actual.(map[string]interface{})
+	if expectedIsMap && actualIsMap {
+		keys := []string{}
+		for key := range expectedMap {
+			keys = append(keys, key)
+		}
+		for key := range actualMap {
+			if _, ok := expectedMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			expectedValue, inExpected := expectedMap[key]
+			actualValue, inActual := actualMap[key]
+			switch {
+			case !inActual:
+				diffs = append(diffs, path+"."+key+":  missing, expected "+toJSON(expectedValue))
+			case !inExpected:
+				diffs = append(diffs, path+"."+key+":  unexpected "+toJSON(actualValue))
+			default:
+				diffs = diffValues(path+"."+key, expectedValue, actualValue, diffs)
+			}
+		}
+		return diffs
+	}
+
+	expectedSlice, expectedIsSlice := expected.([]interface{})
+	actualSlice, actualIsSlice := actual.([]interface{})
+	if expectedIsSlice && actualIsSlice && len(expectedSlice) == len(actualSlice) {
+		for i := range expectedSlice {
+			diffs = diffValues(path+"["+strconv.Itoa(i)+"]", expectedSlice[i], actualSlice[i], diffs)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		diffs = append(diffs, path+":  expected "+toJSON(expected)+", got "+toJSON(actual))
+	}
+	return diffs
+}
+
+func toJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
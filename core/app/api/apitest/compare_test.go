@@ -0,0 +1,187 @@
+package apitest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchMask(t *testing.T) {
+	tests := []struct {
+		mask  string
+		path  string
+		match bool
+	}{
+		{"id", "id", true},
+		{"id", "name", false},
+		{"id", "user.id", false},
+		{"user.id", "user.id", true},
+		{"user.id", "user", false},
+		{"items.*.createdAt", "items.0.createdAt", true},
+		{"items.*.createdAt", "items.12.createdAt", true},
+		{"items.*.createdAt", "items.0.updatedAt", false},
+		{"items.*.createdAt", "items.0.child.createdAt", false},
+		{"*", "id", true},
+		{"*", "user.id", false},
+		{"**.Id", "Id", true},
+		{"**.Id", "user.Id", true},
+		{"**.Id", "items.3.owner.Id", true},
+		{"**.Id", "items.3.owner.Name", false},
+		{"**", "anything.at.all", true},
+		{"user.**", "user", true},
+		{"user.**", "user.address.zip", true},
+		{"user.**", "account.id", false},
+		{"**.items.*.id", "data.items.1.id", true},
+		{"**.items.*.id", "data.items.id", false},
+	}
+
+	for _, test := range tests {
+		var path []string
+		if test.path != "" {
+			path = strings.Split(test.path, ".")
+		}
+		match := matchMask(strings.Split(test.mask, "."), path)
+		if match != test.match {
+			t.Errorf("matchMask(%q, %q) = %v, expected %v", test.mask, test.path, match, test.match)
+		}
+	}
+}
+
+func TestApplyMasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		masks    []string
+		expected string
+	}{
+		{
+			name:     "no masks",
+			value:    `{"id":1,"name":"a"}`,
+			expected: `{"id":1,"name":"a"}`,
+		},
+		{
+			name:     "top level key",
+			value:    `{"id":1,"name":"a"}`,
+			masks:    []string{"id"},
+			expected: `{"id":"<masked>","name":"a"}`,
+		},
+		{
+			name:     "array index wildcard",
+			value:    `{"items":[{"createdAt":"x","n":1},{"createdAt":"y","n":2}]}`,
+			masks:    []string{"items.*.createdAt"},
+			expected: `{"items":[{"createdAt":"<masked>","n":1},{"createdAt":"<masked>","n":2}]}`,
+		},
+		{
+			name:     "any depth",
+			value:    `{"Id":"a","user":{"Id":"b","friends":[{"Id":"c"}]}}`,
+			masks:    []string{"**.Id"},
+			expected: `{"Id":"<masked>","user":{"Id":"<masked>","friends":[{"Id":"<masked>"}]}}`,
+		},
+		{
+			name:     "whole object",
+			value:    `{"meta":{"a":1,"b":[1,2]},"keep":true}`,
+			masks:    []string{"meta"},
+			expected: `{"meta":"<masked>","keep":true}`,
+		},
+		{
+			name:     "missing path",
+			value:    `{"id":1}`,
+			masks:    []string{"user.id", ""},
+			expected: `{"id":1}`,
+		},
+		{
+			name:     "root array",
+			value:    `[{"id":1},{"id":2}]`,
+			masks:    []string{"*.id"},
+			expected: `[{"id":"<masked>"},{"id":"<masked>"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		value, err := decodeResponse([]byte(test.value))
+		if err != nil {
+			t.Fatalf("%s:  failed to decode value:  %v", test.name, err)
+		}
+		expected, err := decodeResponse([]byte(test.expected))
+		if err != nil {
+			t.Fatalf("%s:  failed to decode expected:  %v", test.name, err)
+		}
+
+		masked := applyMasks(value, nil, parseMasks(test.masks))
+		if !reflect.DeepEqual(masked, expected) {
+			t.Errorf("%s:  got %s, expected %s", test.name, toJSON(masked), test.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Case
+		actual   Case
+		masks    []string
+		diffs    []string
+	}{
+		{
+			name:     "equal",
+			expected: Case{Status: 200, Response: json.RawMessage(`{"a":1,"b":[1,2]}`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`{"b":[1,2],"a":1}`)},
+		},
+		{
+			name:     "status",
+			expected: Case{Status: 200, Response: json.RawMessage(`{}`)},
+			actual:   Case{Status: 500, Response: json.RawMessage(`{}`)},
+			diffs:    []string{"status:  expected 200, got 500"},
+		},
+		{
+			name:     "changed value",
+			expected: Case{Status: 200, Response: json.RawMessage(`{"user":{"name":"a"}}`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`{"user":{"name":"b"}}`)},
+			diffs:    []string{`response.user.name:  expected "a", got "b"`},
+		},
+		{
+			name:     "missing and unexpected keys",
+			expected: Case{Status: 200, Response: json.RawMessage(`{"a":1}`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`{"b":2}`)},
+			diffs:    []string{"response.a:  missing, expected 1", "response.b:  unexpected 2"},
+		},
+		{
+			name:     "array element",
+			expected: Case{Status: 200, Response: json.RawMessage(`[1,2,3]`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`[1,5,3]`)},
+			diffs:    []string{"response[1]:  expected 2, got 5"},
+		},
+		{
+			name:     "array length",
+			expected: Case{Status: 200, Response: json.RawMessage(`[1,2]`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`[1]`)},
+			diffs:    []string{"response:  expected [1,2], got [1]"},
+		},
+		{
+			name:     "large numbers compare exactly",
+			expected: Case{Status: 200, Response: json.RawMessage(`{"id":9007199254740993}`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`{"id":9007199254740992}`)},
+			diffs:    []string{"response.id:  expected 9007199254740993, got 9007199254740992"},
+		},
+		{
+			name:     "masked",
+			expected: Case{Status: 200, Response: json.RawMessage(`{"id":"a","items":[{"at":1}]}`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`{"id":"b","items":[{"at":2}]}`)},
+			masks:    []string{"id", "items.*.at"},
+		},
+		{
+			name:     "invalid json",
+			expected: Case{Status: 200, Response: json.RawMessage(`{`)},
+			actual:   Case{Status: 200, Response: json.RawMessage(`{}`)},
+			diffs:    []string{"expected response is not valid json:  unexpected EOF"},
+		},
+	}
+
+	for _, test := range tests {
+		diffs := Compare(test.expected, test.actual, test.masks)
+		if !reflect.DeepEqual(diffs, test.diffs) {
+			t.Errorf("%s:  got diffs %q, expected %q", test.name, diffs, test.diffs)
+		}
+	}
+}
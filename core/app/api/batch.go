@@ -0,0 +1,271 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gin-gonic/gin"
+)
+
+//Error codes for batches.  ErrCodeBatchAborted is returned for items of an atomic batch that did not run because an earlier item failed.
+const (
+	ErrCodeBatchAborted  = "batchAborted"
+	ErrCodeBatchTooLarge = "batchTooLarge"
+)
+
+//defaultMaxBatchSize caps the items of a batch when webConfig.json application.maxBatchSize is 0.
+const defaultMaxBatchSize = 100
+
+//BatchTransaction is satisfied by the generated model Transaction.  Discard is called when an atomic batch is not committed.
+type BatchTransaction interface {
+	Commit() error
+	Discard() error
+}
+
+//BatchTransactionFactory creates the model Transaction shared by every item of an atomic batch.
+//It must be set by the application before atomic batches can be used, typically:
+//	api.BatchTransactionFactory = func(rc *api.RequestContext) (api.BatchTransaction, error) {
+//		return model.Transactions.New(rc.Session("UserId"))
+//	}
+//Actions receive the shared transaction by declaring a parameter of its type (ie *model.Transaction) or from RequestContext.Transaction.
+var BatchTransactionFactory func(rc *RequestContext) (BatchTransaction, error)
+
+type batchRequest struct {
+	Atomic     bool         `json:"atomic"`
+	Sequential bool         `json:"sequential"`
+	Items      []apiRequest `json:"items"`
+}
+
+type batchResponse struct {
+	Committed bool          `json:"committed,omitempty"`
+	Error     *errorObj     `json:"error,omitempty"`
+	Results   []batchResult `json:"results"`
+}
+
+type batchResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data"`
+}
+
+/*BatchAPICallback executes an array of controller calls posted in a single request.
+Items run concurrently unless sequential is set.  Atomic batches run sequentially on one shared model Transaction
+that is only committed when every item succeeds and is discarded otherwise.  Only the changes saved with that Transaction are discarded,
+anything an earlier item wrote without it (ie a Save outside the transaction, an email or a call to another service) is not rolled back.
+Atomic batches are refused with notImplemented when the default dbConnection uses the bolt driver, it does not support transactions.
+A batch may hold up to webConfig.json application.maxBatchSize items, 100 by default.
+Body example-----------
+{"atomic": false, "sequential": false, "items": [{"controller": "Users", "action": "Get", "state": {...}}]}
+Implementation example-----------
+ginServer.Router.POST("/apiBatch", api.BatchAPICallback)
+---------------------------------
+*/
+func BatchAPICallback(c *gin.Context) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("Panic Stack at batch.BatchAPICallback: " + string(debug.Stack()))
+			c.JSON(http.StatusInternalServerError, errorResponseFromPanic("Recover Error:  "+fmt.Sprintf("%+v", r)))
+		}
+	}()
+
+	body, _ := ginServer.GetRequestBody(c)
+
+	var request batchRequest
+	err := app.UnmarshalEncoding(app.EncodingFromContentType(c.GetHeader("Content-Type")), body, &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(ErrCodeInvalidPayload, "Failed to unmarshal batchRequest:  "+err.Error()))
+		return
+	}
+
+	response, httpStatus := processBatch(request, c, nil)
+	processHTTPResponse(response, ErrorResponse{}, httpStatus, c)
+}
+
+//processBatch runs every item of the batch through processRequest.
+func processBatch(request batchRequest, c *gin.Context, conn *app.WebSocketConnection) (response batchResponse, httpStatus int) {
+
+	maxSize := getMaxBatchSize()
+	if len(request.Items) > maxSize {
+		e := newErrorResponse(ErrCodeBatchTooLarge, "Batch of "+strconv.Itoa(len(request.Items))+" items exceeds the maximum of "+strconv.Itoa(maxSize)+".")
+		response.Error = e.Error
+		return response, http.StatusRequestEntityTooLarge
+	}
+
+	response.Results = make([]batchResult, len(request.Items))
+	httpStatus = http.StatusOK
+
+	//Items get their own gin.Context so concurrent items do not share the Keys map or the response headers.
+	contexts := make([]*RequestContext, len(request.Items))
+	invalid := make([]*ErrorResponse, len(request.Items))
+	for i, item := range request.Items {
+		data, err := json.Marshal(item.State)
+		if err != nil {
+			e := newErrorResponse(ErrCodeInvalidPayload, "Failed to marshal the state of batch item "+strconv.Itoa(i)+":  "+err.Error())
+			invalid[i] = &e
+		}
+		contexts[i] = newRequestContext(item.Controller, item.Action, data, copyBatchGinContext(c), conn)
+	}
+
+	//invoke runs the item or returns the error of a state that could not be marshalled.
+	invoke := func(i int) (y interface{}, e ErrorResponse, status int) {
+		if invalid[i] != nil {
+			return nil, *invalid[i], http.StatusBadRequest
+		}
+		return invokeRequest(contexts[i])
+	}
+
+	if !request.Atomic {
+		run := func(i int) {
+			y, e, status := invoke(i)
+			response.Results[i] = newBatchResult(i, y, e, status)
+		}
+
+		if request.Sequential {
+			for i := range contexts {
+				run(i)
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range contexts {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(i)
+			}(i)
+		}
+		wg.Wait()
+		return
+	}
+
+	if BatchTransactionFactory == nil {
+		e := newErrorResponse(ErrCodeNotImplemented, "Atomic batches require api.BatchTransactionFactory to be set.")
+		response.Error = e.Error
+		return response, http.StatusNotImplemented
+	}
+
+	if serverSettings.GetWebConfig().DbConnection.Driver == dbServices.DATABASE_DRIVER_BOLTDB {
+		e := newErrorResponse(ErrCodeNotImplemented, "Atomic batches are not supported by the "+dbServices.DATABASE_DRIVER_BOLTDB+" driver.")
+		response.Error = e.Error
+		return response, http.StatusNotImplemented
+	}
+
+	tran, err := BatchTransactionFactory(newRequestContext("", "", nil, c, conn))
+	if err != nil {
+		e, status := errorResponseFromError(err)
+		response.Error = e.Error
+		return response, status
+	}
+
+	failed := false
+	failedStatus := http.StatusOK
+	for i, rc := range contexts {
+		if failed {
+			e := newErrorResponse(ErrCodeBatchAborted, "Not executed because an earlier item in the atomic batch failed.")
+			response.Results[i] = newBatchResult(i, nil, e, http.StatusFailedDependency)
+			continue
+		}
+
+		rc.transaction = tran
+		y, e, status := invoke(i)
+		response.Results[i] = newBatchResult(i, y, e, status)
+		if y == nil || status >= http.StatusBadRequest {
+			failed = true
+			failedStatus = status
+		}
+	}
+
+	if failed {
+		discardBatchTransaction(tran)
+		e := newErrorResponse(ErrCodeBatchAborted, "Atomic batch was not committed because an item failed.")
+		response.Error = e.Error
+		return response, failedStatus
+	}
+
+	err = tran.Commit()
+	if err != nil {
+		discardBatchTransaction(tran)
+		e, status := errorResponseFromError(err)
+		response.Error = e.Error
+		return response, status
+	}
+	response.Committed = true
+	return
+}
+
+//discardBatchTransaction discards the Transaction of an atomic batch that was not committed.
+func discardBatchTransaction(tran BatchTransaction) {
+	err := tran.Discard()
+	if err != nil {
+		log.Println("Failed to discard the transaction of an atomic batch:  " + err.Error())
+	}
+}
+
+func getMaxBatchSize() int {
+	size := serverSettings.GetWebConfig().Application.MaxBatchSize
+	if size <= 0 {
+		return defaultMaxBatchSize
+	}
+	return size
+}
+
+//batchItemWriter keeps the headers an item sets to itself and discards its writes.  The batch writes a single response.
+type batchItemWriter struct {
+	gin.ResponseWriter
+	header http.Header
+}
+
+func (w *batchItemWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchItemWriter) WriteHeader(code int) {}
+
+func (w *batchItemWriter) WriteHeaderNow() {}
+
+func (w *batchItemWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *batchItemWriter) WriteString(s string) (int, error) {
+	return len(s), nil
+}
+
+//copyBatchGinContext copies the gin.Context and its Keys for a batch item.
+func copyBatchGinContext(c *gin.Context) *gin.Context {
+	if c == nil {
+		return nil
+	}
+
+	cp := c.Copy()
+	cp.Writer = &batchItemWriter{ResponseWriter: c.Writer, header: make(http.Header)}
+	if c.Keys != nil {
+		cp.Keys = make(map[string]interface{}, len(c.Keys))
+		for key, value := range c.Keys {
+			cp.Keys[key] = value
+		}
+	}
+	return cp
+}
+
+func newBatchResult(index int, y interface{}, e ErrorResponse, httpStatus int) (result batchResult) {
+	result.Index = index
+	result.Status = httpStatus
+	if y == nil {
+		result.Data = e
+	} else {
+		result.Data = y
+	}
+	return
+}
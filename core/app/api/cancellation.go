@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/app"
+)
+
+//StatusClientClosedRequest is the http status reported when a call is cancelled by the client.
+const StatusClientClosedRequest = 499
+
+//Error codes for cancelled and timed out calls.
+const (
+	ErrCodeCancelled = "cancelled"
+	ErrCodeTimeout   = "timeout"
+)
+
+var actionTimeouts sync.Map
+var socketCalls sync.Map
+
+//SetControllerTimeout sets the deadline for every action of a controller key.
+func SetControllerTimeout(controller string, timeout time.Duration) {
+	actionTimeouts.Store(strings.Title(controller), timeout)
+}
+
+//SetActionTimeout sets the deadline for a single controller action and overrides the controller timeout.
+func SetActionTimeout(controller string, action string, timeout time.Duration) {
+	actionTimeouts.Store(actionKey(controller, action), timeout)
+}
+
+func getActionTimeout(controller string, action string) (timeout time.Duration, ok bool) {
+	obj, ok := actionTimeouts.Load(actionKey(controller, action))
+	if !ok {
+		obj, ok = actionTimeouts.Load(strings.Title(controller))
+	}
+	if ok {
+		timeout = obj.(time.Duration)
+	}
+	return
+}
+
+//withActionTimeout returns a cancellable context for the call with the configured deadline applied.
+func withActionTimeout(ctx context.Context, controller string, action string) (context.Context, context.CancelFunc) {
+	timeout, ok := getActionTimeout(controller, action)
+	if ok && timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func socketCallKey(conn *app.WebSocketConnection, callbackID int) string {
+	return conn.Id + ":" + strconv.Itoa(callbackID)
+}
+
+//trackSocketCall makes a web socket call cancellable by its callBackId.  The returned func must be called when the call completes.
+func trackSocketCall(rc *RequestContext, callbackID int) (done func()) {
+	key := socketCallKey(rc.Connection, callbackID)
+	ctx, cancel := context.WithCancel(rc.Context)
+	rc.Context = ctx
+	socketCalls.Store(key, cancel)
+
+	return func() {
+		socketCalls.Delete(key)
+		cancel()
+	}
+}
+
+//CancelSocketCall cancels the context of an in flight web socket call.  Clients send {"cancel": callBackId} to do the same.
+func CancelSocketCall(conn *app.WebSocketConnection, callbackID int) (ok bool) {
+	obj, ok := socketCalls.Load(socketCallKey(conn, callbackID))
+	if ok {
+		obj.(context.CancelFunc)()
+	}
+	return
+}
@@ -0,0 +1,500 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+/*GenerateClients writes a typed Go client package and a TypeScript module for every registered controller action.
+Files are only rewritten when their contents change so it can run on every start in development, keeping the clients
+in sync with the controllers so type drift is caught when the clients are compiled.  Pass an empty path to skip a client.
+Implementation example-----------
+api.RegisterController(&controllers.Users{})
+api.GenerateClients("src/github.com/myApp/apiClient/client.go", "web/app/apiClient.ts")
+---------------------------------
+*/
+func GenerateClients(goPath string, typeScriptPath string) (err error) {
+	if goPath != "" {
+		err = GenerateGoClient(goPath, filepath.Base(filepath.Dir(goPath)))
+		if err != nil {
+			return
+		}
+	}
+	if typeScriptPath != "" {
+		err = GenerateTypeScriptClient(typeScriptPath)
+	}
+	return
+}
+
+//GenerateGoClient writes a Go client package with one method per controller action and typed request and response structs.
+func GenerateGoClient(filePath string, packageName string) error {
+
+	source, err := goClientSource(packageName)
+	if err != nil {
+		return err
+	}
+	return writeClientFile(filePath, source)
+}
+
+//clientTypes assigns unique client names to the named structs used by controller actions.
+type clientTypes struct {
+	names map[reflect.Type]string
+	taken map[string]bool
+	order []reflect.Type
+}
+
+func newClientTypes(reserved []string) *clientTypes {
+	ct := &clientTypes{names: make(map[reflect.Type]string), taken: make(map[string]bool)}
+	for _, name := range reserved {
+		ct.taken[name] = true
+	}
+	return ct
+}
+
+//name returns the client name of a named struct and queues its definition.
+func (ct *clientTypes) name(t reflect.Type) string {
+
+	name, ok := ct.names[t]
+	if ok {
+		return name
+	}
+
+	name = t.Name()
+	if ct.taken[name] {
+		name = strings.Title(path.Base(t.PkgPath())) + t.Name()
+	}
+	for i := 2; ct.taken[name]; i++ {
+		name = t.Name() + strconv.Itoa(i)
+	}
+
+	ct.taken[name] = true
+	ct.names[t] = name
+	ct.order = append(ct.order, t)
+	return name
+}
+
+//clientActions returns the sorted actions of a controller with their request and response types.
+func clientActions(key string) (actions []clientAction) {
+	ctl := getController(key)
+	ctlType := ctl.Type()
+	for i := 0; i < ctlType.NumMethod(); i++ {
+		methodType := ctl.Method(i).Type()
+		actions = append(actions, clientAction{
+			Name:     ctlType.Method(i).Name,
+			Request:  getActionParamType(methodType),
+			Response: getActionResultType(methodType),
+		})
+	}
+	return
+}
+
+type clientAction struct {
+	Name     string
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+//clientMarshalerKind reports how a type with its own json marshaling is represented by clients.
+func clientMarshalerKind(t reflect.Type) (custom bool, isString bool) {
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return true, t.Kind() == reflect.String
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return true, true
+	}
+	return false, false
+}
+
+type goClientWriter struct {
+	types   *clientTypes
+	imports map[string]bool
+}
+
+func goClientSource(packageName string) ([]byte, error) {
+
+	keys := getControllerKeys()
+
+	reserved := []string{"Client", "New", "Transport", "Error", "HTTPTransport", "WebSocketTransport", "DialWebSocket", "NewWebSocketTransport"}
+	for _, key := range keys {
+		reserved = append(reserved, strings.Title(key)+"Controller")
+	}
+
+	w := goClientWriter{types: newClientTypes(reserved), imports: make(map[string]bool)}
+
+	var controllers bytes.Buffer
+	var fields bytes.Buffer
+	var constructors bytes.Buffer
+
+	for _, key := range keys {
+		name := strings.Title(key) + "Controller"
+
+		fields.WriteString("\t" + strings.Title(key) + " *" + name + "\n")
+		constructors.WriteString("\t\t" + strings.Title(key) + ": &" + name + "{transport: transport},\n")
+
+		controllers.WriteString("//" + name + " calls the " + key + " controller actions.\n")
+		controllers.WriteString("type " + name + " struct {\n\ttransport Transport\n}\n\n")
+
+		for _, action := range clientActions(key) {
+			request := "nil"
+			params := "ctx context.Context"
+			if action.Request != nil {
+				request = "request"
+				params += ", request " + w.typeName(action.Request)
+			}
+
+			controllers.WriteString("//" + action.Name + " calls " + key + "." + action.Name + ".\n")
+			if action.Response == nil {
+				controllers.WriteString("func (c *" + name + ") " + action.Name + "(" + params + ") error {\n")
+				controllers.WriteString("\treturn c.transport.Call(ctx, " + strconv.Quote(key) + ", " + strconv.Quote(action.Name) + ", " + request + ", nil)\n}\n\n")
+				continue
+			}
+			controllers.WriteString("func (c *" + name + ") " + action.Name + "(" + params + ") (response " + w.typeName(action.Response) + ", err error) {\n")
+			controllers.WriteString("\terr = c.transport.Call(ctx, " + strconv.Quote(key) + ", " + strconv.Quote(action.Name) + ", " + request + ", &response)\n\treturn\n}\n\n")
+		}
+	}
+
+	var types bytes.Buffer
+	for i := 0; i < len(w.types.order); i++ {
+		t := w.types.order[i]
+		name := w.types.names[t]
+		types.WriteString("//" + name + " is generated from " + t.PkgPath() + "." + t.Name() + ".\n")
+		types.WriteString("type " + name + " " + w.structType(t) + "\n\n")
+	}
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by GoCore api.GenerateGoClient. DO NOT EDIT.\n\n")
+	source.WriteString("//Package " + packageName + " is a typed client for the GoCore controller api.\n")
+	source.WriteString("package " + packageName + "\n\n")
+	source.WriteString("import (\n")
+	source.WriteString(goClientImports)
+	if w.imports["time"] {
+		source.WriteString("\t\"time\"\n")
+	}
+	source.WriteString("\n\t\"github.com/gorilla/websocket\"\n)\n\n")
+	source.WriteString("//Client provides a typed method for every registered controller action.\n")
+	source.WriteString("type Client struct {\n" + fields.String() + "}\n\n")
+	source.WriteString("//New creates a Client that sends calls with the transport.\n")
+	source.WriteString("func New(transport Transport) *Client {\n\treturn &Client{\n" + constructors.String() + "\t}\n}\n\n")
+	source.Write(controllers.Bytes())
+	source.Write(types.Bytes())
+	source.WriteString(goClientTransports)
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated go client:  %v", err)
+	}
+	return formatted, nil
+}
+
+//typeName returns the Go client type for a go type.
+func (w *goClientWriter) typeName(t reflect.Type) string {
+
+	if t.Kind() == reflect.Ptr {
+		return "*" + w.typeName(t.Elem())
+	}
+	if t == timeType {
+		w.imports["time"] = true
+		return "time.Time"
+	}
+	if custom, isString := clientMarshalerKind(t); custom {
+		if isString {
+			return "string"
+		}
+		return "json.RawMessage"
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return w.structType(t)
+		}
+		return w.types.name(t)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "[]byte"
+		}
+		return "[]" + w.typeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + w.typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + w.typeName(t.Key()) + "]" + w.typeName(t.Elem())
+	case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return "interface{}"
+	}
+	return t.Kind().String()
+}
+
+func (w *goClientWriter) structType(t reflect.Type) string {
+	var buf bytes.Buffer
+	buf.WriteString("struct {\n")
+	for _, field := range getJSONFields(t) {
+		tag := field.Field.Tag.Get("json")
+		if tag == "" {
+			tag = field.Name
+		}
+		buf.WriteString("\t" + field.Field.Name + " " + w.typeName(field.Field.Type) + " `json:" + strconv.Quote(tag) + "`\n")
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+//writeClientFile writes a generated client unless the file already has the same contents.
+func writeClientFile(filePath string, data []byte) error {
+
+	existing, err := ioutil.ReadFile(filePath)
+	if err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0777)
+	if err != nil {
+		log.Println("Error creating directory for " + filePath + ":  " + err.Error())
+		return err
+	}
+
+	err = ioutil.WriteFile(filePath, data, 0777)
+	if err != nil {
+		log.Println("Error writing file " + filePath + ":  " + err.Error())
+		return err
+	}
+	log.Println("Saved file " + filePath + " successfully.")
+	return nil
+}
+
+const goClientImports = `	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+`
+
+const goClientTransports = `//Transport sends a controller action request and decodes its response.
+type Transport interface {
+	Call(ctx context.Context, controller string, action string, request interface{}, response interface{}) error
+}
+
+//Error is returned when the api responds with an error.
+type Error struct {
+	Status     int             ` + "`json:\"-\"`" + `
+	Code       string          ` + "`json:\"code\"`" + `
+	Message    string          ` + "`json:\"Message\"`" + `
+	Details    json.RawMessage ` + "`json:\"details,omitempty\"`" + `
+	Stacktrace string          ` + "`json:\"stackTrace,omitempty\"`" + `
+}
+
+func (e *Error) Error() string {
+	return strconv.Itoa(e.Status) + " " + e.Code + ":  " + e.Message
+}
+
+type errorResponse struct {
+	Error *Error ` + "`json:\"error\"`" + `
+}
+
+func decodeResponse(status int, data []byte, response interface{}) error {
+	if status >= http.StatusBadRequest {
+		var e errorResponse
+		json.Unmarshal(data, &e)
+		if e.Error == nil {
+			e.Error = &Error{Message: string(data)}
+		}
+		e.Error.Status = status
+		return e.Error
+	}
+	if response == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, response)
+}
+
+//HTTPTransport posts calls to the api route, ie http://localhost/api.
+type HTTPTransport struct {
+	URL    string
+	Header http.Header
+	Client *http.Client
+}
+
+//Call posts the request to the controller action.
+func (t *HTTPTransport) Call(ctx context.Context, controller string, action string, request interface{}, response interface{}) error {
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL+"?controller="+url.QueryEscape(controller)+"&action="+url.QueryEscape(action), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range t.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp.StatusCode, data, response)
+}
+
+//WebSocketTransport sends calls over a web socket to api.SocketAPICallback and correlates responses by callBackId.
+type WebSocketTransport struct {
+	conn       *websocket.Conn
+	onMessage  func(data []byte)
+	writeLock  sync.Mutex
+	lock       sync.Mutex
+	callbackID int
+	pending    map[int]chan socketResponse
+	err        error
+	closed     chan struct{}
+}
+
+type socketRequest struct {
+	CallbackID int                ` + "`json:\"callBackId\"`" + `
+	Data       *socketRequestData ` + "`json:\"data,omitempty\"`" + `
+	Cancel     *int               ` + "`json:\"cancel,omitempty\"`" + `
+}
+
+type socketRequestData struct {
+	Controller string      ` + "`json:\"controller\"`" + `
+	Action     string      ` + "`json:\"action\"`" + `
+	State      interface{} ` + "`json:\"state\"`" + `
+}
+
+type socketResponse struct {
+	CallbackID int             ` + "`json:\"callBackId\"`" + `
+	Status     int             ` + "`json:\"status\"`" + `
+	Data       json.RawMessage ` + "`json:\"data\"`" + `
+}
+
+//DialWebSocket connects to the web socket route.  onMessage receives every message that is not a call response and may be nil.
+func DialWebSocket(urlStr string, header http.Header, onMessage func(data []byte)) (*WebSocketTransport, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(urlStr, header)
+	if err != nil {
+		return nil, err
+	}
+	return NewWebSocketTransport(conn, onMessage), nil
+}
+
+//NewWebSocketTransport starts reading responses from an open web socket connection.
+func NewWebSocketTransport(conn *websocket.Conn, onMessage func(data []byte)) *WebSocketTransport {
+	t := &WebSocketTransport{
+		conn:      conn,
+		onMessage: onMessage,
+		pending:   make(map[int]chan socketResponse),
+		closed:    make(chan struct{}),
+	}
+	go t.read()
+	return t
+}
+
+//Call sends the request and waits for the response with the same callBackId.  Cancelling ctx cancels the call on the server.
+func (t *WebSocketTransport) Call(ctx context.Context, controller string, action string, request interface{}, response interface{}) error {
+
+	t.lock.Lock()
+	if t.err != nil {
+		err := t.err
+		t.lock.Unlock()
+		return err
+	}
+	t.callbackID++
+	id := t.callbackID
+	ch := make(chan socketResponse, 1)
+	t.pending[id] = ch
+	t.lock.Unlock()
+
+	err := t.send(socketRequest{CallbackID: id, Data: &socketRequestData{Controller: controller, Action: action, State: request}})
+	if err != nil {
+		t.forget(id)
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		return decodeResponse(resp.Status, resp.Data, response)
+	case <-t.closed:
+		t.forget(id)
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		return t.err
+	case <-ctx.Done():
+		t.forget(id)
+		t.send(socketRequest{Cancel: &id})
+		return ctx.Err()
+	}
+}
+
+//Close closes the web socket connection.
+func (t *WebSocketTransport) Close() error {
+	return t.conn.Close()
+}
+
+func (t *WebSocketTransport) send(request socketRequest) error {
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	return t.conn.WriteJSON(request)
+}
+
+func (t *WebSocketTransport) forget(id int) {
+	t.lock.Lock()
+	delete(t.pending, id)
+	t.lock.Unlock()
+}
+
+func (t *WebSocketTransport) read() {
+	for {
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			t.lock.Lock()
+			t.err = err
+			t.lock.Unlock()
+			close(t.closed)
+			return
+		}
+
+		var resp socketResponse
+		if json.Unmarshal(data, &resp) == nil && resp.CallbackID != 0 {
+			t.lock.Lock()
+			ch, ok := t.pending[resp.CallbackID]
+			delete(t.pending, resp.CallbackID)
+			t.lock.Unlock()
+			if ok {
+				ch <- resp
+				continue
+			}
+		}
+
+		if t.onMessage != nil {
+			t.onMessage(data)
+		}
+	}
+}
+`
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DanielRenne/GoCore/core/extensions"
+)
+
+var typeScriptIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+//GenerateTypeScriptClient writes a TypeScript module with one method per controller action and an interface for every struct they use.
+func GenerateTypeScriptClient(filePath string) error {
+	return writeClientFile(filePath, typeScriptClientSource())
+}
+
+type typeScriptClientWriter struct {
+	types *clientTypes
+}
+
+func typeScriptClientSource() []byte {
+
+	keys := getControllerKeys()
+
+	reserved := []string{"Client", "Transport", "ApiError", "HttpTransport", "WebSocketTransport"}
+	for _, key := range keys {
+		reserved = append(reserved, strings.Title(key)+"Controller")
+	}
+
+	w := typeScriptClientWriter{types: newClientTypes(reserved)}
+
+	var controllers bytes.Buffer
+	var fields bytes.Buffer
+	var constructors bytes.Buffer
+
+	for _, key := range keys {
+		name := strings.Title(key) + "Controller"
+		field := extensions.MakeFirstLowerCase(key)
+
+		fields.WriteString("  readonly " + field + ": " + name + ";\n")
+		constructors.WriteString("    this." + field + " = new " + name + "(transport);\n")
+
+		controllers.WriteString("/** Calls the " + key + " controller actions. */\n")
+		controllers.WriteString("export class " + name + " {\n  constructor(private transport: Transport) {}\n")
+
+		for _, action := range clientActions(key) {
+			params := ""
+			request := "undefined"
+			if action.Request != nil {
+				params = "request: " + w.typeName(action.Request) + ", "
+				request = "request"
+			}
+			response := "void"
+			if action.Response != nil {
+				response = w.typeName(action.Response)
+			}
+
+			controllers.WriteString("\n  /** Calls " + key + "." + action.Name + ". */\n")
+			controllers.WriteString("  " + extensions.MakeFirstLowerCase(action.Name) + "(" + params + "signal?: AbortSignal): Promise<" + response + "> {\n")
+			controllers.WriteString("    return this.transport.call<" + response + ">(" + strconv.Quote(key) + ", " + strconv.Quote(action.Name) + ", " + request + ", signal);\n  }\n")
+		}
+		controllers.WriteString("}\n\n")
+	}
+
+	var types bytes.Buffer
+	for i := 0; i < len(w.types.order); i++ {
+		t := w.types.order[i]
+		types.WriteString("/** Generated from " + t.PkgPath() + "." + t.Name() + ". */\n")
+		types.WriteString("export interface " + w.types.names[t] + " " + w.structType(t, "") + "\n\n")
+	}
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by GoCore api.GenerateTypeScriptClient. DO NOT EDIT.\n\n")
+	source.WriteString(typeScriptClientTransports)
+	source.WriteString("/** Provides a typed method for every registered controller action. */\n")
+	source.WriteString("export class Client {\n" + fields.String() + "\n  constructor(transport: Transport) {\n" + constructors.String() + "  }\n}\n\n")
+	source.Write(controllers.Bytes())
+	source.Write(bytes.TrimRight(types.Bytes(), "\n"))
+	source.WriteString("\n")
+	return source.Bytes()
+}
+
+//typeName returns the TypeScript type for a go type.
+func (w *typeScriptClientWriter) typeName(t reflect.Type) string {
+
+	if t.Kind() == reflect.Ptr {
+		return w.typeName(t.Elem()) + " | null"
+	}
+	if t == timeType {
+		return "string"
+	}
+	if custom, isString := clientMarshalerKind(t); custom {
+		if isString {
+			return "string"
+		}
+		return "any"
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return w.structType(t, "")
+		}
+		return w.types.name(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		elem := w.typeName(t.Elem())
+		if strings.Contains(elem, "|") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "{ [key: string]: " + w.typeName(t.Elem()) + " }"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "any"
+}
+
+func (w *typeScriptClientWriter) structType(t reflect.Type, indent string) string {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for _, field := range getJSONFields(t) {
+		name := field.Name
+		if !typeScriptIdentifier.MatchString(name) {
+			name = strconv.Quote(name)
+		}
+		if strings.Contains(field.Field.Tag.Get("json"), ",omitempty") {
+			name += "?"
+		}
+		fieldType := field.Field.Type
+		if fieldType.Kind() == reflect.Struct && fieldType.Name() == "" {
+			buf.WriteString(indent + "  " + name + ": " + w.structType(fieldType, indent+"  ") + ";\n")
+			continue
+		}
+		buf.WriteString(indent + "  " + name + ": " + w.typeName(fieldType) + ";\n")
+	}
+	buf.WriteString(indent + "}")
+	return buf.String()
+}
+
+const typeScriptClientTransports = `/** Thrown when the api responds with an error. */
+export class ApiError extends Error {
+  status: number;
+  code: string;
+  details?: any;
+  stackTrace?: string;
+
+  constructor(status: number, body: any) {
+    const error = (body && body.error) || {};
+    super(error.Message || "Request failed with status " + status);
+    this.status = status;
+    this.code = error.code || "";
+    this.details = error.details;
+    this.stackTrace = error.stackTrace;
+  }
+}
+
+/** Sends a controller action request and resolves its response. */
+export interface Transport {
+  call<T>(controller: string, action: string, request?: unknown, signal?: AbortSignal): Promise<T>;
+}
+
+/** Posts calls to the api route, ie http://localhost/api. */
+export class HttpTransport implements Transport {
+  constructor(private url: string, private init: RequestInit = {}) {}
+
+  async call<T>(controller: string, action: string, request?: unknown, signal?: AbortSignal): Promise<T> {
+    const headers = new Headers(this.init.headers);
+    headers.set("Content-Type", "application/json");
+    const response = await fetch(this.url + "?controller=" + encodeURIComponent(controller) + "&action=" + encodeURIComponent(action), {
+      ...this.init,
+      method: "POST",
+      headers,
+      body: JSON.stringify(request === undefined ? null : request),
+      signal,
+    });
+    const text = await response.text();
+    const body = text ? JSON.parse(text) : undefined;
+    if (!response.ok) {
+      throw new ApiError(response.status, body);
+    }
+    return body as T;
+  }
+}
+
+interface PendingCall {
+  resolve: (value: any) => void;
+  reject: (reason: unknown) => void;
+}
+
+/** Sends calls over a web socket to api.SocketAPICallback and correlates responses by callBackId. */
+export class WebSocketTransport implements Transport {
+  /** Receives every message that is not a call response, ie published messages. */
+  onMessage?: (message: any) => void;
+
+  private callbackId = 0;
+  private pending = new Map<number, PendingCall>();
+
+  constructor(private socket: WebSocket) {
+    socket.addEventListener("message", (event: MessageEvent) => this.receive(event.data));
+    socket.addEventListener("close", () => {
+      this.pending.forEach((call) => call.reject(new Error("web socket closed")));
+      this.pending.clear();
+    });
+  }
+
+  call<T>(controller: string, action: string, request?: unknown, signal?: AbortSignal): Promise<T> {
+    const callBackId = ++this.callbackId;
+    return new Promise<T>((resolve, reject) => {
+      if (signal) {
+        if (signal.aborted) {
+          reject(new DOMException("Aborted", "AbortError"));
+          return;
+        }
+        signal.addEventListener("abort", () => {
+          if (this.pending.delete(callBackId)) {
+            this.socket.send(JSON.stringify({ cancel: callBackId }));
+            reject(new DOMException("Aborted", "AbortError"));
+          }
+        });
+      }
+      this.pending.set(callBackId, { resolve, reject });
+      this.socket.send(JSON.stringify({ callBackId, data: { controller, action, state: request === undefined ? null : request } }));
+    });
+  }
+
+  private receive(data: any) {
+    let message = data;
+    if (typeof data === "string") {
+      try {
+        message = JSON.parse(data);
+      } catch (e) {
+        message = data;
+      }
+    }
+
+    const call = message && message.callBackId ? this.pending.get(message.callBackId) : undefined;
+    if (!call) {
+      if (this.onMessage) {
+        this.onMessage(message);
+      }
+      return;
+    }
+
+    this.pending.delete(message.callBackId);
+    if (message.status >= 400) {
+      call.reject(new ApiError(message.status, message.data));
+    } else {
+      call.resolve(message.data);
+    }
+  }
+}
+
+`
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/gin-gonic/gin"
+)
+
+//httpResponseEncoding returns the encoding named by the Accept header, falling back to the encoding of the request body.
+func httpResponseEncoding(c *gin.Context) string {
+	encoding := app.EncodingFromContentType(c.GetHeader("Accept"))
+	if encoding == app.EncodingJSON {
+		encoding = app.EncodingFromContentType(c.GetHeader("Content-Type"))
+	}
+	return encoding
+}
+
+//responseEncoding returns the encoding the response of the call is sent with.
+func responseEncoding(rc *RequestContext) string {
+	if rc.Connection == nil && rc.GinContext != nil && rc.GinContext.Request != nil {
+		return httpResponseEncoding(rc.GinContext)
+	}
+	if rc.Encoding == "" {
+		return app.EncodingJSON
+	}
+	return rc.Encoding
+}
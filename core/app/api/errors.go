@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+//Error codes returned by the router in ErrorResponse.Error.Code.
+const (
+	ErrCodeInternal       = "internal"
+	ErrCodeBadRequest     = "badRequest"
+	ErrCodeInvalidPayload = "invalidPayload"
+	ErrCodeNotImplemented = "notImplemented"
+	ErrCodeForbidden      = "forbidden"
+	ErrCodePanic          = "panic"
+	ErrCodeShuttingDown   = "shuttingDown"
+)
+
+//Error can be returned from a controller action to control the http status, code and details of the ErrorResponse.
+//Example:  return nil, api.NewError(http.StatusNotFound, "userNotFound", "User does not exist.")
+type Error struct {
+	HTTPStatus int
+	Code       string
+	Message    string
+	Details    interface{}
+}
+
+//NewError returns a new api Error.
+func NewError(httpStatus int, code string, message string) *Error {
+	return &Error{HTTPStatus: httpStatus, Code: code, Message: message}
+}
+
+//WithDetails sets optional details that are returned to the client with the error.
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type stackTracer interface {
+	ErrorStack() string
+}
+
+var errorInterfaceType = reflect.TypeOf((*error)(nil)).Elem()
+
+//newErrorResponse returns an ErrorResponse with the Error populated.
+func newErrorResponse(code string, message string) (e ErrorResponse) {
+	e.Error = new(errorObj)
+	e.Error.Code = code
+	e.Error.Message = message
+	return
+}
+
+//errorResponseFromError maps an error returned by an action into an ErrorResponse and http status.
+func errorResponseFromError(err error) (e ErrorResponse, httpStatus int) {
+	var apiErr *Error
+	if errors.Is(err, context.Canceled) {
+		e = newErrorResponse(ErrCodeCancelled, "The call was cancelled.")
+		httpStatus = StatusClientClosedRequest
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		e = newErrorResponse(ErrCodeTimeout, "The call exceeded its deadline.")
+		httpStatus = http.StatusGatewayTimeout
+	} else if errors.As(err, &apiErr) {
+		e = newErrorResponse(apiErr.Code, apiErr.Message)
+		e.Error.Details = apiErr.Details
+		httpStatus = apiErr.HTTPStatus
+		if httpStatus == 0 {
+			httpStatus = http.StatusInternalServerError
+		}
+		if e.Error.Code == "" {
+			e.Error.Code = ErrCodeInternal
+		}
+	} else {
+		e = newErrorResponse(ErrCodeInternal, err.Error())
+		httpStatus = http.StatusInternalServerError
+	}
+
+	var tracer stackTracer
+	if errors.As(err, &tracer) {
+		setStackTrace(&e, tracer.ErrorStack())
+	}
+	return
+}
+
+//errorResponseFromPanic maps a recovered panic into an ErrorResponse.
+func errorResponseFromPanic(message string) (e ErrorResponse) {
+	e = newErrorResponse(ErrCodePanic, message)
+	setStackTrace(&e, string(debug.Stack()))
+	return
+}
+
+//setStackTrace only includes stack traces in responses when coreDebugStackTrace is set in webConfig.json.
+func setStackTrace(e *ErrorResponse, stack string) {
+	debugStack := serverSettings.GetWebConfig().Application.CoreDebugStackTrace
+
+	if debugStack {
+		e.Error.Stacktrace = stack
+	}
+}
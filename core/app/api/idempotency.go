@@ -0,0 +1,401 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/asdine/storm"
+	"github.com/boltdb/bolt"
+	"github.com/globalsign/mgo"
+)
+
+//IdempotencyKeyHeader is the http header clients send to make a call idempotent.  Web socket calls send an idempotencyKey field.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+//IdempotentReplayedHeader is set to true on http responses that were replayed from the store.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+//Error codes for idempotency keys.
+const (
+	ErrCodeIdempotencyConflict   = "idempotencyConflict"
+	ErrCodeIdempotencyInProgress = "idempotencyInProgress"
+)
+
+const (
+	idempotencyCollection    = "GoCoreIdempotencyKeys"
+	idempotencyDefaultWindow = 24 * time.Hour
+	idempotencyPurgeInterval = time.Minute
+)
+
+//IdempotencyScope returns who a call belongs to so one caller cannot replay the stored responses of another.
+//By default calls are scoped to the session cookie or, without one, the web socket connection.  A call without a scope
+//runs without its idempotency key.
+var IdempotencyScope func(rc *RequestContext) string
+
+//IdempotencyRecord is the stored first response of an idempotent call.  Key is a hash of the scope and idempotency key of the call.
+//Completed is false while the first call is still running.
+type IdempotencyRecord struct {
+	Key       string    `json:"key" bson:"_id"`
+	Hash      string    `json:"hash" bson:"hash"`
+	Completed bool      `json:"completed" bson:"completed"`
+	Status    int       `json:"status" bson:"status"`
+	IsError   bool      `json:"isError" bson:"isError"`
+	Response  []byte    `json:"response" bson:"response"`
+	Expires   time.Time `json:"expires" bson:"expires"`
+}
+
+//IdempotencyStore persists idempotency records.  Reserve must be atomic so concurrent retries cannot both run the action.
+type IdempotencyStore interface {
+	//Reserve stores the record unless an unexpired record exists for the key, which is returned instead.
+	Reserve(record IdempotencyRecord) (existing *IdempotencyRecord, err error)
+	//Complete replaces the reserved record with the completed response.
+	Complete(record IdempotencyRecord) error
+	//Release removes a reservation so the call can be retried.
+	Release(key string) error
+}
+
+//IdempotencyStorage overrides the store of idempotency records.  By default records are stored in the configured
+//mongo or bolt database and in memory when neither is configured.
+var IdempotencyStorage IdempotencyStore
+
+var defaultIdempotencyStore IdempotencyStore
+var defaultIdempotencyStoreOnce sync.Once
+
+func getIdempotencyStore() IdempotencyStore {
+	if IdempotencyStorage != nil {
+		return IdempotencyStorage
+	}
+
+	defaultIdempotencyStoreOnce.Do(func() {
+		driver := serverSettings.GetWebConfig().DbConnection.Driver
+
+		switch {
+		case driver == dbServices.DATABASE_DRIVER_MONGODB && dbServices.ReadMongoDB() != nil:
+			defaultIdempotencyStore = new(mongoIdempotencyStore)
+		case driver == dbServices.DATABASE_DRIVER_BOLTDB && dbServices.BoltDB != nil:
+			defaultIdempotencyStore = new(boltIdempotencyStore)
+		default:
+			defaultIdempotencyStore = &memoryIdempotencyStore{records: make(map[string]IdempotencyRecord)}
+		}
+	})
+	return defaultIdempotencyStore
+}
+
+func getIdempotencyWindow() time.Duration {
+	seconds := serverSettings.GetWebConfig().Application.Idempotency.WindowSeconds
+	if seconds <= 0 {
+		return idempotencyDefaultWindow
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+//idempotencyHash identifies the controller action and payload sent with a key.
+func idempotencyHash(rc *RequestContext) string {
+	hash := sha256.New()
+	hash.Write([]byte(actionKey(rc.Controller, rc.Action) + "\n"))
+	hash.Write(rc.Data)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func getIdempotencyScope(rc *RequestContext) string {
+	if IdempotencyScope != nil {
+		return IdempotencyScope(rc)
+	}
+	if rc.GinContext != nil && rc.GinContext.Request != nil {
+		session := rateLimitSessionKey(rc, "")
+		if session != "" {
+			return "session:" + session
+		}
+	}
+	if rc.Connection != nil {
+		return "connection:" + rc.Connection.Id
+	}
+	return ""
+}
+
+//idempotencyStoreKey scopes the key of the call.  The scope is hashed so session cookies are not stored.
+func idempotencyStoreKey(scope string, key string) string {
+	hash := sha256.Sum256([]byte(scope + "\n" + key))
+	return hex.EncodeToString(hash[:])
+}
+
+//beginIdempotentCall reserves the idempotency key of the call.  It returns replayed true when the response was already
+//sent from the store or the key conflicts, otherwise the returned results func stores the response before sending it.
+//A key released for a retry stays reserved until a timed out action goroutine returns.
+func beginIdempotentCall(rc *RequestContext, releases *callReleases, results func(y interface{}, e ErrorResponse, httpStatus int)) (func(y interface{}, e ErrorResponse, httpStatus int), bool) {
+
+	scope := getIdempotencyScope(rc)
+	if scope == "" {
+		log.Println("Ignoring idempotency key " + rc.IdempotencyKey + " of " + actionKey(rc.Controller, rc.Action) + " because the call has no session or connection.")
+		return results, false
+	}
+
+	store := getIdempotencyStore()
+	record := IdempotencyRecord{
+		Key:     idempotencyStoreKey(scope, rc.IdempotencyKey),
+		Hash:    idempotencyHash(rc),
+		Expires: time.Now().Add(getIdempotencyWindow()),
+	}
+
+	existing, err := store.Reserve(record)
+	if err != nil {
+		log.Println("Failed to reserve idempotency key " + rc.IdempotencyKey + ":  " + err.Error())
+		return results, false
+	}
+
+	if existing != nil {
+		if existing.Hash != record.Hash {
+			results(nil, newErrorResponse(ErrCodeIdempotencyConflict, "Idempotency key "+rc.IdempotencyKey+" was already used with a different controller action or payload."), http.StatusUnprocessableEntity)
+			return results, true
+		}
+		if !existing.Completed {
+			results(nil, newErrorResponse(ErrCodeIdempotencyInProgress, "A call with idempotency key "+rc.IdempotencyKey+" is still in progress."), http.StatusConflict)
+			return results, true
+		}
+		replayIdempotentResponse(rc, *existing, results)
+		return results, true
+	}
+
+	return func(y interface{}, e ErrorResponse, httpStatus int) {
+		completeIdempotentCall(store, record, releases, y, e, httpStatus)
+		results(y, e, httpStatus)
+	}, false
+}
+
+//completeIdempotentCall stores the response.  Server errors, timeouts and cancellations release the key with the other
+//releases of the call so the call can be retried once it is no longer running.
+func completeIdempotentCall(store IdempotencyStore, record IdempotencyRecord, releases *callReleases, y interface{}, e ErrorResponse, httpStatus int) {
+
+	var err error
+	if httpStatus >= http.StatusInternalServerError || httpStatus == StatusClientClosedRequest {
+		releases.add(func() {
+			errRelease := store.Release(record.Key)
+			if errRelease != nil {
+				log.Println("Failed to release idempotency key " + record.Key + ":  " + errRelease.Error())
+			}
+		})
+	} else {
+		record.Completed = true
+		record.Status = httpStatus
+		record.IsError = y == nil
+		if record.IsError {
+			record.Response, err = json.Marshal(e)
+		} else {
+			record.Response, err = json.Marshal(y)
+		}
+		if err == nil {
+			err = store.Complete(record)
+		}
+	}
+
+	if err != nil {
+		log.Println("Failed to store idempotency key " + record.Key + ":  " + err.Error())
+	}
+}
+
+func replayIdempotentResponse(rc *RequestContext, record IdempotencyRecord, results func(y interface{}, e ErrorResponse, httpStatus int)) {
+
+	if rc.GinContext != nil && rc.Connection == nil {
+		rc.GinContext.Header(IdempotentReplayedHeader, "true")
+	}
+
+	if record.IsError {
+		var e ErrorResponse
+		json.Unmarshal(record.Response, &e)
+		results(nil, e, record.Status)
+		return
+	}
+
+	//Binary encodings would write json.RawMessage as bytes so the stored json is decoded first.
+	var y interface{} = json.RawMessage(record.Response)
+	if app.IsBinaryEncoding(responseEncoding(rc)) {
+		var value interface{}
+		json.Unmarshal(record.Response, &value)
+		y = value
+	}
+	results(y, ErrorResponse{}, record.Status)
+}
+
+type memoryIdempotencyStore struct {
+	sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+func (store *memoryIdempotencyStore) Reserve(record IdempotencyRecord) (existing *IdempotencyRecord, err error) {
+	store.Lock()
+	defer store.Unlock()
+
+	now := time.Now()
+	for key, value := range store.records {
+		if now.After(value.Expires) {
+			delete(store.records, key)
+		}
+	}
+
+	value, ok := store.records[record.Key]
+	if ok {
+		return &value, nil
+	}
+	store.records[record.Key] = record
+	return nil, nil
+}
+
+func (store *memoryIdempotencyStore) Complete(record IdempotencyRecord) error {
+	store.Lock()
+	store.records[record.Key] = record
+	store.Unlock()
+	return nil
+}
+
+func (store *memoryIdempotencyStore) Release(key string) error {
+	store.Lock()
+	delete(store.records, key)
+	store.Unlock()
+	return nil
+}
+
+type mongoIdempotencyStore struct {
+	indexOnce sync.Once
+}
+
+func (store *mongoIdempotencyStore) collection() *mgo.Collection {
+	collection := dbServices.ReadMongoDB().C(idempotencyCollection)
+	store.indexOnce.Do(func() {
+		err := collection.EnsureIndex(mgo.Index{Key: []string{"expires"}, ExpireAfter: time.Second, Background: true})
+		if err != nil {
+			log.Println("Failed to create index for " + idempotencyCollection + ".expires:  " + err.Error())
+		}
+	})
+	return collection
+}
+
+func (store *mongoIdempotencyStore) Reserve(record IdempotencyRecord) (existing *IdempotencyRecord, err error) {
+	collection := store.collection()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		err = collection.Insert(record)
+		if err == nil || !mgo.IsDup(err) {
+			return
+		}
+
+		var found IdempotencyRecord
+		err = collection.FindId(record.Key).One(&found)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if time.Now().Before(found.Expires) {
+			return &found, nil
+		}
+
+		//The TTL monitor has not removed the expired record yet.
+		err = collection.Remove(map[string]interface{}{"_id": found.Key, "expires": found.Expires})
+		if err != nil && err != mgo.ErrNotFound {
+			return
+		}
+	}
+	return
+}
+
+func (store *mongoIdempotencyStore) Complete(record IdempotencyRecord) error {
+	return store.collection().UpdateId(record.Key, record)
+}
+
+func (store *mongoIdempotencyStore) Release(key string) error {
+	err := store.collection().RemoveId(key)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+type boltIdempotencyStore struct {
+	sync.Mutex
+	purged time.Time
+}
+
+func (store *boltIdempotencyStore) Reserve(record IdempotencyRecord) (existing *IdempotencyRecord, err error) {
+	store.purgeExpired()
+
+	tx, err := dbServices.BoltDB.Begin(true)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var found IdempotencyRecord
+	err = tx.Get(idempotencyCollection, record.Key, &found)
+	if err == nil && time.Now().Before(found.Expires) {
+		return &found, nil
+	}
+	if err != nil && err != storm.ErrNotFound {
+		return
+	}
+
+	err = tx.Set(idempotencyCollection, record.Key, record)
+	if err != nil {
+		return
+	}
+	err = tx.Commit()
+	return
+}
+
+func (store *boltIdempotencyStore) Complete(record IdempotencyRecord) error {
+	return dbServices.BoltDB.Set(idempotencyCollection, record.Key, record)
+}
+
+func (store *boltIdempotencyStore) Release(key string) error {
+	err := dbServices.BoltDB.Delete(idempotencyCollection, key)
+	if err == storm.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+//purgeExpired deletes the expired records at most once a minute like the memory store does on Reserve.
+func (store *boltIdempotencyStore) purgeExpired() {
+	store.Lock()
+	if time.Since(store.purged) < idempotencyPurgeInterval {
+		store.Unlock()
+		return
+	}
+	store.purged = time.Now()
+	store.Unlock()
+
+	now := time.Now()
+	err := dbServices.BoltDB.Bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(idempotencyCollection))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for key, value := cursor.First(); key != nil; {
+			var record IdempotencyRecord
+			err := dbServices.BoltDB.Codec.Decode(value, &record)
+			if err == nil && now.After(record.Expires) {
+				err = cursor.Delete()
+				if err != nil {
+					return err
+				}
+				key, value = cursor.Seek(key)
+				continue
+			}
+			key, value = cursor.Next()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Failed to purge expired records from " + idempotencyCollection + ":  " + err.Error())
+	}
+}
@@ -0,0 +1,66 @@
+// +build !race
+
+//The vendored bolt version fails the checkptr checks the race detector enables.
+
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/asdine/storm"
+)
+
+func TestBoltIdempotencyStorePurgesExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idempotency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := storm.Open(filepath.Join(dir, "bolt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	boltDB := dbServices.BoltDB
+	dbServices.BoltDB = db
+	defer func() {
+		dbServices.BoltDB = boltDB
+	}()
+
+	now := time.Now()
+	for _, record := range []IdempotencyRecord{
+		{Key: "a", Expires: now.Add(-time.Minute)},
+		{Key: "b", Expires: now.Add(time.Hour)},
+		{Key: "c", Expires: now.Add(-time.Minute)},
+		{Key: "d", Expires: now.Add(-time.Minute)},
+	} {
+		err = db.Set(idempotencyCollection, record.Key, record)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := new(boltIdempotencyStore)
+	existing, err := store.Reserve(IdempotencyRecord{Key: "e", Expires: now.Add(time.Hour)})
+	if err != nil || existing != nil {
+		t.Fatalf("expected the key to be reserved, got %+v %v", existing, err)
+	}
+
+	for key, kept := range map[string]bool{"a": false, "b": true, "c": false, "d": false, "e": true} {
+		var record IdempotencyRecord
+		err = db.Get(idempotencyCollection, key, &record)
+		if kept && err != nil {
+			t.Errorf("expected %s to be kept, got %v", key, err)
+		}
+		if !kept && err != storm.ErrNotFound {
+			t.Errorf("expected %s to be purged, got %v", key, err)
+		}
+	}
+}
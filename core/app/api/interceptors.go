@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//Interceptor runs before and after controller actions.  Either function may be left nil.
+//Returning a non nil ErrorResponse from Before short circuits the call with the httpStatus returned.
+//After is called with the final response of every call, including short circuited ones.
+type Interceptor struct {
+	Before func(rc *RequestContext) (e *ErrorResponse, httpStatus int)
+	After  func(rc *RequestContext, y interface{}, e ErrorResponse, httpStatus int)
+}
+
+type interceptorChain struct {
+	sync.RWMutex
+	items []Interceptor
+}
+
+func (chain *interceptorChain) append(item Interceptor) {
+	chain.Lock()
+	defer chain.Unlock()
+	chain.items = append(chain.items, item)
+}
+
+func (chain *interceptorChain) list() (items []Interceptor) {
+	chain.RLock()
+	defer chain.RUnlock()
+	items = append(items, chain.items...)
+	return
+}
+
+const globalInterceptorKey = "*"
+
+var interceptors sync.Map
+
+//RegisterInterceptor registers an interceptor that runs for every controller action.
+func RegisterInterceptor(interceptor Interceptor) {
+	addInterceptor(globalInterceptorKey, interceptor)
+}
+
+//RegisterControllerInterceptor registers an interceptor that runs for every action of a controller key.
+func RegisterControllerInterceptor(controller string, interceptor Interceptor) {
+	addInterceptor(strings.Title(controller), interceptor)
+}
+
+//RegisterActionInterceptor registers an interceptor that runs for a single controller action.
+func RegisterActionInterceptor(controller string, action string, interceptor Interceptor) {
+	addInterceptor(actionKey(controller, action), interceptor)
+}
+
+func addInterceptor(key string, interceptor Interceptor) {
+	obj, _ := interceptors.LoadOrStore(key, new(interceptorChain))
+	obj.(*interceptorChain).append(interceptor)
+}
+
+func actionKey(controller string, action string) string {
+	return strings.Title(controller) + "." + strings.Title(action)
+}
+
+//getInterceptors returns the global, controller and then action interceptors for the call.
+func getInterceptors(controller string, action string) (items []Interceptor) {
+	for _, key := range []string{globalInterceptorKey, strings.Title(controller), actionKey(controller, action)} {
+		obj, ok := interceptors.Load(key)
+		if ok {
+			items = append(items, obj.(*interceptorChain).list()...)
+		}
+	}
+	return
+}
+
+//runBeforeInterceptors runs each Before in order and stops at the first one to short circuit.
+func runBeforeInterceptors(rc *RequestContext, items []Interceptor) (e *ErrorResponse, httpStatus int) {
+	for _, item := range items {
+		if item.Before == nil {
+			continue
+		}
+		e, httpStatus = item.Before(rc)
+		if e != nil {
+			if httpStatus == 0 {
+				httpStatus = http.StatusForbidden
+			}
+			if e.Error == nil {
+				e.Error = new(errorObj)
+			}
+			if e.Error.Code == "" {
+				e.Error.Code = ErrCodeForbidden
+			}
+			return
+		}
+	}
+	return
+}
+
+//runAfterInterceptors runs each After in reverse registration order.
+func runAfterInterceptors(rc *RequestContext, items []Interceptor, y interface{}, e ErrorResponse, httpStatus int) {
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].After != nil {
+			items[i].After(rc, y, e, httpStatus)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/gin-gonic/gin"
+)
+
+//JSON-RPC 2.0 error codes.  Errors returned by controller actions use JSONRPCServerError with the
+//ErrorResponse code, http status and details placed in the error data.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+	JSONRPCServerError    = -32000
+)
+
+const jsonRPCVersion = "2.0"
+
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type jsonRPCError struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    *jsonRPCErrorData `json:"data,omitempty"`
+}
+
+type jsonRPCErrorData struct {
+	Code       string      `json:"code,omitempty"`
+	HTTPStatus int         `json:"httpStatus,omitempty"`
+	Details    interface{} `json:"details,omitempty"`
+	Stacktrace string      `json:"stackTrace,omitempty"`
+}
+
+/*JSONRPCCallback provides JSON-RPC 2.0 routing to controller methods over HTTP POST.
+The method is "Controller.Action" and params are passed to the action as its data parameter.
+Implementation example-----------
+ginServer.Router.POST("/rpc", api.JSONRPCCallback)
+---------------------------------
+*/
+func JSONRPCCallback(c *gin.Context) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("Panic Stack at jsonrpc.JSONRPCCallback: " + string(debug.Stack()))
+			c.JSON(http.StatusOK, newJSONRPCError(nil, JSONRPCInternalError, "Recover Error:  "+fmt.Sprintf("%+v", r)))
+		}
+	}()
+
+	body, _ := ginServer.GetRequestBody(c)
+
+	response, ok := processJSONRPC(body, c, nil)
+	if !ok {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+/*SocketJSONRPCCallback provides JSON-RPC 2.0 routing to controller methods for web socket calls.
+Messages that are not JSON-RPC 2.0 are ignored so it can be registered alongside SocketAPICallback.
+Implementation example-----------
+app.RegisterWebSocketDataCallback(api.SocketJSONRPCCallback)
+---------------------------------
+*/
+func SocketJSONRPCCallback(conn *app.WebSocketConnection, c *gin.Context, messageType int, id string, data []byte) {
+
+	if !isJSONRPC(data) {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("Panic Stack at jsonrpc.SocketJSONRPCCallback: " + string(debug.Stack()))
+			app.ReplyToWebSocketJSON(conn, newJSONRPCError(nil, JSONRPCInternalError, "Recover Error:  "+fmt.Sprintf("%+v", r)))
+		}
+	}()
+
+	response, ok := processJSONRPC(data, c, conn)
+	if ok {
+		app.ReplyToWebSocketJSON(conn, response)
+	}
+}
+
+//isJSONRPC returns true for batch arrays and objects with a "jsonrpc" member of "2.0".
+func isJSONRPC(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return false
+	}
+	if trimmed[0] == '[' {
+		return true
+	}
+
+	var peek struct {
+		JSONRPC string `json:"jsonrpc"`
+	}
+	json.Unmarshal(trimmed, &peek)
+	return peek.JSONRPC == jsonRPCVersion
+}
+
+//processJSONRPC handles a single request or a batch.  ok is false when nothing should be written back (notifications only).
+func processJSONRPC(data []byte, c *gin.Context, conn *app.WebSocketConnection) (response interface{}, ok bool) {
+
+	trimmed := bytes.TrimSpace(data)
+
+	if !json.Valid(trimmed) {
+		return newJSONRPCError(nil, JSONRPCParseError, "Parse error:  invalid JSON"), true
+	}
+
+	if trimmed[0] == '[' {
+		var batch []json.RawMessage
+		json.Unmarshal(trimmed, &batch)
+		if len(batch) == 0 {
+			return newJSONRPCError(nil, JSONRPCInvalidRequest, "Invalid Request:  empty batch"), true
+		}
+		maxSize := getMaxBatchSize()
+		if len(batch) > maxSize {
+			return newJSONRPCError(nil, JSONRPCInvalidRequest, "Invalid Request:  batch of "+strconv.Itoa(len(batch))+" requests exceeds the maximum of "+strconv.Itoa(maxSize)), true
+		}
+
+		responses := []jsonRPCResponse{}
+		for _, raw := range batch {
+			item, reply := processJSONRPCItem(raw, c, conn)
+			if reply {
+				responses = append(responses, item)
+			}
+		}
+		if len(responses) == 0 {
+			return nil, false
+		}
+		return responses, true
+	}
+
+	item, reply := processJSONRPCItem(trimmed, c, conn)
+	return item, reply
+}
+
+func processJSONRPCItem(raw []byte, c *gin.Context, conn *app.WebSocketConnection) (response jsonRPCResponse, reply bool) {
+
+	var request jsonRPCRequest
+	err := json.Unmarshal(raw, &request)
+	if err != nil {
+		return newJSONRPCError(nil, JSONRPCInvalidRequest, "Invalid Request:  "+err.Error()), true
+	}
+
+	notification := len(request.ID) == 0
+
+	if request.JSONRPC != jsonRPCVersion || request.Method == "" {
+		return newJSONRPCError(request.ID, JSONRPCInvalidRequest, "Invalid Request:  jsonrpc must be \"2.0\" and method is required"), true
+	}
+
+	controller, action, found := splitJSONRPCMethod(request.Method)
+	if !found {
+		return newJSONRPCError(request.ID, JSONRPCMethodNotFound, "Method not found:  "+request.Method), !notification
+	}
+
+	params, err := jsonRPCParams(request.Params)
+	if err != nil {
+		return newJSONRPCError(request.ID, JSONRPCInvalidParams, "Invalid params:  "+err.Error()), !notification
+	}
+
+	y, e, httpStatus := invokeRequest(newRequestContext(controller, action, params, c, conn))
+	if notification {
+		return
+	}
+
+	if y == nil {
+		response = newJSONRPCError(request.ID, jsonRPCCode(e), "")
+		response.Error.Data = &jsonRPCErrorData{HTTPStatus: httpStatus}
+		if e.Error != nil {
+			response.Error.Message = e.Error.Message
+			response.Error.Data.Code = e.Error.Code
+			response.Error.Data.Details = e.Error.Details
+			response.Error.Data.Stacktrace = e.Error.Stacktrace
+		}
+		return response, true
+	}
+
+	response.JSONRPC = jsonRPCVersion
+	response.ID = request.ID
+	response.Result = y
+	return response, true
+}
+
+//splitJSONRPCMethod maps "Controller.Action" to a registered controller method.
+func splitJSONRPCMethod(method string) (controller string, action string, found bool) {
+	index := strings.LastIndex(method, ".")
+	if index <= 0 || index == len(method)-1 {
+		return
+	}
+	controller = method[:index]
+	action = method[index+1:]
+
+	ctl := getController(strings.Title(controller))
+	found = ctl.IsValid() && ctl.MethodByName(strings.Title(action)).IsValid()
+	return
+}
+
+//jsonRPCParams returns by-name params as is and unwraps a single by-position param.
+func jsonRPCParams(params json.RawMessage) (data []byte, err error) {
+	trimmed := bytes.TrimSpace(params)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return
+	}
+	if trimmed[0] != '[' {
+		data = trimmed
+		return
+	}
+
+	var positional []json.RawMessage
+	err = json.Unmarshal(trimmed, &positional)
+	if err != nil {
+		return
+	}
+	switch len(positional) {
+	case 0:
+	case 1:
+		data = positional[0]
+	default:
+		err = fmt.Errorf("controller actions accept a single parameter, %d were passed", len(positional))
+	}
+	return
+}
+
+func jsonRPCCode(e ErrorResponse) int {
+	if e.Error != nil {
+		switch e.Error.Code {
+		case ErrCodeNotImplemented:
+			return JSONRPCMethodNotFound
+		case ErrCodeInvalidPayload, ErrCodeBadRequest:
+			return JSONRPCInvalidParams
+		case ErrCodeInternal, ErrCodePanic:
+			return JSONRPCInternalError
+		}
+	}
+	return JSONRPCServerError
+}
+
+func newJSONRPCError(id json.RawMessage, code int, message string) (response jsonRPCResponse) {
+	response.JSONRPC = jsonRPCVersion
+	response.ID = id
+	if len(response.ID) == 0 {
+		response.ID = json.RawMessage("null")
+	}
+	response.Error = &jsonRPCError{Code: code, Message: message}
+	return
+}
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+//Error codes for calls rejected by the rate limits configured in webConfig.json application.rateLimits.
+const (
+	ErrCodeRateLimited     = "rateLimited"
+	ErrCodeTooManyInFlight = "tooManyInFlight"
+)
+
+//Rate limit names reported in error details and RateLimitStat.Limit.
+const (
+	RateLimitConnection = "connection"
+	RateLimitSession    = "session"
+	RateLimitIP         = "ip"
+	RateLimitAction     = "action"
+	RateLimitInFlight   = "inFlight"
+)
+
+const rateLimitIdleTimeout = 10 * time.Minute
+
+var rateLimitBuckets sync.Map
+var actionSlotsRegistry sync.Map
+var rateLimitLastSweep int64
+
+//RateLimitStat reports the counters of a single limit key.
+type RateLimitStat struct {
+	Limit    string `json:"limit"`
+	Key      string `json:"key"`
+	Allowed  int64  `json:"allowed"`
+	Rejected int64  `json:"rejected"`
+	InFlight int    `json:"inFlight,omitempty"`
+	Queued   int64  `json:"queued,omitempty"`
+}
+
+type rateLimitDetails struct {
+	Limit      string `json:"limit"`
+	Key        string `json:"key"`
+	RetryAfter int64  `json:"retryAfter,omitempty"`
+}
+
+type tokenBucket struct {
+	sync.Mutex
+	limit    string
+	key      string
+	tokens   float64
+	last     time.Time
+	allowed  int64
+	rejected int64
+	evicted  bool
+}
+
+//take removes a token from the bucket.  When the bucket is empty it returns how long until the next token.
+//evicted is true when the sweep removed the bucket after it was loaded, the caller must load the bucket again.
+func (b *tokenBucket) take(rate float64, burst int) (ok bool, retryAfter time.Duration, evicted bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.evicted {
+		return false, 0, true
+	}
+
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	now := time.Now()
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.allowed++
+		return true, 0, false
+	}
+	b.rejected++
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), false
+}
+
+type actionSlots struct {
+	sync.Mutex
+	key      string
+	slots    chan struct{}
+	allowed  int64
+	rejected int64
+	queued   int64
+}
+
+//channel returns the semaphore for the current maxInFlight, replacing it when the configuration changes.
+func (s *actionSlots) channel(maxInFlight int) chan struct{} {
+	s.Lock()
+	defer s.Unlock()
+	if s.slots == nil || cap(s.slots) != maxInFlight {
+		s.slots = make(chan struct{}, maxInFlight)
+	}
+	return s.slots
+}
+
+//acquire takes an in flight slot, waiting up to queueTimeout or until the call is cancelled.
+func (s *actionSlots) acquire(ctx context.Context, maxInFlight int, queueTimeout time.Duration) (release func(), ok bool) {
+	slots := s.channel(maxInFlight)
+	release = func() {
+		<-slots
+	}
+
+	select {
+	case slots <- struct{}{}:
+		atomic.AddInt64(&s.allowed, 1)
+		return release, true
+	default:
+	}
+
+	if queueTimeout > 0 {
+		atomic.AddInt64(&s.queued, 1)
+		defer atomic.AddInt64(&s.queued, -1)
+
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+
+		select {
+		case slots <- struct{}{}:
+			atomic.AddInt64(&s.allowed, 1)
+			return release, true
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	atomic.AddInt64(&s.rejected, 1)
+	return nil, false
+}
+
+/*GetRateLimitStats returns the counters of every rate limit and in flight limit, most rejected first.
+Idle client buckets are removed after 10 minutes.
+Implementation example-----------
+ginServer.Router.GET("/rateLimits", func(c *gin.Context) {
+	c.JSON(http.StatusOK, api.GetRateLimitStats())
+})
+---------------------------------
+*/
+func GetRateLimitStats() (stats []RateLimitStat) {
+
+	rateLimitBuckets.Range(func(key interface{}, value interface{}) bool {
+		b := value.(*tokenBucket)
+		b.Lock()
+		stats = append(stats, RateLimitStat{Limit: b.limit, Key: b.key, Allowed: b.allowed, Rejected: b.rejected})
+		b.Unlock()
+		return true
+	})
+
+	actionSlotsRegistry.Range(func(key interface{}, value interface{}) bool {
+		s := value.(*actionSlots)
+		s.Lock()
+		inFlight := len(s.slots)
+		s.Unlock()
+		stats = append(stats, RateLimitStat{
+			Limit:    RateLimitInFlight,
+			Key:      s.key,
+			Allowed:  atomic.LoadInt64(&s.allowed),
+			Rejected: atomic.LoadInt64(&s.rejected),
+			InFlight: inFlight,
+			Queued:   atomic.LoadInt64(&s.queued),
+		})
+		return true
+	})
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Rejected != stats[j].Rejected {
+			return stats[i].Rejected > stats[j].Rejected
+		}
+		if stats[i].Limit != stats[j].Limit {
+			return stats[i].Limit < stats[j].Limit
+		}
+		return stats[i].Key < stats[j].Key
+	})
+	return
+}
+
+//acquireRateLimits applies the configured limits to the call.  release must be called when the call completes.
+func acquireRateLimits(rc *RequestContext) (release func(), e *ErrorResponse, httpStatus int) {
+
+	release = func() {}
+
+	limits := serverSettings.GetWebConfig().Application.RateLimits
+
+	sweepRateLimitBuckets()
+
+	if rc.Connection != nil {
+		e, httpStatus = takeRateLimit(RateLimitConnection, rc.Connection.Id, limits.Connection.Rate, limits.Connection.Burst)
+		if e != nil {
+			return
+		}
+	}
+
+	if rc.GinContext != nil && rc.GinContext.Request != nil {
+		if limits.Session.Rate > 0 {
+			session := rateLimitSessionKey(rc, limits.Session.SessionKey)
+			if session != "" {
+				e, httpStatus = takeRateLimit(RateLimitSession, session, limits.Session.Rate, limits.Session.Burst)
+				if e != nil {
+					return
+				}
+			}
+		}
+
+		e, httpStatus = takeRateLimit(RateLimitIP, rc.GinContext.ClientIP(), limits.IP.Rate, limits.IP.Burst)
+		if e != nil {
+			return
+		}
+	}
+
+	key := actionKey(rc.Controller, rc.Action)
+	limit, ok := limits.Actions[key]
+	if !ok {
+		key = strings.Title(rc.Controller)
+		limit, ok = limits.Actions[key]
+	}
+	if !ok {
+		return
+	}
+
+	e, httpStatus = takeRateLimit(RateLimitAction, key, limit.Rate, limit.Burst)
+	if e != nil || limit.MaxInFlight <= 0 {
+		return
+	}
+
+	obj, _ := actionSlotsRegistry.LoadOrStore(key, &actionSlots{key: key})
+	releaseSlot, ok := obj.(*actionSlots).acquire(rc.Context, limit.MaxInFlight, time.Duration(limit.QueueTimeout)*time.Millisecond)
+	if !ok {
+		response := newErrorResponse(ErrCodeTooManyInFlight, "Too many calls in flight for "+key+".")
+		response.Error.Details = rateLimitDetails{Limit: RateLimitInFlight, Key: key}
+		return release, &response, http.StatusTooManyRequests
+	}
+	return releaseSlot, nil, 0
+}
+
+//takeRateLimit takes a token from the bucket of the limit key.  A rate of 0 disables the limit.
+func takeRateLimit(limit string, key string, rate float64, burst int) (e *ErrorResponse, httpStatus int) {
+	if rate <= 0 || key == "" {
+		return
+	}
+
+	var ok, evicted bool
+	var retryAfter time.Duration
+	for {
+		obj, _ := rateLimitBuckets.LoadOrStore(limit+":"+key, &tokenBucket{limit: limit, key: key})
+		ok, retryAfter, evicted = obj.(*tokenBucket).take(rate, burst)
+		if !evicted {
+			break
+		}
+	}
+	if ok {
+		return
+	}
+
+	response := newErrorResponse(ErrCodeRateLimited, "Rate limit exceeded for "+limit+".  Retry after "+strconv.FormatInt(int64(retryAfter/time.Millisecond), 10)+"ms.")
+	response.Error.Details = rateLimitDetails{Limit: limit, Key: key, RetryAfter: int64(retryAfter / time.Millisecond)}
+	return &response, http.StatusTooManyRequests
+}
+
+//rateLimitSessionKey identifies the session by the configured session value or by the session cookie.
+func rateLimitSessionKey(rc *RequestContext, sessionKey string) string {
+	if sessionKey != "" {
+		return rc.Session(sessionKey)
+	}
+
+	name := serverSettings.GetWebConfig().Application.SessionName
+	if name == "" {
+		name = "defaultSession"
+	}
+
+	cookie, err := rc.GinContext.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie
+}
+
+//sweepRateLimitBuckets removes client buckets that have been idle, at most once a minute.
+func sweepRateLimitBuckets() {
+	now := time.Now()
+	last := atomic.LoadInt64(&rateLimitLastSweep)
+	if now.UnixNano()-last < int64(time.Minute) || !atomic.CompareAndSwapInt64(&rateLimitLastSweep, last, now.UnixNano()) {
+		return
+	}
+
+	//Buckets are marked evicted under their lock so a call that already loaded one loads its replacement instead.
+	rateLimitBuckets.Range(func(key interface{}, value interface{}) bool {
+		b := value.(*tokenBucket)
+		if b.limit == RateLimitAction {
+			return true
+		}
+		b.Lock()
+		if now.Sub(b.last) > rateLimitIdleTimeout {
+			b.evicted = true
+			rateLimitBuckets.Delete(key)
+		}
+		b.Unlock()
+		return true
+	})
+}
+
+//setRetryAfter sets the Retry-After header on rate limited http calls.
+func setRetryAfter(rc *RequestContext, e ErrorResponse) {
+	if rc.GinContext == nil || rc.Connection != nil || e.Error == nil {
+		return
+	}
+	details, ok := e.Error.Details.(rateLimitDetails)
+	if ok && details.RetryAfter > 0 {
+		rc.GinContext.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(float64(details.RetryAfter)/1000)), 10))
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/gin-gonic/gin"
+)
+
+//RequestContext describes a single controller action call as it passes through the router and interceptors.
+//Controller actions may accept a *RequestContext, *gin.Context, *app.WebSocketConnection or context.Context parameter
+//in any position and it will be populated automatically.  The remaining parameter receives the JSON decoded data.
+//IdempotencyKey is set from the Idempotency-Key header or the idempotencyKey field of a web socket call.
+//Encoding is the app.Encoding of Data, json when empty.
+type RequestContext struct {
+	Controller     string
+	Action         string
+	Data           []byte
+	Encoding       string
+	GinContext     *gin.Context
+	Connection     *app.WebSocketConnection
+	Context        context.Context
+	IdempotencyKey string
+
+	values      sync.Map
+	user        interface{}
+	userOnce    sync.Once
+	transaction BatchTransaction
+}
+
+//UserResolver is called at most once per request the first time RequestContext.User is read.
+//Applications typically look up the user from a session key.
+var UserResolver func(rc *RequestContext) interface{}
+
+var (
+	requestContextType = reflect.TypeOf((*RequestContext)(nil))
+	ginContextType     = reflect.TypeOf((*gin.Context)(nil))
+	webSocketConnType  = reflect.TypeOf((*app.WebSocketConnection)(nil))
+	contextType        = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+func newRequestContext(controller string, action string, data []byte, c *gin.Context, conn *app.WebSocketConnection) (rc *RequestContext) {
+	rc = &RequestContext{Controller: controller, Action: action, Data: data, GinContext: c, Connection: conn}
+
+	//The gin request of a web socket is the upgrade request and is cancelled once the handshake returns.
+	if conn != nil {
+		rc.Context = conn.ConnectionContext()
+	} else if c != nil && c.Request != nil {
+		rc.Context = c.Request.Context()
+	} else {
+		rc.Context = context.Background()
+	}
+	return
+}
+
+//Set stores a value on the request so later interceptors and the action can read it.
+func (rc *RequestContext) Set(key string, value interface{}) {
+	rc.values.Store(key, value)
+}
+
+//Get returns a value stored on the request by an interceptor.
+func (rc *RequestContext) Get(key string) (value interface{}, ok bool) {
+	return rc.values.Load(key)
+}
+
+//IsWebSocket returns true when the call came in over a web socket connection.
+func (rc *RequestContext) IsWebSocket() bool {
+	return rc.Connection != nil
+}
+
+//Session returns the session value for the key.  Web socket calls read the session of the upgrade request.
+func (rc *RequestContext) Session(key string) string {
+	if rc.GinContext == nil {
+		return ""
+	}
+	return ginServer.GetSessionKey(rc.GinContext, key)
+}
+
+//SetUser sets the calling user, typically from an authentication interceptor.
+func (rc *RequestContext) SetUser(user interface{}) {
+	rc.userOnce.Do(func() {})
+	rc.user = user
+}
+
+//User returns the calling user set by SetUser or resolved by UserResolver.
+func (rc *RequestContext) User() interface{} {
+	rc.userOnce.Do(func() {
+		if UserResolver != nil {
+			rc.user = UserResolver(rc)
+		}
+	})
+	return rc.user
+}
+
+//Transaction returns the model Transaction shared by an atomic batch or nil when the call is not part of one.
+func (rc *RequestContext) Transaction() interface{} {
+	return rc.transaction
+}
+
+//injectParam returns the value to pass for parameter types populated by the router rather than the request data.
+func (rc *RequestContext) injectParam(paramType reflect.Type) (value reflect.Value, ok bool) {
+	switch paramType {
+	case requestContextType:
+		return reflect.ValueOf(rc), true
+	case ginContextType:
+		return reflect.ValueOf(rc.GinContext), true
+	case webSocketConnType:
+		return reflect.ValueOf(rc.Connection), true
+	case contextType:
+		return reflect.ValueOf(&rc.Context).Elem(), true
+	}
+
+	if rc.transaction != nil && reflect.TypeOf(rc.transaction) == paramType {
+		return reflect.ValueOf(rc.transaction), true
+	}
+	return
+}
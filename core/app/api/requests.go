@@ -2,13 +2,14 @@ package api
 
 import (
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"reflect"
 	"runtime/debug"
 	"strings"
+	"sync"
 
 	"github.com/DanielRenne/GoCore/core/app"
 	"github.com/DanielRenne/GoCore/core/ginServer"
@@ -21,16 +22,20 @@ type ErrorResponse struct {
 }
 
 type errorObj struct {
-	Message    string `json:"Message"`
-	Code       string `json:"code"`
-	Stacktrace string `json:"stackTrace"`
+	Message    string      `json:"Message"`
+	Code       string      `json:"code"`
+	Stacktrace string      `json:"stackTrace,omitempty"`
+	Details    interface{} `json:"details,omitempty"`
 }
 
 type emptyResponse struct{}
 
 type socketAPIRequest struct {
-	CallbackID int        `json:"callBackId"`
-	Data       apiRequest `json:"data"`
+	CallbackID     int           `json:"callBackId"`
+	Data           apiRequest    `json:"data"`
+	Batch          *batchRequest `json:"batch"`
+	Cancel         *int          `json:"cancel"`
+	IdempotencyKey string        `json:"idempotencyKey"`
 }
 
 type apiRequest struct {
@@ -41,6 +46,7 @@ type apiRequest struct {
 
 type socketAPIResponse struct {
 	CallbackId int         `json:"callBackId"`
+	Status     int         `json:"status"`
 	Data       interface{} `json:"data"`
 }
 
@@ -58,16 +64,12 @@ func processGETAPI(c *gin.Context) {
 			log.Println("Failed to processGETAPI:  Controller:  " + controller + " Action: " + action + " " + fmt.Sprintf("%+v", r))
 			log.Println("Panic Stack: " + string(debug.Stack()))
 
-			var e ErrorResponse
-			e.Error.Message = "Recover Error:  " + fmt.Sprintf("%+v", r)
+			e := errorResponseFromPanic("Recover Error:  " + fmt.Sprintf("%+v", r))
 			c.JSON(http.StatusInternalServerError, e)
 			return
 		}
 	}()
 
-	var e ErrorResponse
-	e.Error = new(errorObj)
-
 	if action == "" {
 		action = "Root"
 	}
@@ -80,12 +82,12 @@ func processGETAPI(c *gin.Context) {
 	}
 
 	if err != nil {
-		e.Error.Message = "Failed to decode uriParams:  " + err.Error()
-		c.JSON(http.StatusInternalServerError, e)
+		e := newErrorResponse(ErrCodeInvalidPayload, "Failed to decode uriParams:  "+err.Error())
+		c.JSON(http.StatusBadRequest, e)
 		return
 	}
 
-	if serverSettings.WebConfig.Application.AllowCrossOriginRequests {
+	if serverSettings.GetWebConfig().Application.AllowCrossOriginRequests {
 		c.Header("Access-Control-Allow-Origin", "*")
 	}
 
@@ -93,7 +95,9 @@ func processGETAPI(c *gin.Context) {
 		processHTTPResponse(y, e, httpStatus, c)
 	}
 
-	processRequest(controller, action, uriParamsData, c, response)
+	rc := newRequestContext(controller, action, uriParamsData, c, nil)
+	rc.IdempotencyKey = c.GetHeader(IdempotencyKeyHeader)
+	processRequest(rc, response)
 
 }
 
@@ -103,8 +107,7 @@ func processPOSTAPI(c *gin.Context) {
 		if r := recover(); r != nil {
 			log.Println("Panic Stack: " + string(debug.Stack()))
 			log.Println("Recover Error:  " + fmt.Sprintf("%+v", r))
-			var e ErrorResponse
-			e.Error.Message = "Recover Error:  " + fmt.Sprintf("%+v", r)
+			e := errorResponseFromPanic("Recover Error:  " + fmt.Sprintf("%+v", r))
 			c.JSON(http.StatusInternalServerError, e)
 			return
 		}
@@ -123,19 +126,34 @@ func processPOSTAPI(c *gin.Context) {
 		processHTTPResponse(y, e, httpStatus, c)
 	}
 
-	processRequest(controller, action, body, c, response)
+	rc := newRequestContext(controller, action, body, c, nil)
+	rc.Encoding = app.EncodingFromContentType(c.GetHeader("Content-Type"))
+	rc.IdempotencyKey = c.GetHeader(IdempotencyKeyHeader)
+	processRequest(rc, response)
 }
 
 func processHTTPResponse(y interface{}, e ErrorResponse, httpStatus int, c *gin.Context) {
 
-	if y == nil {
-		c.JSON(httpStatus, e)
-	} else {
-		c.JSON(httpStatus, y)
+	var v interface{} = e
+	if y != nil {
+		v = y
+	}
+
+	encoding := httpResponseEncoding(c)
+	if !app.IsBinaryEncoding(encoding) {
+		c.JSON(httpStatus, v)
+		return
+	}
+
+	data, err := app.MarshalEncoding(encoding, v)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, newErrorResponse(ErrCodeInternal, "Failed to encode response as "+encoding+":  "+err.Error()))
+		return
 	}
+	c.Data(httpStatus, app.ContentTypeOfEncoding(encoding), data)
 }
 
-func processSocketAPI(c *gin.Context, data []byte, conn *app.WebSocketConnection) {
+func processSocketAPI(c *gin.Context, data []byte, conn *app.WebSocketConnection, encoding string) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -147,131 +165,353 @@ func processSocketAPI(c *gin.Context, data []byte, conn *app.WebSocketConnection
 
 	var request socketAPIRequest
 
-	var e ErrorResponse
-	e.Error = new(errorObj)
-
 	var socketResponse socketAPIResponse
 
-	errMarshal := json.Unmarshal(data, &request)
+	reply := app.ReplyToWebSocketJSON
+	if app.IsBinaryEncoding(encoding) {
+		reply = app.ReplyToWebSocketEncoded
+	}
+
+	errMarshal := app.UnmarshalEncoding(encoding, data, &request)
 	if errMarshal != nil {
+		socketResponse.Status = http.StatusBadRequest
+		socketResponse.Data = newErrorResponse(ErrCodeInvalidPayload, "Failed to unmarshal socketAPIRequest:  "+errMarshal.Error())
+		reply(conn, socketResponse)
+		return
+	}
 
-		e.Error.Message = "Failed to unmarshal socketAPIRequest:  " + errMarshal.Error()
-		socketResponse.Data = e
-		app.ReplyToWebSocketJSON(conn, socketResponse)
+	if request.Cancel != nil {
+		CancelSocketCall(conn, *request.Cancel)
 		return
 	}
 
 	socketResponse.CallbackId = request.CallbackID
 
 	response := func(y interface{}, e ErrorResponse, httpStatus int) {
+		socketResponse.Status = httpStatus
 		if y == nil {
 			socketResponse.Data = e
-			app.ReplyToWebSocketJSON(conn, socketResponse)
+			reply(conn, socketResponse)
 		} else {
 			socketResponse.Data = y
-			app.ReplyToWebSocketJSON(conn, socketResponse)
+			reply(conn, socketResponse)
 		}
 	}
 
-	data, err := json.Marshal(request.Data.State)
+	if request.Batch != nil {
+		y, httpStatus := processBatch(*request.Batch, c, conn)
+		response(y, ErrorResponse{}, httpStatus)
+		return
+	}
+
+	data, err := app.MarshalEncoding(encoding, request.Data.State)
 	if err != nil {
-		e.Error.Message = "Failed to Marshal socketAPIRequest.Data.State:  " + err.Error()
-		socketResponse.Data = e
-		app.ReplyToWebSocketJSON(conn, socketResponse)
+		socketResponse.Status = http.StatusBadRequest
+		socketResponse.Data = newErrorResponse(ErrCodeInvalidPayload, "Failed to Marshal socketAPIRequest.Data.State:  "+err.Error())
+		reply(conn, socketResponse)
 		return
 	}
 
-	processRequest(request.Data.Controller, request.Data.Action, data, c, response)
+	rc := newRequestContext(request.Data.Controller, request.Data.Action, data, c, conn)
+	rc.Encoding = encoding
+	rc.IdempotencyKey = request.IdempotencyKey
+	done := trackSocketCall(rc, request.CallbackID)
+	defer done()
+
+	processRequest(rc, response)
 
 }
 
 //ProcessRequest will process a controller requeest.
 func ProcessRequest(controller string, action string, data []byte, results func(y interface{}, e ErrorResponse, httpStatus int)) {
-	processRequest(controller, action, data, nil, results)
+	processRequest(newRequestContext(controller, action, data, nil, nil), results)
 }
 
-func processRequest(controller string, action string, data []byte, c *gin.Context, results func(y interface{}, e ErrorResponse, httpStatus int)) {
+//ProcessEncodedRequest will process a controller request with data in an app.Encoding, ie app.EncodingMessagePack.
+func ProcessEncodedRequest(controller string, action string, data []byte, encoding string, results func(y interface{}, e ErrorResponse, httpStatus int)) {
+	rc := newRequestContext(controller, action, data, nil, nil)
+	rc.Encoding = encoding
+	processRequest(rc, results)
+}
 
-	var e ErrorResponse
-	e.Error = new(errorObj)
+//invokeRequest runs processRequest and returns the response instead of calling back with it.
+func invokeRequest(rc *RequestContext) (y interface{}, e ErrorResponse, httpStatus int) {
+	processRequest(rc, func(result interface{}, errResponse ErrorResponse, status int) {
+		y = result
+		e = errResponse
+		httpStatus = status
+	})
+	return
+}
 
-	ctl := getController(strings.Title(controller))
+func processRequest(rc *RequestContext, results func(y interface{}, e ErrorResponse, httpStatus int)) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("Panic Stack at requests.processRequest: " + string(debug.Stack()))
+			results(nil, errorResponseFromPanic("Recover Error:  "+fmt.Sprintf("%+v", r)), http.StatusInternalServerError)
+		}
+	}()
 
-	method := ctl.MethodByName(strings.Title(action))
+	//The releases run when the call returns or, once a timed out action is abandoned, when the action goroutine returns.
+	releases := new(callReleases)
+	defer releases.run()
+
+	//Shutdown waits for calls that started and refuses new ones.
+	done, ok := app.BeginRequest()
+	if !ok {
+		results(nil, newErrorResponse(ErrCodeShuttingDown, "Server is shutting down."), http.StatusServiceUnavailable)
+		return
+	}
+	releases.add(done)
+
+	controller := rc.Controller
+	action := rc.Action
+	data := rc.Data
+
+	var method reflect.Value
+	ctl := getController(strings.Title(controller))
+	if ctl.IsValid() {
+		method = ctl.MethodByName(strings.Title(action))
+	}
 
 	if !method.IsValid() {
-		e.Error.Message = "Method " + action + " not available to call."
+		e := newErrorResponse(ErrCodeNotImplemented, "Method "+action+" not available to call.")
 		// c.JSON(http.StatusNotImplemented, e)
 
 		results(nil, e, http.StatusNotImplemented)
 		return
 	}
 
+	release, limitResponse, limitStatus := acquireRateLimits(rc)
+	if limitResponse != nil {
+		setRetryAfter(rc, *limitResponse)
+		results(nil, *limitResponse, limitStatus)
+		return
+	}
+	releases.add(release)
+
+	chain := getInterceptors(controller, action)
+	if len(chain) > 0 {
+		respond := results
+		results = func(y interface{}, e ErrorResponse, httpStatus int) {
+			runAfterInterceptors(rc, chain, y, e, httpStatus)
+			respond(y, e, httpStatus)
+		}
+
+		errResponse, httpStatus := runBeforeInterceptors(rc, chain)
+		if errResponse != nil {
+			results(nil, *errResponse, httpStatus)
+			return
+		}
+	}
+
+	if rc.IdempotencyKey != "" {
+		var replayed bool
+		results, replayed = beginIdempotentCall(rc, releases, results)
+		if replayed {
+			return
+		}
+	}
+
+	ctx, cancel := withActionTimeout(rc.Context, controller, action)
+	defer cancel()
+	rc.Context = ctx
+
 	methodType := method.Type()
-	paramCnt := methodType.NumIn()
 	in := []reflect.Value{}
 
-	if paramCnt == 0 {
+	for i := 0; i < methodType.NumIn(); i++ {
+		paramType := methodType.In(i)
 
-		value := method.Call(in)
-		if len(value) > 0 {
-			y := value[0].Interface()
-			results(y, e, http.StatusOK)
-		} else {
-			results(emptyResponse{}, e, http.StatusOK)
+		injected, ok := rc.injectParam(paramType)
+		if ok {
+			in = append(in, injected)
+			continue
+		}
+
+		if len(data) == 0 {
+			results(nil, newErrorResponse(ErrCodeBadRequest, "No data posted.  Method expects a parameter of data."), http.StatusBadRequest)
+			return
+		}
+
+		param, err := unmarshalParam(paramType, data, rc.Encoding)
+		if err != nil {
+			results(nil, newErrorResponse(ErrCodeInvalidPayload, err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		fieldErrors := Validate(param.Interface())
+		if len(fieldErrors) > 0 {
+			results(nil, newValidationErrorResponse(fieldErrors), http.StatusBadRequest)
+			return
 		}
+		in = append(in, param)
+	}
+
+	value, err := callAction(rc, method, in, releases)
+	if err != nil {
+		errResponse, httpStatus := errorResponseFromError(err)
+		results(nil, errResponse, httpStatus)
 		return
 	}
 
-	paramType := methodType.In(0)
-	genericType := reflect.TypeOf((*interface{})(nil))
+	processActionResults(methodType, value, results)
+}
+
+//callAction calls the controller method and returns early when the request context is cancelled or times out.
+//An action that ignores its context keeps running in the background but its result is discarded.  The releases of the call
+//are handed to the action goroutine so its in flight slot and Shutdown wait for it to return.
+func callAction(rc *RequestContext, method reflect.Value, in []reflect.Value, releases *callReleases) (value []reflect.Value, err error) {
+
+	done := make(chan []reflect.Value, 1)
+	panicked := make(chan interface{}, 1)
 
-	if len(data) == 0 {
-		e.Error.Message = "No data posted.  Method expects a parameter of data."
-		results(nil, e, http.StatusBadRequest)
+	go func() {
+		defer releases.exit()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Println("Panic Stack at requests.callAction: " + string(debug.Stack()))
+				panicked <- r
+			}
+		}()
+		done <- method.Call(in)
+	}()
+
+	select {
+	case value = <-done:
+		return
+	case r := <-panicked:
+		panic(r)
+	case <-rc.Context.Done():
+		select {
+		case value = <-done:
+			return
+		default:
+		}
+		releases.abandon()
+		err = rc.Context.Err()
 		return
 	}
+}
 
-	if paramType == genericType || paramType.String() == "interface {}" {
+//callReleases holds the funcs that release the slots of a call, ie the Shutdown wait, the in flight limit and a retryable idempotency key.
+type callReleases struct {
+	sync.Mutex
+	funcs     []func()
+	abandoned bool
+	exited    bool
+	released  bool
+}
 
-		var x interface{}
-		err := json.Unmarshal(data, &x)
+//add queues the release.  It runs right away when the call was already released.
+func (r *callReleases) add(release func()) {
+	r.Lock()
+	if r.released {
+		r.Unlock()
+		release()
+		return
+	}
+	r.funcs = append(r.funcs, release)
+	r.Unlock()
+}
 
-		if err != nil {
-			e.Error.Message = "Failed to unmarshal uriParams or post body data:  " + err.Error()
-			results(nil, e, http.StatusInternalServerError)
+//run releases the call unless a running action was abandoned, in which case the action goroutine releases it on exit.
+func (r *callReleases) run() {
+	r.Lock()
+	abandoned := r.abandoned
+	r.Unlock()
+	if !abandoned {
+		r.release()
+	}
+}
+
+//abandon hands the releases to the action goroutine unless it already returned.
+func (r *callReleases) abandon() {
+	r.Lock()
+	if !r.exited {
+		r.abandoned = true
+	}
+	r.Unlock()
+}
+
+//exit is called by the action goroutine once the controller method returns.
+func (r *callReleases) exit() {
+	r.Lock()
+	r.exited = true
+	abandoned := r.abandoned
+	r.Unlock()
+	if abandoned {
+		r.release()
+	}
+}
+
+func (r *callReleases) release() {
+	r.Lock()
+	funcs := r.funcs
+	r.funcs = nil
+	r.released = true
+	r.Unlock()
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i]()
+	}
+}
+
+//processActionResults handles actions returning nothing, a value, an error or (value, error).
+func processActionResults(methodType reflect.Type, value []reflect.Value, results func(y interface{}, e ErrorResponse, httpStatus int)) {
+
+	var e ErrorResponse
+	e.Error = new(errorObj)
+
+	if len(value) == 0 {
+		results(emptyResponse{}, e, http.StatusOK)
+		return
+	}
+
+	last := len(value) - 1
+	if methodType.Out(last) == errorInterfaceType {
+		if !value[last].IsNil() {
+			errResponse, httpStatus := errorResponseFromError(value[last].Interface().(error))
+			results(nil, errResponse, httpStatus)
 			return
 		}
-
-		if x != nil {
-			in = append(in, reflect.ValueOf(x))
+		if last == 0 {
+			results(emptyResponse{}, e, http.StatusOK)
+			return
 		}
-	} else {
+	}
 
-		raw := data
+	results(value[0].Interface(), e, http.StatusOK)
+}
 
-		if len(raw) > 0 {
-			param := reflect.New(paramType)
+//unmarshalParam decodes the uriParams or post body data with the request encoding into the action parameter type.
+func unmarshalParam(paramType reflect.Type, data []byte, encoding string) (param reflect.Value, err error) {
 
-			err := json.Unmarshal(raw, param.Interface())
-			if err != nil {
-				e.Error.Message = "Failed to unmarshal raw uriParamsData:  " + err.Error()
-				results(nil, e, http.StatusInternalServerError)
-				return
-			}
+	genericType := reflect.TypeOf((*interface{})(nil))
 
-			in = append(in, param.Elem())
-		} else {
-			var tmp string
-			in = append(in, reflect.ValueOf(tmp))
+	if paramType == genericType || paramType.String() == "interface {}" {
+
+		var x interface{}
+		err = app.UnmarshalEncoding(encoding, data, &x)
+		if err != nil {
+			err = errors.New("Failed to unmarshal uriParams or post body data:  " + err.Error())
+			return
 		}
+
+		if x == nil {
+			param = reflect.Zero(paramType)
+			return
+		}
+		param = reflect.ValueOf(x)
+		return
 	}
 
-	value := method.Call(in)
-	if len(value) > 0 {
-		y := value[0].Interface()
-		results(y, e, http.StatusOK)
-	} else {
-		results(emptyResponse{}, e, http.StatusOK)
+	ptr := reflect.New(paramType)
+	err = app.UnmarshalEncoding(encoding, data, ptr.Interface())
+	if err != nil {
+		err = errors.New("Failed to unmarshal raw uriParamsData:  " + err.Error())
+		return
 	}
+	param = ptr.Elem()
+	return
 }
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/gin-gonic/gin"
+)
+
+var restMethods sync.Map
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+/*RegisterRESTMethod selects the http method an action is exposed with by RegisterRESTRoutes.
+method is one of GET, POST, PUT, PATCH or DELETE.  Actions default to POST when they accept data and GET otherwise.
+Implementation example-----------
+api.RegisterRESTMethod("Users", "Get", http.MethodGet)
+api.RegisterRESTMethod("Users", "Delete", http.MethodDelete)
+---------------------------------
+*/
+func RegisterRESTMethod(controller string, action string, method string) {
+	restMethods.Store(actionKey(controller, action), strings.ToUpper(method))
+}
+
+/*RegisterRESTRoutes exposes every registered controller action as /{version}/{controller}/{action} through ginServer.AddRouterGroup.
+Fields of the action parameter struct tagged with path are appended to the route as path parameters in field order and
+fields tagged with query are read from the query string.  Any body is decoded first and path and query values override it.
+Calls run through the same interceptors, limits and validation as APICallback.  Call it after all controllers are registered.
+Implementation example-----------
+type GetUserRequest struct {
+	Id     string `json:"id" path:"id"`
+	Fields string `json:"fields" query:"fields"`
+}
+
+api.RegisterRESTMethod("Users", "Get", http.MethodGet)
+api.RegisterRESTRoutes("v1")
+//GET /v1/Users/Get/5b0f?fields=name
+---------------------------------
+*/
+func RegisterRESTRoutes(version string) {
+
+	group := "/" + strings.Trim(version, "/")
+
+	for _, key := range getControllerKeys() {
+		ctl := getController(key)
+		ctlType := ctl.Type()
+
+		for i := 0; i < ctlType.NumMethod(); i++ {
+			action := ctlType.Method(i).Name
+			paramType := getActionParamType(ctl.Method(i).Type())
+
+			route := "/" + key + "/" + action
+			for _, field := range getRESTFields(paramType, "path") {
+				route += "/:" + field.tag
+			}
+
+			ginServer.AddRouterGroup(group, route, getRESTMethod(key, action, paramType), restHandler(key, action, paramType))
+		}
+	}
+}
+
+func getRESTMethod(controller string, action string, paramType reflect.Type) string {
+	method, ok := restMethods.Load(actionKey(controller, action))
+	if ok {
+		return method.(string)
+	}
+	if paramType == nil {
+		return http.MethodGet
+	}
+	return http.MethodPost
+}
+
+type restField struct {
+	jsonField
+	tag string
+}
+
+//getRESTFields returns the fields of the parameter struct that have the path or query tag.
+func getRESTFields(paramType reflect.Type, tagName string) (fields []restField) {
+	if paramType == nil {
+		return
+	}
+	if paramType.Kind() == reflect.Ptr {
+		paramType = paramType.Elem()
+	}
+	if paramType.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, field := range getJSONFields(paramType) {
+		tag := field.Field.Tag.Get(tagName)
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fields = append(fields, restField{jsonField: field, tag: tag})
+	}
+	return
+}
+
+func restHandler(controller string, action string, paramType reflect.Type) func(c *gin.Context) {
+
+	pathFields := getRESTFields(paramType, "path")
+	queryFields := getRESTFields(paramType, "query")
+
+	return func(c *gin.Context) {
+
+		defer func() {
+			if r := recover(); r != nil {
+				log.Println("Failed to process REST route:  Controller:  " + controller + " Action: " + action + " " + fmt.Sprintf("%+v", r))
+				log.Println("Panic Stack: " + string(debug.Stack()))
+				c.JSON(http.StatusInternalServerError, errorResponseFromPanic("Recover Error:  "+fmt.Sprintf("%+v", r)))
+			}
+		}()
+
+		encoding := app.EncodingFromContentType(c.GetHeader("Content-Type"))
+		data, err := bindRESTData(c, encoding, pathFields, queryFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(ErrCodeInvalidPayload, err.Error()))
+			return
+		}
+
+		response := func(y interface{}, e ErrorResponse, httpStatus int) {
+			processHTTPResponse(y, e, httpStatus, c)
+		}
+
+		rc := newRequestContext(controller, action, data, c, nil)
+		rc.Encoding = encoding
+		rc.IdempotencyKey = c.GetHeader(IdempotencyKeyHeader)
+		processRequest(rc, response)
+	}
+}
+
+//bindRESTData merges the path and query values into the body so the call can be processed like any other request.
+func bindRESTData(c *gin.Context, encoding string, pathFields []restField, queryFields []restField) (data []byte, err error) {
+
+	body, err := ginServer.GetRequestBody(c)
+	if err != nil {
+		err = errors.New("Failed to read request body:  " + err.Error())
+		return
+	}
+
+	if len(pathFields) == 0 && len(queryFields) == 0 {
+		data = body
+		return
+	}
+
+	values := make(map[string]interface{})
+	if len(body) > 0 {
+		err = app.UnmarshalEncoding(encoding, body, &values)
+		if err != nil {
+			err = errors.New("Failed to unmarshal request body:  " + err.Error())
+			return
+		}
+	}
+
+	for _, field := range pathFields {
+		value := c.Param(field.tag)
+		var parsed reflect.Value
+		parsed, err = parseRESTValue(field.Field.Type, []string{value})
+		if err != nil {
+			err = errors.New("Invalid path parameter " + field.tag + ":  " + err.Error())
+			return
+		}
+		values[field.Name] = parsed.Interface()
+	}
+
+	for _, field := range queryFields {
+		query, ok := c.GetQueryArray(field.tag)
+		if !ok {
+			continue
+		}
+		var parsed reflect.Value
+		parsed, err = parseRESTValue(field.Field.Type, query)
+		if err != nil {
+			err = errors.New("Invalid query parameter " + field.tag + ":  " + err.Error())
+			return
+		}
+		values[field.Name] = parsed.Interface()
+	}
+
+	data, err = app.MarshalEncoding(encoding, values)
+	return
+}
+
+//parseRESTValue converts path or query strings into a value of the field type so it marshals with the request encoding like the rest of the body.
+func parseRESTValue(t reflect.Type, values []string) (value reflect.Value, err error) {
+
+	if t.Kind() == reflect.Ptr {
+		var elem reflect.Value
+		elem, err = parseRESTValue(t.Elem(), values)
+		if err != nil {
+			return
+		}
+		value = reflect.New(t.Elem())
+		value.Elem().Set(elem)
+		return
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		value = reflect.MakeSlice(t, 0, len(values))
+		for _, raw := range values {
+			for _, part := range strings.Split(raw, ",") {
+				var item reflect.Value
+				item, err = parseRESTValue(t.Elem(), []string{part})
+				if err != nil {
+					return
+				}
+				value = reflect.Append(value, item)
+			}
+		}
+		return
+	}
+
+	raw := ""
+	if len(values) > 0 {
+		raw = values[len(values)-1]
+	}
+
+	value = reflect.New(t).Elem()
+
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		err = value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+		return
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(raw)
+		value.SetBool(b)
+		return
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(raw, 10, t.Bits())
+		value.SetInt(i)
+		return
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(raw, 10, t.Bits())
+		value.SetUint(u)
+		return
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(raw, t.Bits())
+		value.SetFloat(f)
+		return
+	case reflect.Slice:
+		var b []byte
+		b, err = base64.StdEncoding.DecodeString(raw)
+		value.SetBytes(b)
+		return
+	}
+
+	//Structs, maps and types with their own json decoding are written as json in the url.
+	err = json.Unmarshal([]byte(raw), value.Addr().Interface())
+	if err != nil && t.Kind() == reflect.Interface {
+		value.Set(reflect.ValueOf(raw))
+		err = nil
+	}
+	return
+}
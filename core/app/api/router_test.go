@@ -0,0 +1,694 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/app"
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gin-gonic/gin"
+)
+
+type routerUser struct {
+	Id    string `json:"id" path:"id"`
+	Email string `json:"email" validate:"false,email,,,,,"`
+	Age   int    `json:"age" query:"age" validate:"false,,0,120,,,"`
+	Name  string `json:"name" validate:"true,,,,,10,2"`
+}
+
+type RouterUsers struct{}
+
+func (RouterUsers) Get(user routerUser) routerUser {
+	return user
+}
+
+func (RouterUsers) Taken(user routerUser) (routerUser, error) {
+	return user, NewError(http.StatusConflict, "emailTaken", "Email is taken.")
+}
+
+type routerSearch struct {
+	Owner string    `json:"owner" path:"owner"`
+	Tags  []string  `json:"tags" query:"tag"`
+	Pages []int     `json:"pages" query:"pages"`
+	Limit *int      `json:"limit" query:"limit"`
+	Since time.Time `json:"since" query:"since"`
+	Text  string    `json:"text"`
+}
+
+func (RouterUsers) Search(search routerSearch) routerSearch {
+	return search
+}
+
+type RouterGuard struct{}
+
+func (RouterGuard) Whoami(rc *RequestContext) string {
+	user, _ := rc.Get("user")
+	return user.(string)
+}
+
+func (RouterGuard) Denied() string {
+	return "ran"
+}
+
+var routerCounter int32
+
+type RouterCounter struct{}
+
+func (RouterCounter) Next() int32 {
+	return atomic.AddInt32(&routerCounter, 1)
+}
+
+func (RouterCounter) Echo(x map[string]interface{}) map[string]interface{} {
+	atomic.AddInt32(&routerCounter, 1)
+	return x
+}
+
+func (RouterCounter) Slow() int32 {
+	time.Sleep(200 * time.Millisecond)
+	return atomic.AddInt32(&routerCounter, 1)
+}
+
+type routerTransaction struct {
+	committed bool
+	discarded bool
+	commitErr error
+	saved     []string
+}
+
+func (tran *routerTransaction) Commit() error {
+	if tran.commitErr != nil {
+		return tran.commitErr
+	}
+	tran.committed = true
+	return nil
+}
+
+func (tran *routerTransaction) Discard() error {
+	tran.discarded = true
+	return nil
+}
+
+type RouterBatch struct{}
+
+func (RouterBatch) Save(tran *routerTransaction, user routerUser) routerUser {
+	tran.saved = append(tran.saved, user.Name)
+	return user
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	RegisterController(&RouterUsers{})
+	RegisterController(&RouterGuard{})
+	RegisterController(&RouterCounter{})
+	RegisterController(&RouterBatch{})
+}
+
+//setRateLimits applies webConfig.json application.rateLimits for the test and returns a func restoring them.
+func setRateLimits(t *testing.T, limits string) func() {
+	serverSettings.WebConfigMutex.Lock()
+	defer serverSettings.WebConfigMutex.Unlock()
+
+	running := serverSettings.WebConfig.Application.RateLimits
+	var parsed serverSettings.RateLimits
+	err := json.Unmarshal([]byte(limits), &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverSettings.WebConfig.Application.RateLimits = parsed
+	return func() {
+		serverSettings.WebConfigMutex.Lock()
+		serverSettings.WebConfig.Application.RateLimits = running
+		serverSettings.WebConfigMutex.Unlock()
+	}
+}
+
+func newTestContext(method string, target string, body string, cookie string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if cookie != "" {
+		c.Request.Header.Set("Cookie", "defaultSession="+cookie)
+	}
+	return c, recorder
+}
+
+func invoke(controller string, action string, data string, c *gin.Context) (y interface{}, e ErrorResponse, httpStatus int) {
+	var payload []byte
+	if data != "" {
+		payload = []byte(data)
+	}
+	return invokeRequest(newRequestContext(controller, action, payload, c, nil))
+}
+
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder, x interface{}) {
+	err := json.Unmarshal(recorder.Body.Bytes(), x)
+	if err != nil {
+		t.Fatalf("failed to decode response %s:  %v", recorder.Body.String(), err)
+	}
+}
+
+func TestAPICallback(t *testing.T) {
+	c, recorder := newTestContext(http.MethodPost, "/api?controller=RouterUsers&action=Get", `{"id":"1","name":"Ann"}`, "")
+	APICallback(c)
+
+	var user routerUser
+	decodeBody(t, recorder, &user)
+	if recorder.Code != http.StatusOK || user.Id != "1" || user.Name != "Ann" {
+		t.Errorf("unexpected response %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	c, recorder = newTestContext(http.MethodPost, "/api?controller=RouterUsers&action=Missing", `{}`, "")
+	APICallback(c)
+	if recorder.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 for an unknown action, got %d", recorder.Code)
+	}
+
+	_, e, status := invoke("RouterUsers", "Taken", `{"name":"Ann"}`, nil)
+	if status != http.StatusConflict || e.Error == nil || e.Error.Code != "emailTaken" {
+		t.Errorf("expected the api.Error of the action, got %d %+v", status, e.Error)
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	var mutex sync.Mutex
+	var after []int
+
+	RegisterControllerInterceptor("RouterGuard", Interceptor{
+		Before: func(rc *RequestContext) (*ErrorResponse, int) {
+			if rc.Action == "Denied" {
+				e := newErrorResponse(ErrCodeForbidden, "Denied.")
+				return &e, http.StatusForbidden
+			}
+			rc.Set("user", "alice")
+			return nil, 0
+		},
+		After: func(rc *RequestContext, y interface{}, e ErrorResponse, httpStatus int) {
+			mutex.Lock()
+			after = append(after, httpStatus)
+			mutex.Unlock()
+		},
+	})
+
+	y, _, status := invoke("RouterGuard", "Whoami", "", nil)
+	if status != http.StatusOK || y != "alice" {
+		t.Errorf("expected the value set by the interceptor, got %d %v", status, y)
+	}
+
+	y, e, status := invoke("RouterGuard", "Denied", "", nil)
+	if status != http.StatusForbidden || y != nil || e.Error.Code != ErrCodeForbidden {
+		t.Errorf("expected the interceptor to short circuit the call, got %d %v", status, y)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if !reflect.DeepEqual(after, []int{http.StatusOK, http.StatusForbidden}) {
+		t.Errorf("expected After to see every response, got %v", after)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		data   string
+		status int
+		fields []string
+	}{
+		{`{"name":"Ann","email":"ann@example.com","age":30}`, http.StatusOK, nil},
+		{`{"email":"ann@example.com"}`, http.StatusBadRequest, []string{"name:" + ValidationCodeRequired}},
+		{`{"name":"Ann","email":"Ann <ann@example.com>"}`, http.StatusBadRequest, []string{"email:notValidEmail"}},
+		{`{"name":"A","age":-1}`, http.StatusBadRequest, []string{"age:" + ValidationCodeMin, "name:" + ValidationCodeLengthMin}},
+		{`{"name":"Annabelle Smith","age":121}`, http.StatusBadRequest, []string{"age:" + ValidationCodeMax, "name:" + ValidationCodeLengthMax}},
+		{`{"name":`, http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		_, e, status := invoke("RouterUsers", "Get", test.data, nil)
+		if status != test.status {
+			t.Errorf("%s:  expected %d, got %d", test.data, test.status, status)
+			continue
+		}
+		if test.fields == nil {
+			continue
+		}
+
+		if e.Error == nil || e.Error.Code != ErrCodeValidation {
+			t.Errorf("%s:  expected a validation error, got %+v", test.data, e.Error)
+			continue
+		}
+		var fields []string
+		for _, fieldError := range e.Error.Details.([]FieldError) {
+			fields = append(fields, fieldError.Field+":"+fieldError.Code)
+		}
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("%s:  expected field errors %v, got %v", test.data, test.fields, fields)
+		}
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	defer setRateLimits(t, `{"actions":{"RouterCounter.Next":{"rate":1,"burst":2}}}`)()
+	rateLimitBuckets.Delete(RateLimitAction + ":RouterCounter.Next")
+
+	for i := 0; i < 2; i++ {
+		_, _, status := invoke("RouterCounter", "Next", "", nil)
+		if status != http.StatusOK {
+			t.Fatalf("call %d within the burst was rejected with %d", i, status)
+		}
+	}
+
+	c, _ := newTestContext(http.MethodPost, "/api", "", "")
+	rc := newRequestContext("RouterCounter", "Next", nil, c, nil)
+	var e ErrorResponse
+	var status int
+	processRequest(rc, func(y interface{}, errResponse ErrorResponse, httpStatus int) {
+		e = errResponse
+		status = httpStatus
+	})
+	if status != http.StatusTooManyRequests || e.Error.Code != ErrCodeRateLimited {
+		t.Fatalf("expected the call over the burst to be rate limited, got %d %+v", status, e.Error)
+	}
+	if c.Writer.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After of 1, got %q", c.Writer.Header().Get("Retry-After"))
+	}
+
+	found := false
+	for _, stat := range GetRateLimitStats() {
+		if stat.Limit == RateLimitAction && stat.Key == "RouterCounter.Next" {
+			found = true
+			if stat.Allowed != 2 || stat.Rejected != 1 {
+				t.Errorf("expected 2 allowed and 1 rejected, got %+v", stat)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected stats for RouterCounter.Next")
+	}
+}
+
+func TestInFlightLimitHeldByTimedOutAction(t *testing.T) {
+	defer setRateLimits(t, `{"actions":{"RouterCounter.Slow":{"maxInFlight":1}}}`)()
+	SetActionTimeout("RouterCounter", "Slow", 20*time.Millisecond)
+
+	_, _, status := invoke("RouterCounter", "Slow", "", nil)
+	if status != http.StatusGatewayTimeout {
+		t.Fatalf("expected the action to time out, got %d", status)
+	}
+
+	_, e, status := invoke("RouterCounter", "Slow", "", nil)
+	if status != http.StatusTooManyRequests || e.Error.Code != ErrCodeTooManyInFlight {
+		t.Fatalf("expected the running action to hold its slot, got %d %+v", status, e.Error)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	_, _, status = invoke("RouterCounter", "Slow", "", nil)
+	if status != http.StatusGatewayTimeout {
+		t.Errorf("expected the slot to be released once the action returned, got %d", status)
+	}
+	time.Sleep(300 * time.Millisecond)
+}
+
+func TestIdempotency(t *testing.T) {
+	key := "order-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	call := func(data string, cookie string) (interface{}, ErrorResponse, int) {
+		c, _ := newTestContext(http.MethodPost, "/api", data, cookie)
+		rc := newRequestContext("RouterCounter", "Echo", []byte(data), c, nil)
+		rc.IdempotencyKey = key
+		return invokeRequest(rc)
+	}
+
+	start := atomic.LoadInt32(&routerCounter)
+
+	y, _, status := call(`{"n":1}`, "alice")
+	if status != http.StatusOK {
+		t.Fatalf("first call failed with %d", status)
+	}
+	first, _ := json.Marshal(y)
+
+	y, _, status = call(`{"n":1}`, "alice")
+	replayed, _ := json.Marshal(y)
+	if status != http.StatusOK || !bytes.Equal(first, replayed) {
+		t.Errorf("expected the stored response, got %d %s", status, replayed)
+	}
+	if atomic.LoadInt32(&routerCounter) != start+1 {
+		t.Errorf("expected the retry not to run the action")
+	}
+
+	_, e, status := call(`{"n":2}`, "alice")
+	if status != http.StatusUnprocessableEntity || e.Error.Code != ErrCodeIdempotencyConflict {
+		t.Errorf("expected a conflict for a different payload, got %d %+v", status, e.Error)
+	}
+
+	_, _, status = call(`{"n":1}`, "bob")
+	if status != http.StatusOK || atomic.LoadInt32(&routerCounter) != start+2 {
+		t.Errorf("expected the key of another session to run the action, got %d", status)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	c, recorder := newTestContext(http.MethodPost, "/apiBatch", `{"items":[
+		{"controller":"RouterUsers","action":"Get","state":{"name":"Ann"}},
+		{"controller":"RouterUsers","action":"Get","state":{"name":"A"}},
+		{"controller":"RouterUsers","action":"Missing","state":{}}
+	]}`, "")
+	BatchAPICallback(c)
+
+	var response struct {
+		Results []struct {
+			Index  int             `json:"index"`
+			Status int             `json:"status"`
+			Data   json.RawMessage `json:"data"`
+		} `json:"results"`
+	}
+	decodeBody(t, recorder, &response)
+	if recorder.Code != http.StatusOK || len(response.Results) != 3 {
+		t.Fatalf("unexpected batch response %d %s", recorder.Code, recorder.Body.String())
+	}
+	for i, expected := range []int{http.StatusOK, http.StatusBadRequest, http.StatusNotImplemented} {
+		if response.Results[i].Index != i || response.Results[i].Status != expected {
+			t.Errorf("item %d:  expected %d, got %+v", i, expected, response.Results[i])
+		}
+	}
+
+	batch, _ := processBatch(batchRequest{Items: []apiRequest{
+		{Controller: "RouterUsers", Action: "Get", State: make(chan int)},
+		{Controller: "RouterUsers", Action: "Get", State: map[string]interface{}{"name": "Ann"}},
+	}}, nil, nil)
+	failed := batch.Results[0].Data.(ErrorResponse)
+	if batch.Results[0].Status != http.StatusBadRequest || failed.Error.Code != ErrCodeInvalidPayload {
+		t.Errorf("expected an invalidPayload error for a state that cannot be encoded, got %+v", batch.Results[0])
+	}
+	if batch.Results[1].Status != http.StatusOK {
+		t.Errorf("expected the other item to run, got %+v", batch.Results[1])
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	serverSettings.WebConfigMutex.Lock()
+	serverSettings.WebConfig.Application.MaxBatchSize = 1
+	serverSettings.WebConfigMutex.Unlock()
+	defer func() {
+		serverSettings.WebConfigMutex.Lock()
+		serverSettings.WebConfig.Application.MaxBatchSize = 0
+		serverSettings.WebConfigMutex.Unlock()
+	}()
+
+	item := apiRequest{Controller: "RouterCounter", Action: "Next"}
+	response, status := processBatch(batchRequest{Items: []apiRequest{item, item}}, nil, nil)
+	if status != http.StatusRequestEntityTooLarge || response.Error.Code != ErrCodeBatchTooLarge || response.Results != nil {
+		t.Errorf("expected the batch to be rejected, got %d %+v", status, response)
+	}
+}
+
+func TestAtomicBatch(t *testing.T) {
+	var tran *routerTransaction
+	BatchTransactionFactory = func(rc *RequestContext) (BatchTransaction, error) {
+		tran = new(routerTransaction)
+		return tran, nil
+	}
+	defer func() {
+		BatchTransactionFactory = nil
+	}()
+
+	save := func(name string) apiRequest {
+		return apiRequest{Controller: "RouterBatch", Action: "Save", State: map[string]interface{}{"name": name}}
+	}
+
+	response, status := processBatch(batchRequest{Atomic: true, Items: []apiRequest{save("Ann"), save("Bob")}}, nil, nil)
+	if status != http.StatusOK || !response.Committed || !tran.committed || tran.discarded || !reflect.DeepEqual(tran.saved, []string{"Ann", "Bob"}) {
+		t.Errorf("expected the batch to commit, got %d %+v %+v", status, response, tran)
+	}
+
+	response, status = processBatch(batchRequest{Atomic: true, Items: []apiRequest{save("Ann"), save("B"), save("Cid")}}, nil, nil)
+	if status != http.StatusBadRequest || response.Committed || tran.committed || !tran.discarded {
+		t.Errorf("expected the batch to be discarded, got %d %+v %+v", status, response, tran)
+	}
+	if response.Results[2].Status != http.StatusFailedDependency {
+		t.Errorf("expected the item after the failure to be aborted, got %+v", response.Results[2])
+	}
+	if !reflect.DeepEqual(tran.saved, []string{"Ann"}) {
+		t.Errorf("expected only the first item to run, got %v", tran.saved)
+	}
+
+	BatchTransactionFactory = func(rc *RequestContext) (BatchTransaction, error) {
+		tran = &routerTransaction{commitErr: errors.New("write conflict")}
+		return tran, nil
+	}
+	response, status = processBatch(batchRequest{Atomic: true, Items: []apiRequest{save("Ann")}}, nil, nil)
+	if status < http.StatusBadRequest || response.Committed || !tran.discarded {
+		t.Errorf("expected a failed commit to be discarded, got %d %+v %+v", status, response, tran)
+	}
+
+	serverSettings.WebConfigMutex.Lock()
+	driver := serverSettings.WebConfig.DbConnection.Driver
+	serverSettings.WebConfig.DbConnection.Driver = dbServices.DATABASE_DRIVER_BOLTDB
+	serverSettings.WebConfigMutex.Unlock()
+	defer func() {
+		serverSettings.WebConfigMutex.Lock()
+		serverSettings.WebConfig.DbConnection.Driver = driver
+		serverSettings.WebConfigMutex.Unlock()
+	}()
+
+	tran = nil
+	response, status = processBatch(batchRequest{Atomic: true, Items: []apiRequest{save("Ann")}}, nil, nil)
+	if status != http.StatusNotImplemented || response.Error == nil || response.Error.Code != ErrCodeNotImplemented || tran != nil {
+		t.Errorf("expected the atomic batch to be refused on bolt, got %d %+v", status, response)
+	}
+}
+
+func TestJSONRPC(t *testing.T) {
+	c, recorder := newTestContext(http.MethodPost, "/rpc", `{"jsonrpc":"2.0","method":"RouterUsers.Get","params":{"name":"Ann"},"id":1}`, "")
+	JSONRPCCallback(c)
+
+	var response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  routerUser      `json:"result"`
+		Error   *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	decodeBody(t, recorder, &response)
+	if response.JSONRPC != "2.0" || string(response.ID) != "1" || response.Result.Name != "Ann" || response.Error != nil {
+		t.Errorf("unexpected response %s", recorder.Body.String())
+	}
+
+	c, recorder = newTestContext(http.MethodPost, "/rpc", `[
+		{"jsonrpc":"2.0","method":"RouterUsers.Get","params":[{"name":"Ann"}],"id":"a"},
+		{"jsonrpc":"2.0","method":"RouterCounter.Next"},
+		{"jsonrpc":"2.0","method":"RouterUsers.Missing","id":"b"},
+		{"jsonrpc":"2.0","method":"RouterUsers.Get","params":{"name":"A"},"id":"c"},
+		{"method":"RouterUsers.Get","id":"d"}
+	]`, "")
+	JSONRPCCallback(c)
+
+	var batch []struct {
+		ID    string `json:"id"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	decodeBody(t, recorder, &batch)
+	if len(batch) != 4 {
+		t.Fatalf("expected 4 responses without the notification, got %s", recorder.Body.String())
+	}
+	codes := map[string]int{}
+	for _, item := range batch {
+		if item.Error != nil {
+			codes[item.ID] = item.Error.Code
+		}
+	}
+	expected := map[string]int{"b": JSONRPCMethodNotFound, "c": JSONRPCServerError, "d": JSONRPCInvalidRequest}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expected error codes %v, got %v", expected, codes)
+	}
+
+	c, _ = newTestContext(http.MethodPost, "/rpc", `{"jsonrpc":"2.0","method":"RouterCounter.Next"}`, "")
+	JSONRPCCallback(c)
+	if c.Writer.Status() != http.StatusNoContent {
+		t.Errorf("expected no content for a notification, got %d", c.Writer.Status())
+	}
+}
+
+func TestRESTRoutes(t *testing.T) {
+	paramType := reflect.TypeOf(routerUser{})
+	if method := getRESTMethod("RouterUsers", "Get", paramType); method != http.MethodPost {
+		t.Errorf("expected actions with data to default to POST, got %s", method)
+	}
+	if method := getRESTMethod("RouterCounter", "Next", nil); method != http.MethodGet {
+		t.Errorf("expected actions without data to default to GET, got %s", method)
+	}
+	RegisterRESTMethod("RouterUsers", "Get", "get")
+	defer restMethods.Delete(actionKey("RouterUsers", "Get"))
+	if method := getRESTMethod("RouterUsers", "Get", paramType); method != http.MethodGet {
+		t.Errorf("expected the registered method, got %s", method)
+	}
+
+	router := gin.New()
+	router.GET("/v1/RouterUsers/Get/:id", restHandler("RouterUsers", "Get", paramType))
+	router.POST("/v1/RouterUsers/Taken/:id", restHandler("RouterUsers", "Taken", paramType))
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+		user   routerUser
+	}{
+		{http.MethodGet, "/v1/RouterUsers/Get/42?age=30&name=ignored", "", http.StatusBadRequest, routerUser{}},
+		{http.MethodGet, "/v1/RouterUsers/Get/42?age=30", `{"name":"Ann"}`, http.StatusOK, routerUser{Id: "42", Age: 30, Name: "Ann"}},
+		{http.MethodGet, "/v1/RouterUsers/Get/42?age=old", `{"name":"Ann"}`, http.StatusBadRequest, routerUser{}},
+		{http.MethodGet, "/v1/RouterUsers/Get/42", `{"id":"7","name":"Ann"}`, http.StatusOK, routerUser{Id: "42", Name: "Ann"}},
+		{http.MethodPost, "/v1/RouterUsers/Taken/42", `{"name":"Ann"}`, http.StatusConflict, routerUser{}},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s %s:  expected %d, got %d %s", test.method, test.target, test.status, recorder.Code, recorder.Body.String())
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		var user routerUser
+		decodeBody(t, recorder, &user)
+		if user != test.user {
+			t.Errorf("%s %s:  expected %+v, got %+v", test.method, test.target, test.user, user)
+		}
+	}
+}
+
+func TestCallReleases(t *testing.T) {
+	var released []string
+	release := func(name string) func() {
+		return func() {
+			released = append(released, name)
+		}
+	}
+
+	r := new(callReleases)
+	r.add(release("a"))
+	r.add(release("b"))
+	r.run()
+	if !reflect.DeepEqual(released, []string{"b", "a"}) {
+		t.Errorf("expected the releases in reverse, got %v", released)
+	}
+
+	released = nil
+	r = new(callReleases)
+	r.add(release("a"))
+	r.abandon()
+	r.run()
+	if len(released) != 0 {
+		t.Errorf("expected an abandoned call to wait for its action, got %v", released)
+	}
+	r.add(release("b"))
+	r.exit()
+	r.add(release("c"))
+	if !reflect.DeepEqual(released, []string{"b", "a", "c"}) {
+		t.Errorf("expected the action to release the call, got %v", released)
+	}
+}
+
+func TestRESTEncodings(t *testing.T) {
+	router := gin.New()
+	router.POST("/v1/RouterUsers/Search/:owner", restHandler("RouterUsers", "Search", reflect.TypeOf(routerSearch{})))
+
+	since := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	limit := 5
+	expected := routerSearch{Owner: "ann", Tags: []string{"a", "b", "c"}, Pages: []int{1, 2}, Limit: &limit, Since: since, Text: "shoes"}
+
+	for _, encoding := range []string{app.EncodingJSON, app.EncodingMessagePack, app.EncodingCBOR} {
+		body, err := app.MarshalEncoding(encoding, map[string]interface{}{"text": "shoes", "owner": "bob"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/v1/RouterUsers/Search/ann?tag=a,b&tag=c&pages=1,2&limit=5&since=2020-01-02T03:04:05Z", bytes.NewReader(body))
+		request.Header.Set("Content-Type", app.ContentTypeOfEncoding(encoding))
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s:  expected 200, got %d %s", encoding, recorder.Code, recorder.Body.String())
+			continue
+		}
+		var search routerSearch
+		err = app.UnmarshalEncoding(encoding, recorder.Body.Bytes(), &search)
+		if err != nil {
+			t.Errorf("%s:  failed to decode response:  %v", encoding, err)
+			continue
+		}
+		if !search.Since.Equal(since) {
+			t.Errorf("%s:  expected since %v, got %v", encoding, since, search.Since)
+		}
+		search.Since = since
+		if !reflect.DeepEqual(search, expected) {
+			t.Errorf("%s:  expected %+v, got %+v", encoding, expected, search)
+		}
+	}
+}
+
+func TestParseRESTValue(t *testing.T) {
+	type point struct {
+		X int `json:"x"`
+	}
+
+	tests := []struct {
+		value    interface{}
+		values   []string
+		expected interface{}
+		err      bool
+	}{
+		{"", []string{"a", "b"}, "b", false},
+		{0, []string{"-3"}, -3, false},
+		{int8(0), []string{"300"}, nil, true},
+		{uint(0), []string{"7"}, uint(7), false},
+		{0.0, []string{"1.5"}, 1.5, false},
+		{false, []string{"true"}, true, false},
+		{false, []string{"yes"}, nil, true},
+		{[]int{}, []string{"1,2", "3"}, []int{1, 2, 3}, false},
+		{[]byte{}, []string{"aGk="}, []byte("hi"), false},
+		{point{}, []string{`{"x":4}`}, point{X: 4}, false},
+		{map[string]int{}, []string{`{"a":1}`}, map[string]int{"a": 1}, false},
+		{time.Time{}, []string{"2020-01-02T03:04:05Z"}, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{time.Time{}, []string{"yesterday"}, nil, true},
+	}
+
+	for _, test := range tests {
+		value, err := parseRESTValue(reflect.TypeOf(test.value), test.values)
+		if test.err {
+			if err == nil {
+				t.Errorf("%T %v:  expected an error, got %v", test.value, test.values, value.Interface())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%T %v:  unexpected error %v", test.value, test.values, err)
+			continue
+		}
+		if !reflect.DeepEqual(value.Interface(), test.expected) {
+			t.Errorf("%T %v:  expected %#v, got %#v", test.value, test.values, test.expected, value.Interface())
+		}
+	}
+
+	value, err := parseRESTValue(reflect.TypeOf((*int)(nil)), []string{"9"})
+	if err != nil || *value.Interface().(*int) != 9 {
+		t.Errorf("expected a pointer to 9, got %v %v", value, err)
+	}
+}
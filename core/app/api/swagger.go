@@ -0,0 +1,403 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/extensions"
+	"github.com/gin-gonic/gin"
+)
+
+const swaggerErrorDefinition = "apiErrorResponse"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+//swaggerDefinitionTypes holds the type each definition name was given to.
+var swaggerDefinitionTypes sync.Map
+
+/*RegisterSwaggerPaths documents every exported action of the registered controllers as an operation of the route APICallback is mounted on for POST.
+Each action is its own path with the controller and action query string, ie /api?controller=Users&action=Get,
+with the parameter of the action as the body and its result as the response.  Call it after all controllers are registered.
+Implementation example-----------
+api.RegisterSwaggerPaths("/api")
+ginServer.Router.GET("/swagger.json", api.SwaggerCallback)
+---------------------------------
+*/
+func RegisterSwaggerPaths(apiPath string) {
+
+	addSwaggerErrorDefinition()
+
+	for _, key := range getControllerKeys() {
+		ctl := getController(key)
+		ctlType := ctl.Type()
+
+		dbServices.AddSwaggerTag(key, key+" controller", "", "")
+
+		for i := 0; i < ctlType.NumMethod(); i++ {
+			action := ctlType.Method(i).Name
+			route := apiPath + "?controller=" + key + "&action=" + action
+			op := swaggerCallOperation(key, action, ctl.Method(i).Type())
+			dbServices.AddSwaggerPOSTPath(route, dbServices.Swagger2Path{POST: &op})
+		}
+	}
+}
+
+/*RegisterSwaggerRESTPaths documents every exported action of the registered controllers as the route RegisterRESTRoutes exposes it on.
+Path and query tagged fields are documented as path and query parameters.  Call it after all controllers are registered.
+Implementation example-----------
+api.RegisterRESTRoutes("v1")
+api.RegisterSwaggerRESTPaths("v1")
+ginServer.Router.GET("/swagger.json", api.SwaggerCallback)
+---------------------------------
+*/
+func RegisterSwaggerRESTPaths(version string) {
+
+	addSwaggerErrorDefinition()
+
+	group := "/" + strings.Trim(version, "/")
+
+	for _, key := range getControllerKeys() {
+		ctl := getController(key)
+		ctlType := ctl.Type()
+
+		dbServices.AddSwaggerTag(key, key+" controller", "", "")
+
+		for i := 0; i < ctlType.NumMethod(); i++ {
+			action := ctlType.Method(i).Name
+			methodType := ctl.Method(i).Type()
+			paramType := getActionParamType(methodType)
+			method := getRESTMethod(key, action, paramType)
+
+			route := group + "/" + key + "/" + action
+			for _, field := range getRESTFields(paramType, "path") {
+				route += "/{" + field.tag + "}"
+			}
+			addSwaggerOperation(route, method, swaggerActionOperation(key, action, method, methodType))
+		}
+	}
+}
+
+//SwaggerCallback serves the current swagger definition including the registered controllers.
+func SwaggerCallback(c *gin.Context) {
+	c.Header("Content-Type", "application/json")
+	c.String(http.StatusOK, dbServices.GetSwaggerDefinitionJSONString())
+}
+
+//getControllerKeys returns the registered controller keys in sorted order.
+func getControllerKeys() (keys []string) {
+	registry.Range(func(key interface{}, value interface{}) bool {
+		keys = append(keys, key.(string))
+		return true
+	})
+	sort.Strings(keys)
+	return
+}
+
+//getActionParamType returns the parameter type that receives the request data or nil when the action takes none.
+func getActionParamType(methodType reflect.Type) reflect.Type {
+	rc := new(RequestContext)
+	for i := 0; i < methodType.NumIn(); i++ {
+		_, injected := rc.injectParam(methodType.In(i))
+		if !injected {
+			return methodType.In(i)
+		}
+	}
+	return nil
+}
+
+//getActionResultType returns the type of the first non error return value or nil when the action returns none.
+func getActionResultType(methodType reflect.Type) reflect.Type {
+	if methodType.NumOut() == 0 || methodType.Out(0) == errorInterfaceType {
+		return nil
+	}
+	return methodType.Out(0)
+}
+
+func addSwaggerOperation(route string, method string, op dbServices.Swagger2Operation) {
+	switch method {
+	case http.MethodGet:
+		dbServices.AddSwaggerGETPath(route, dbServices.Swagger2Path{GET: &op})
+	case http.MethodPut:
+		dbServices.AddSwaggerPUTPath(route, dbServices.Swagger2Path{PUT: &op})
+	case http.MethodPatch:
+		dbServices.AddSwaggerPATCHPath(route, dbServices.Swagger2Path{PATCH: &op})
+	case http.MethodDelete:
+		dbServices.AddSwaggerDELETEPath(route, dbServices.Swagger2Path{DELETE: &op})
+	default:
+		dbServices.AddSwaggerPOSTPath(route, dbServices.Swagger2Path{POST: &op})
+	}
+}
+
+//swaggerCallOperation documents an action called through APICallback.  The whole parameter of the action is the body.
+func swaggerCallOperation(controller string, action string, methodType reflect.Type) (op dbServices.Swagger2Operation) {
+
+	op.Tags = []string{controller}
+	op.Summary = controller + "." + action
+	op.OperationId = "call" + controller + action
+	op.Consumes = []string{"application/json"}
+	op.Produces = []string{"application/json"}
+
+	paramType := getActionParamType(methodType)
+	if paramType != nil {
+		schema := swaggerSchema(paramType)
+		op.Parameters = append(op.Parameters, dbServices.Swagger2Parameter{
+			Name:     "body",
+			In:       "body",
+			Required: true,
+			Schema:   &schema,
+		})
+	}
+
+	var result dbServices.Swagger2Schema
+	resultType := getActionResultType(methodType)
+	if resultType != nil {
+		result = swaggerSchema(resultType)
+	}
+	op.Responses = swaggerResponses(result)
+	return
+}
+
+func swaggerActionOperation(controller string, action string, method string, methodType reflect.Type) (op dbServices.Swagger2Operation) {
+
+	op.Tags = []string{controller}
+	op.Summary = controller + "." + action
+	op.OperationId = extensions.MakeFirstLowerCase(controller) + action
+	op.Consumes = []string{"application/json"}
+	op.Produces = []string{"application/json"}
+
+	paramType := getActionParamType(methodType)
+	for _, field := range getRESTFields(paramType, "path") {
+		param := swaggerSimpleParameter(field)
+		param.In = "path"
+		param.Required = true
+		op.Parameters = append(op.Parameters, param)
+	}
+	for _, field := range getRESTFields(paramType, "query") {
+		param := swaggerSimpleParameter(field)
+		param.In = "query"
+		op.Parameters = append(op.Parameters, param)
+	}
+	if paramType != nil && method != http.MethodGet && method != http.MethodDelete {
+		schema := swaggerSchema(paramType)
+		op.Parameters = append(op.Parameters, dbServices.Swagger2Parameter{
+			Name:     "body",
+			In:       "body",
+			Required: true,
+			Schema:   &schema,
+		})
+	}
+
+	var result dbServices.Swagger2Schema
+	resultType := getActionResultType(methodType)
+	if resultType != nil {
+		result = swaggerSchema(resultType)
+	}
+	op.Responses = swaggerResponses(result)
+	return
+}
+
+//swaggerSimpleParameter documents a path or query field.  Slices are sent as repeated or comma separated values.
+func swaggerSimpleParameter(field restField) (param dbServices.Swagger2Parameter) {
+	param.Name = field.tag
+
+	t := field.Field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		item := swaggerItem(t.Elem())
+		param.Type = "array"
+		param.Items = &item
+		param.CollectionFormat = "multi"
+		return
+	}
+
+	schema := swaggerSchema(t)
+	param.Type = schema.Type
+	param.Format = schema.Format
+	if param.Type == "" || param.Type == "object" {
+		param.Type = "string"
+	}
+	return
+}
+
+//swaggerResponses returns the success response with the result schema and the error responses.
+func swaggerResponses(result dbServices.Swagger2Schema) (responses map[string]dbServices.Swagger2Response) {
+	responses = make(map[string]dbServices.Swagger2Response)
+
+	success := dbServices.Swagger2Response{Description: "Successful operation"}
+	if result.Ref != "" || result.Type != "" {
+		success.Schema = &result
+	}
+	responses["200"] = success
+
+	errorRef := dbServices.Swagger2Schema{Ref: "#/definitions/" + swaggerErrorDefinition}
+	responses["400"] = dbServices.Swagger2Response{Description: "Invalid request data", Schema: &errorRef}
+	responses["500"] = dbServices.Swagger2Response{Description: "Action failed", Schema: &errorRef}
+	return
+}
+
+//swaggerSchema builds the schema for a go type from its struct fields and json tags.
+//Named structs are added as definitions and referenced.
+func swaggerSchema(t reflect.Type) (schema dbServices.Swagger2Schema) {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == timeType {
+			schema.Type = "string"
+			schema.Format = "date-time"
+			return
+		}
+		if t.Name() == "" {
+			return swaggerStructSchema(t)
+		}
+		schema.Ref = "#/definitions/" + addSwaggerStructDefinition(t)
+		return
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			schema.Type = "string"
+			schema.Format = "byte"
+			return
+		}
+		item := swaggerItem(t.Elem())
+		schema.Type = "array"
+		schema.Items = &item
+		return
+	case reflect.Map, reflect.Interface:
+		schema.Type = "object"
+		return
+	}
+
+	schema.Type, schema.Format = swaggerPrimitive(t)
+	return
+}
+
+//swaggerItem builds the items of an array schema.
+func swaggerItem(t reflect.Type) (item dbServices.Swagger2Item) {
+	schema := swaggerSchema(t)
+	item.Ref = schema.Ref
+	item.Type = schema.Type
+	item.Format = schema.Format
+	item.Items = schema.Items
+	return
+}
+
+func swaggerPrimitive(t reflect.Type) (valType string, format string) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "integer", "int32"
+	case reflect.Int64, reflect.Uint64:
+		return "integer", "int64"
+	case reflect.Float32:
+		return "number", "float"
+	case reflect.Float64:
+		return "number", "double"
+	}
+	return "string", ""
+}
+
+//swaggerDefinitionName names a struct definition by its package and type, ie controllers.Request.
+//The name is only qualified with the full package path, ie github.com.myApp.controllers.Request, when another type already uses it.
+func swaggerDefinitionName(t reflect.Type) string {
+	if t.PkgPath() == "" {
+		return t.Name()
+	}
+	name := t.String()
+	existing, loaded := swaggerDefinitionTypes.LoadOrStore(name, t)
+	if !loaded || existing.(reflect.Type) == t {
+		return name
+	}
+	name = strings.Replace(t.PkgPath(), "/", ".", -1) + "." + t.Name()
+	swaggerDefinitionTypes.Store(name, t)
+	return name
+}
+
+//addSwaggerStructDefinition adds the struct to the definitions and returns its name.
+func addSwaggerStructDefinition(t reflect.Type) (name string) {
+	name = swaggerDefinitionName(t)
+
+	dbServices.SwaggerDefinition.RLock()
+	_, exists := dbServices.SwaggerDefinition.Definitions[name]
+	dbServices.SwaggerDefinition.RUnlock()
+	if exists {
+		return
+	}
+
+	//Add a placeholder first so self referencing structs do not recurse forever.
+	dbServices.AddSwaggerDefinition(name, dbServices.Swagger2Schema{Type: "object"})
+	dbServices.AddSwaggerDefinition(name, swaggerStructSchema(t))
+	return
+}
+
+func swaggerStructSchema(t reflect.Type) (schema dbServices.Swagger2Schema) {
+	schema.Type = "object"
+	schema.Properties = make(map[string]dbServices.Swagger2Schema)
+
+	for _, field := range getJSONFields(t) {
+		schema.Properties[field.Name] = swaggerSchema(field.Field.Type)
+	}
+	return
+}
+
+type jsonField struct {
+	Name  string
+	Field reflect.StructField
+	Index []int
+}
+
+//getJSONFields returns the exported fields of a struct by their json names, flattening embedded structs.
+func getJSONFields(t reflect.Type) (fields []jsonField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for _, child := range getJSONFields(embedded) {
+					child.Index = append([]int{i}, child.Index...)
+					fields = append(fields, child)
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{Name: name, Field: field, Index: []int{i}})
+	}
+	return
+}
+
+func addSwaggerErrorDefinition() {
+	errorSchema := swaggerStructSchema(reflect.TypeOf(errorObj{}))
+	var def dbServices.Swagger2Schema
+	def.Type = "object"
+	def.Properties = map[string]dbServices.Swagger2Schema{"error": errorSchema}
+	dbServices.AddSwaggerDefinition(swaggerErrorDefinition, def)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielRenne/GoCore/core/dbServices"
+)
+
+type swaggerCollision struct {
+	Name string `json:"name"`
+}
+
+func TestRegisterSwaggerPaths(t *testing.T) {
+	RegisterSwaggerPaths("/api")
+
+	dbServices.SwaggerDefinition.RLock()
+	path, ok := dbServices.SwaggerDefinition.Paths["/api?controller=RouterUsers&action=Get"]
+	_, defined := dbServices.SwaggerDefinition.Definitions["api.routerUser"]
+	dbServices.SwaggerDefinition.RUnlock()
+
+	if !ok || path.POST == nil {
+		t.Fatalf("expected a POST operation for RouterUsers.Get, got %+v", path)
+	}
+	if !defined {
+		t.Error("expected the api.routerUser definition")
+	}
+
+	op := path.POST
+	if len(op.Parameters) != 1 || op.Parameters[0].In != "body" || op.Parameters[0].Schema.Ref != "#/definitions/api.routerUser" {
+		t.Errorf("expected the body to reference api.routerUser, got %+v", op.Parameters)
+	}
+	if op.Responses["200"].Schema == nil || op.Responses["200"].Schema.Ref != "#/definitions/api.routerUser" {
+		t.Errorf("expected the response to reference api.routerUser, got %+v", op.Responses["200"])
+	}
+}
+
+func TestSwaggerDefinitionName(t *testing.T) {
+	collision := reflect.TypeOf(swaggerCollision{})
+	if name := swaggerDefinitionName(reflect.TypeOf(routerUser{})); name != "api.routerUser" {
+		t.Errorf("expected api.routerUser, got %s", name)
+	}
+
+	swaggerDefinitionTypes.Store("api.swaggerCollision", reflect.TypeOf(routerUser{}))
+	defer swaggerDefinitionTypes.Delete("api.swaggerCollision")
+	if name := swaggerDefinitionName(collision); name != "github.com.DanielRenne.GoCore.core.app.api.swaggerCollision" {
+		t.Errorf("expected the colliding name to be qualified with the package path, got %s", name)
+	}
+}
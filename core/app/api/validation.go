@@ -0,0 +1,247 @@
+package api
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/asaskevich/govalidator"
+)
+
+//ErrCodeValidation is returned with a 400 when an action parameter fails its validate tags.  The error details list each FieldError.
+const ErrCodeValidation = dbServices.ERROR_CODE_VALIDATION
+
+//Field error codes for the validate tag positions.  Failed types use "notValid" + the type, ie notValidEmail.
+const (
+	ValidationCodeRequired  = dbServices.ERROR_CODE_VALIDATION_REQUIRED
+	ValidationCodeMin       = "min"
+	ValidationCodeMax       = "max"
+	ValidationCodeLength    = "length"
+	ValidationCodeLengthMax = "lengthMax"
+	ValidationCodeLengthMin = "lengthMin"
+)
+
+//FieldError describes a single failed validation of a parameter field.  Field is the json path, ie items[0].email.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var validationTypes sync.Map
+
+var builtinValidationTypes = map[string]func(value string) bool{
+	dbServices.VALIDATION_TYPE_EMAIL: isValidEmail,
+	"url":                            isValidURL,
+	"ip":                             func(value string) bool { return net.ParseIP(value) != nil },
+	"uuid":                           regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+	"objectId":                       regexp.MustCompile(`^[0-9a-fA-F]{24}$`).MatchString,
+	"alpha":                          regexp.MustCompile(`^[a-zA-Z]+$`).MatchString,
+	"alphanumeric":                   regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString,
+	"numeric":                        regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+$`).MatchString,
+	"hex":                            regexp.MustCompile(`^[0-9a-fA-F]+$`).MatchString,
+}
+
+/*RegisterValidationType adds a type for the second position of validate tags or replaces a built in type
+(email, url, ip, uuid, objectId, alpha, alphanumeric, numeric, hex).
+Implementation example-----------
+api.RegisterValidationType("zip", func(value string) bool {
+	return len(value) == 5
+})
+type Address struct {
+	Zip string `json:"zip" validate:"true,zip,,,,,"`
+}
+---------------------------------
+*/
+func RegisterValidationType(name string, isValid func(value string) bool) {
+	validationTypes.Store(name, isValid)
+}
+
+func getValidationType(name string) (isValid func(value string) bool, ok bool) {
+	obj, ok := validationTypes.Load(name)
+	if ok {
+		return obj.(func(value string) bool), true
+	}
+	isValid, ok = builtinValidationTypes[name]
+	return
+}
+
+/*Validate checks the validate tags of a struct and its nested structs and returns every failure.
+Tags use the same positions as generated models:  required,type,min,max,length,lengthMax,lengthMin.
+Action parameters are validated automatically after they are decoded and failures return a 400 with ErrCodeValidation.
+Implementation example-----------
+type CreateUser struct {
+	Email string `json:"email" validate:"true,email,,,,,"`
+	Name  string `json:"name" validate:"true,,,,,50,2"`
+	Age   int    `json:"age" validate:"false,,18,120,,,"`
+}
+---------------------------------
+*/
+func Validate(x interface{}) (fieldErrors []FieldError) {
+	validateValue(reflect.ValueOf(x), "", &fieldErrors)
+	return
+}
+
+func validateValue(val reflect.Value, path string, fieldErrors *[]FieldError) {
+
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		if val.Type() == timeType {
+			return
+		}
+		for _, field := range getJSONFields(val.Type()) {
+			fieldValue, ok := fieldByIndex(val, field.Index)
+			if !ok {
+				continue
+			}
+			fieldPath := field.Name
+			if path != "" {
+				fieldPath = path + "." + field.Name
+			}
+			tag := field.Field.Tag.Get("validate")
+			if tag != "" {
+				validateField(fieldValue, fieldPath, tag, fieldErrors)
+			}
+			validateValue(fieldValue, fieldPath, fieldErrors)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			validateValue(val.Index(i), path+"["+strconv.Itoa(i)+"]", fieldErrors)
+		}
+	}
+}
+
+//fieldByIndex follows an embedded field index and returns false when it passes through a nil embedded pointer.
+func fieldByIndex(val reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if val.Kind() == reflect.Ptr {
+				if val.IsNil() {
+					return val, false
+				}
+				val = val.Elem()
+			}
+		}
+		val = val.Field(x)
+	}
+	return val, true
+}
+
+func validateField(val reflect.Value, path string, tag string, fieldErrors *[]FieldError) {
+
+	parts := strings.Split(tag, ",")
+	for len(parts) < 7 {
+		parts = append(parts, "")
+	}
+	required, validationType, min, max, length, lengthMax, lengthMin := parts[0], parts[1], parts[2], parts[3], parts[4], parts[5], parts[6]
+
+	addError := func(code string, message string) {
+		*fieldErrors = append(*fieldErrors, FieldError{Field: path, Code: code, Message: path + " " + message})
+	}
+
+	if isEmptyValue(val) {
+		if required == "true" {
+			addError(ValidationCodeRequired, "is required.")
+		}
+		return
+	}
+
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+
+	if number, ok := numericValue(val); ok {
+		if limit, err := strconv.ParseFloat(min, 64); err == nil && number < limit {
+			addError(ValidationCodeMin, "must be at least "+min+".")
+		}
+		if limit, err := strconv.ParseFloat(max, 64); err == nil && number > limit {
+			addError(ValidationCodeMax, "must be at most "+max+".")
+		}
+	}
+
+	if size, ok := lengthValue(val); ok {
+		if limit, err := strconv.Atoi(length); err == nil && size != limit {
+			addError(ValidationCodeLength, "must have a length of "+length+".")
+		}
+		if limit, err := strconv.Atoi(lengthMin); err == nil && size < limit {
+			addError(ValidationCodeLengthMin, "must have a length of at least "+lengthMin+".")
+		}
+		if limit, err := strconv.Atoi(lengthMax); err == nil && size > limit {
+			addError(ValidationCodeLengthMax, "must have a length of at most "+lengthMax+".")
+		}
+	}
+
+	if validationType != "" && val.Kind() == reflect.String {
+		isValid, ok := getValidationType(validationType)
+		if ok && !isValid(val.String()) {
+			addError("notValid"+strings.Title(validationType), "must be a valid "+validationType+".")
+		}
+	}
+}
+
+//isEmptyValue returns true for empty strings, nil pointers and empty collections.  Numbers and booleans are never empty.
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.String:
+		return val.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return val.IsNil()
+	case reflect.Slice, reflect.Map:
+		return val.Len() == 0
+	case reflect.Struct:
+		return val.Type() == timeType && val.IsZero()
+	}
+	return false
+}
+
+func numericValue(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	}
+	return 0, false
+}
+
+func lengthValue(val reflect.Value) (int, bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(val.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return val.Len(), true
+	}
+	return 0, false
+}
+
+//isValidEmail uses the check of the generated models so a parameter that validates also saves.
+func isValidEmail(value string) bool {
+	return govalidator.IsEmail(value)
+}
+
+func isValidURL(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+//newValidationErrorResponse returns the 400 ErrorResponse for failed parameter validation.
+func newValidationErrorResponse(fieldErrors []FieldError) (e ErrorResponse) {
+	e = newErrorResponse(ErrCodeValidation, "Validation failed for "+strconv.Itoa(len(fieldErrors))+" field(s).")
+	e.Error.Details = fieldErrors
+	return
+}
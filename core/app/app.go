@@ -1,7 +1,9 @@
 package app
 
 import (
+	"context"
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -42,8 +44,41 @@ type WebSocketConnection struct {
 	WriteLock            sync.RWMutex
 	LastResponseTime     time.Time
 	LastResponseTimeLock sync.RWMutex
+	//Encoding is negotiated when the socket is opened with the msgpack or cbor sub protocol or an encoding query parameter.
+	//Binary frames from the client are decoded with it and replies to them are sent as binary frames.
+	Encoding string
+	//Transport is TransportWebSocket or, for virtual connections without a web socket, TransportSSE or TransportLongPoll.
+	Transport string
 
 	GinContextSync GinContextSync
+
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+
+	queue         *webSocketSendQueue
+	sendQueueOnce sync.Once
+	session       *webSocketSession
+	virtual       *virtualTransport
+}
+
+//ConnectionContext returns a context that is cancelled when the web socket is closed or removed.
+func (obj *WebSocketConnection) ConnectionContext() context.Context {
+	obj.Lock()
+	defer obj.Unlock()
+	if obj.ctx == nil {
+		obj.ctx, obj.cancelCtx = context.WithCancel(context.Background())
+	}
+	return obj.ctx
+}
+
+//cancelConnectionContext cancels the context of the connection.  It is created cancelled when ConnectionContext was never called.
+func (obj *WebSocketConnection) cancelConnectionContext() {
+	obj.Lock()
+	defer obj.Unlock()
+	if obj.ctx == nil {
+		obj.ctx, obj.cancelCtx = context.WithCancel(context.Background())
+	}
+	obj.cancelCtx()
 }
 
 type GinContextSync struct {
@@ -149,9 +184,11 @@ func (self *WebSocketCallbackSync) Iter() <-chan ConcurrentWebSocketCallbackItem
 	return c
 }
 
+//WebSocketPubSubPayload is published to subscribers.  Seq numbers the payloads of a resumable session.
 type WebSocketPubSubPayload struct {
 	Key     string      `json:"Key"`
 	Content interface{} `json:"Content"`
+	Seq     uint64      `json:"Seq,omitempty"`
 }
 
 type WebSocketCallback func(conn *WebSocketConnection, c *gin.Context, messageType int, id string, data []byte)
@@ -160,6 +197,7 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin:     func(r *http.Request) bool { return true },
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{EncodingMessagePack, EncodingCBOR},
 }
 
 var WebSocketConnections sync.Map
@@ -173,14 +211,10 @@ func Initialize(path string, config string) (err error) {
 		return
 	}
 
-	serverSettings.WebConfigMutex.RLock()
-	inRelease := serverSettings.WebConfig.Application.ReleaseMode == "release"
-	serverSettings.WebConfigMutex.RUnlock()
-
-	if inRelease {
-		ginServer.Initialize(gin.ReleaseMode, serverSettings.WebConfig.Application.CookieDomain)
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "release" {
+		ginServer.Initialize(gin.ReleaseMode, serverSettings.GetWebConfig().Application.CookieDomain)
 	} else {
-		ginServer.Initialize(gin.DebugMode, serverSettings.WebConfig.Application.CookieDomain)
+		ginServer.Initialize(gin.DebugMode, serverSettings.GetWebConfig().Application.CookieDomain)
 	}
 	fileCache.Initialize()
 
@@ -188,6 +222,8 @@ func Initialize(path string, config string) (err error) {
 	if err != nil {
 		return
 	}
+
+	err = serverSettings.WatchWebConfig()
 	return
 }
 
@@ -197,19 +233,14 @@ func InitializeLite() (err error) {
 	return
 }
 
+//RunLite serves the gin router on port until Shutdown or a SIGTERM or SIGINT.
 func RunLite(port int) {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("Panic Recovered at RunLite():  ", r)
-			time.Sleep(time.Millisecond * 3000)
-			RunLite(port)
-			return
-		}
-	}()
 
 	ginServer.Router.GET("/ws", func(c *gin.Context) {
 		webSocketHandler(c.Writer, c.Request, c)
 	})
+	mountVirtualWebSocketRoutes()
+	mountHealthRoutes()
 
 	log.Println("GoCore Application Started")
 
@@ -219,24 +250,16 @@ func RunLite(port int) {
 		ReadTimeout:  300 * time.Second,
 		WriteTimeout: 300 * time.Second,
 	}
-	s.ListenAndServe()
+	serveUntilShutdown(s)
 
 }
 
+//Run serves the application until Shutdown or a SIGTERM or SIGINT.
 func Run() {
 
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("Panic Recovered at Run():  ", r)
-			time.Sleep(time.Millisecond * 3000)
-			Run()
-			return
-		}
-	}()
-
-	if serverSettings.WebConfig.Application.MountGitWebHooks == true {
-		hook, _ := github.New(github.Options.Secret(serverSettings.WebConfig.Application.GitWebHookSecretKey))
-		http.HandleFunc(serverSettings.WebConfig.Application.GitWebHookPath, func(w http.ResponseWriter, r *http.Request) {
+	if serverSettings.GetWebConfig().Application.MountGitWebHooks == true {
+		hook, _ := github.New(github.Options.Secret(serverSettings.GetWebConfig().Application.GitWebHookSecretKey))
+		http.HandleFunc(serverSettings.GetWebConfig().Application.GitWebHookPath, func(w http.ResponseWriter, r *http.Request) {
 
 			// only these git hooks are supported right now to pass parsed github info to you
 			payload, err := hook.Parse(r, github.PushEvent, github.IssuesEvent, github.IssueCommentEvent, github.CreateEvent, github.DeleteEvent, github.ProjectCardEvent, github.ProjectColumnEvent, github.ProjectEvent)
@@ -267,13 +290,14 @@ func Run() {
 			}
 		})
 		port := "12345"
-		if serverSettings.WebConfig.Application.GitWebHookPort != "" {
-			port = serverSettings.WebConfig.Application.GitWebHookPort
+		if serverSettings.GetWebConfig().Application.GitWebHookPort != "" {
+			port = serverSettings.GetWebConfig().Application.GitWebHookPort
 		}
-		go http.ListenAndServe(":"+port, nil)
+		hookServer := &http.Server{Addr: ":" + port}
+		go listenAndServe(hookServer, "", "")
 	}
 
-	if serverSettings.WebConfig.Application.WebServiceOnly == false {
+	if serverSettings.GetWebConfig().Application.WebServiceOnly == false {
 
 		loadHTMLTemplates()
 
@@ -282,30 +306,32 @@ func Run() {
 		ginServer.Router.GET("/ws", func(c *gin.Context) {
 			webSocketHandler(c.Writer, c.Request, c)
 		})
+		mountVirtualWebSocketRoutes()
 	}
 
 	initializeStaticRoutes()
+	mountHealthRoutes()
 
 	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Println("Panic Recovered at Run TLS():  ", r)
-				return
-			}
-		}()
-
-		ginServer.Router.RunTLS(":"+strconv.Itoa(serverSettings.WebConfig.Application.HttpsPort), serverSettings.APP_LOCATION+"/keys/cert.pem", serverSettings.APP_LOCATION+"/keys/key.pem")
+		tlsServer := &http.Server{
+			Addr:    ":" + strconv.Itoa(serverSettings.GetWebConfig().Application.HttpsPort),
+			Handler: ginServer.Router,
+		}
+		err := listenAndServe(tlsServer, serverSettings.APP_LOCATION+"/keys/cert.pem", serverSettings.APP_LOCATION+"/keys/key.pem")
+		if err != nil && err != http.ErrServerClosed {
+			log.Println("GoCore Application failed to serve TLS:  " + err.Error())
+		}
 	}()
 
 	log.Println("GoCore Application Started")
 
 	s := &http.Server{
-		Addr:         ":" + strconv.Itoa(serverSettings.WebConfig.Application.HttpPort),
+		Addr:         ":" + strconv.Itoa(serverSettings.GetWebConfig().Application.HttpPort),
 		Handler:      ginServer.Router,
 		ReadTimeout:  300 * time.Second,
 		WriteTimeout: 300 * time.Second,
 	}
-	s.ListenAndServe()
+	serveUntilShutdown(s)
 
 	// ginServer.Router.Run(":" + strconv.Itoa(serverSettings.WebConfig.Application.HttpPort))
 
@@ -327,7 +353,7 @@ func webSocketHandler(w http.ResponseWriter, r *http.Request, c *gin.Context) {
 		}
 	}()
 
-	if serverSettings.WebConfig.Application.AllowCrossOriginRequests {
+	if serverSettings.GetWebConfig().Application.AllowCrossOriginRequests {
 		r.Header.Add("Access-Control-Allow-Origin", "*")
 	}
 
@@ -348,22 +374,42 @@ func webSocketHandler(w http.ResponseWriter, r *http.Request, c *gin.Context) {
 	wsConn.Connection = conn
 	wsConn.Req = r
 	wsConn.GinContextSync.Context = c
-	uuid, err := newUUID()
-	if err == nil {
-		wsConn.Id = uuid
+	wsConn.Encoding = webSocketEncoding(conn, r)
+	wsConn.Transport = TransportWebSocket
+
+	grace, _ := getResumeSettings()
+	resumed := grace > 0 && resumeWebSocketSession(wsConn, r)
+
+	if !resumed {
+		uuid, err := newUUID()
+		if err == nil {
+			wsConn.Id = uuid
+		} else {
+			wsConn.Id = randomString(20)
+		}
+	}
+	uuid := wsConn.Id
+
+	//A resumed session keeps its meta and rooms.
+	socketMeta, ok := GetWebSocketMeta(uuid)
+	if resumed && ok {
+		socketMeta.Conn = wsConn
+		socketMeta.LastResponseTime.Set(time.Now())
+		replaceWebSocketMemberships(wsConn)
 	} else {
-		uuid = randomString(20)
-		wsConn.Id = uuid
+		socketMeta = new(WebSocketConnectionMeta)
+		socketMeta.Conn = wsConn
+		socketMeta.LastResponseTime.Set(time.Now())
+		SetWebSocketMeta(uuid, socketMeta)
 	}
 
-	socketMeta := new(WebSocketConnectionMeta)
-	socketMeta.Conn = wsConn
-	socketMeta.LastResponseTime.Set(time.Now())
-
-	SetWebSocketMeta(uuid, socketMeta)
+	if grace > 0 && !resumed {
+		startWebSocketSession(wsConn)
+	}
+	startWebSocketHeartbeat(wsConn)
 
 	if CustomLog != nil {
-		CustomLog("app->webSocketHandler", "Added Web Socket Connection from "+wsConn.Connection.RemoteAddr().String())
+		CustomLog("app->webSocketHandler", "Added Web Socket Connection from "+wsConn.remoteAddr())
 	}
 
 	//Reader
@@ -378,36 +424,11 @@ func webSocketHandler(w http.ResponseWriter, r *http.Request, c *gin.Context) {
 		for {
 			messageType, p, err := conn.ReadMessage()
 			if err == nil {
-
-				go func() {
-					defer func() {
-						if recover := recover(); recover != nil {
-							log.Println("Panic Recovered at webSocketHandler-> Reader-> item.Callback():  ", recover)
-						}
-					}()
-
-					meta, ok := GetWebSocketMeta(uuid)
-					if ok {
-						meta.LastResponseTime.Set(time.Now())
-						SetWebSocketMeta(uuid, meta)
-					}
-
-					WebSocketCallbacks.Range(func(key interface{}, value interface{}) bool {
-						callback, parsed := value.(WebSocketCallback)
-						if parsed {
-							// if strings.Contains(meta.ContextString, "{\"Page\"") {
-							// 	CustomLog("Websocket Request", string(p))
-							// }
-							callback(wsConn, c, messageType, uuid, p)
-						}
-						return true
-					})
-
-				}()
-
+				extendWebSocketDeadline(wsConn)
+				go dispatchWebSocketMessage(wsConn, c, messageType, p)
 			} else {
 				if CustomLog != nil {
-					CustomLog("app->deleteWebSocket", "Deleting Web Socket from read Timeout:  "+err.Error()+":  "+wsConn.Connection.RemoteAddr().String())
+					CustomLog("app->deleteWebSocket", "Deleting Web Socket from read Timeout:  "+err.Error()+":  "+wsConn.remoteAddr())
 				}
 				deleteWebSocket(wsConn)
 				return
@@ -418,6 +439,75 @@ func webSocketHandler(w http.ResponseWriter, r *http.Request, c *gin.Context) {
 	WebSocketConnections.Store(wsConn.Id, wsConn)
 }
 
+//dispatchWebSocketMessage handles room control messages and calls every WebSocketCallback with a message from the client.
+func dispatchWebSocketMessage(wsConn *WebSocketConnection, c *gin.Context, messageType int, p []byte) {
+	defer func() {
+		if recover := recover(); recover != nil {
+			log.Println("Panic Recovered at webSocketHandler-> Reader-> item.Callback():  ", recover)
+		}
+	}()
+
+	uuid := wsConn.Id
+	meta, ok := GetWebSocketMeta(uuid)
+	if ok {
+		meta.LastResponseTime.Set(time.Now())
+		SetWebSocketMeta(uuid, meta)
+	}
+
+	if handleWebSocketRoomControl(wsConn, messageType, p) {
+		return
+	}
+
+	WebSocketCallbacks.Range(func(key interface{}, value interface{}) bool {
+		callback, parsed := value.(WebSocketCallback)
+		if parsed {
+			// if strings.Contains(meta.ContextString, "{\"Page\"") {
+			// 	CustomLog("Websocket Request", string(p))
+			// }
+			callback(wsConn, c, messageType, uuid, p)
+		}
+		return true
+	})
+}
+
+//remoteAddr returns the address of the client for logging.
+func (obj *WebSocketConnection) remoteAddr() string {
+	if obj.Connection != nil {
+		return obj.Connection.RemoteAddr().String()
+	}
+	if obj.Req != nil {
+		return obj.Req.RemoteAddr
+	}
+	return ""
+}
+
+//sendCloseFrame tells the client the server is going away.  Virtual connections have no close frame.
+func (obj *WebSocketConnection) sendCloseFrame() {
+	if obj.Connection != nil {
+		obj.Connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(webSocketPingWriteWait))
+	}
+}
+
+//closeTransport closes the web socket.  Virtual connections end when their context is cancelled.
+func (obj *WebSocketConnection) closeTransport() {
+	if obj.Connection != nil {
+		obj.Connection.Close()
+	}
+}
+
+//webSocketEncoding returns the encoding selected by the sub protocol or the encoding query parameter of the upgrade request.
+func webSocketEncoding(conn *websocket.Conn, r *http.Request) string {
+	encoding := conn.Subprotocol()
+	if encoding == "" {
+		encoding = r.URL.Query().Get("encoding")
+	}
+	if IsBinaryEncoding(encoding) {
+		return encoding
+	}
+	return EncodingJSON
+}
+
+//CloseAllSockets sends a going away close frame to every web socket and removes every connection.
 func CloseAllSockets() {
 
 	items := []*WebSocketConnection{}
@@ -430,20 +520,23 @@ func CloseAllSockets() {
 
 	for i := range items {
 		connection := items[i]
-		connection.Connection.UnderlyingConn().Close()
+		connection.sendCloseFrame()
+		connection.closeTransport()
 		WebSocketConnections.Delete(connection.Id)
+		connection.cancelConnectionContext()
+		removeWebSocketMemberships(connection)
 	}
 
 }
 
 func loadHTMLTemplates() {
 
-	if serverSettings.WebConfig.Application.HtmlTemplates.Enabled {
+	if serverSettings.GetWebConfig().Application.HtmlTemplates.Enabled {
 
 		levels := "/*"
 		dirLevel := ""
 
-		switch serverSettings.WebConfig.Application.HtmlTemplates.DirectoryLevels {
+		switch serverSettings.GetWebConfig().Application.HtmlTemplates.DirectoryLevels {
 		case 0:
 			levels = "/*"
 			dirLevel = ""
@@ -455,22 +548,22 @@ func loadHTMLTemplates() {
 			dirLevel = "root/root/"
 		}
 
-		ginServer.Router.LoadHTMLGlob(serverSettings.APP_LOCATION + "/web/" + serverSettings.WebConfig.Application.HtmlTemplates.Directory + levels)
+		ginServer.Router.LoadHTMLGlob(serverSettings.APP_LOCATION + "/web/" + serverSettings.GetWebConfig().Application.HtmlTemplates.Directory + levels)
 
 		ginServer.Router.GET("", func(c *gin.Context) {
 			c.HTML(http.StatusOK, dirLevel+"index.tmpl", gin.H{})
 		})
 	} else {
 
-		if serverSettings.WebConfig.Application.DisableRootIndex {
+		if serverSettings.GetWebConfig().Application.DisableRootIndex {
 			return
 		}
 
 		ginServer.Router.GET("", func(c *gin.Context) {
-			if serverSettings.WebConfig.Application.RootIndexPath == "" {
+			if serverSettings.GetWebConfig().Application.RootIndexPath == "" {
 				ginServer.ReadHTMLFile(serverSettings.APP_LOCATION+"/web/index.htm", c)
 			} else {
-				ginServer.ReadHTMLFile(serverSettings.APP_LOCATION+"/web/"+serverSettings.WebConfig.Application.RootIndexPath, c)
+				ginServer.ReadHTMLFile(serverSettings.APP_LOCATION+"/web/"+serverSettings.GetWebConfig().Application.RootIndexPath, c)
 			}
 		})
 	}
@@ -490,239 +583,94 @@ func RegisterWebSocketDataCallback(callback WebSocketCallback) {
 	WebSocketCallbacks.Store(uuid, callback)
 }
 
+//ReplyToWebSocket queues data as a text frame.  Messages to a connection are written in order by a single writer.
 func ReplyToWebSocket(conn *WebSocketConnection, data []byte) {
-	defer func() {
-		if recover := recover(); recover != nil {
-			log.Println("Panic Recovered at ReplyToWebSocket():  ", recover)
-			return
-		}
-
-	}()
-
-	go func() {
-
-		unlocked := false
-		defer func() {
-			if recover := recover(); recover != nil {
-				if !unlocked {
-					conn.WriteLock.Unlock()
-				}
-				CustomLog("app->ReplyToWebSocket", "Panic Recovered at ReplyToWebSocket():  "+fmt.Sprintf("%+v", recover))
-			}
-		}()
-		conn.WriteLock.Lock()
-		conn.Connection.WriteMessage(websocket.TextMessage, data)
-		conn.WriteLock.Unlock()
-		unlocked = true
-
-	}()
+	conn.enqueue(websocket.TextMessage, data)
 }
 
+//ReplyToWebSocketJSON queues v as a json text frame.
 func ReplyToWebSocketJSON(conn *WebSocketConnection, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Println("Failed to marshal web socket reply:  " + err.Error())
+		return
+	}
+	conn.enqueue(websocket.TextMessage, data)
+}
 
-	defer func() {
-		if recover := recover(); recover != nil {
-			log.Println("Panic Recovered at ReplyToWebSocketJSON():  ", recover)
-			return
-		}
-	}()
-
-	go func() {
-
-		unlocked := false
+//ReplyToWebSocketEncoded queues v as a binary frame with the negotiated encoding of the connection or as json when none was negotiated.
+func ReplyToWebSocketEncoded(conn *WebSocketConnection, v interface{}) {
 
-		defer func() {
-			if recover := recover(); recover != nil {
-				if !unlocked {
-					conn.WriteLock.Unlock()
-				}
-				CustomLog("app->ReplyToWebSocketJSON", "Panic Recovered at ReplyToWebSocketJSON():  "+fmt.Sprintf("%+v", recover))
-			}
-		}()
-
-		conn.Connection.SetWriteDeadline(time.Now().Add(time.Duration(10000) * time.Millisecond))
-		conn.WriteLock.Lock()
-		conn.Connection.WriteJSON(v)
-		conn.WriteLock.Unlock()
-		unlocked = true
-	}()
+	if !IsBinaryEncoding(conn.Encoding) {
+		ReplyToWebSocketJSON(conn, v)
+		return
+	}
 
+	data, err := MarshalEncoding(conn.Encoding, v)
+	if err != nil {
+		log.Println("Failed to encode web socket reply as " + conn.Encoding + ":  " + err.Error())
+		return
+	}
+	conn.enqueue(websocket.BinaryMessage, data)
 }
 
+//ReplyToWebSocketPubSub queues a WebSocketPubSubPayload with the key and content.  Resumable sessions keep it for replay.
 func ReplyToWebSocketPubSub(conn *WebSocketConnection, key string, v interface{}) {
-	defer func() {
-		if recover := recover(); recover != nil {
-		}
-	}()
-
 	var payload WebSocketPubSubPayload
 	payload.Key = key
 	payload.Content = v
 
-	go func() {
-
-		unlocked := false
-		defer func() {
-			if recover := recover(); recover != nil {
-				if !unlocked {
-					conn.WriteLock.Unlock()
-				}
-				CustomLog("app->ReplyToWebSocketPubSub", "Panic Recovered at ReplyToWebSocketPubSub():  "+fmt.Sprintf("%+v", recover))
-			}
-		}()
-
-		conn.Connection.SetWriteDeadline(time.Now().Add(time.Duration(10000) * time.Millisecond))
-		conn.WriteLock.Lock()
-		conn.Connection.WriteJSON(payload)
-		conn.WriteLock.Unlock()
-		unlocked = true
-
-	}()
-
+	if conn.session != nil {
+		publishToWebSocketSession(conn.session, payload)
+		return
+	}
+	ReplyToWebSocketJSON(conn, payload)
 }
 
+//BroadcastWebSocketData queues data as a binary frame to every connection of the cluster.
 func BroadcastWebSocketData(data []byte) {
-
-	defer func() {
-		if recover := recover(); recover != nil {
-			log.Println("Panic Recovered at WebSocketConnections():  ", recover)
-			return
-		}
-	}()
-
-	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
-		conn, _ := value.(*WebSocketConnection)
-
-		go func() {
-
-			unlocked := false
-			defer func() {
-				if recover := recover(); recover != nil {
-					if !unlocked {
-						conn.WriteLock.Unlock()
-					}
-					CustomLog("app->BroadcastWebSocketData", "Panic Recovered at BroadcastWebSocketData():  "+fmt.Sprintf("%+v", recover))
-				}
-			}()
-			conn.WriteLock.Lock()
-			conn.Connection.WriteMessage(websocket.BinaryMessage, data)
-			conn.WriteLock.Unlock()
-			unlocked = true
-			// deadLockChan <- 0
-		}()
-		return true
-	})
-	return
+	enqueueWebSocketAll(websocket.BinaryMessage, data)
+	publishToCluster(ClusterBroadcastData, "", "", data)
 }
 
+//BroadcastWebSocketJSON queues v as a json text frame to every connection of the cluster.
 func BroadcastWebSocketJSON(v interface{}) {
-	defer func() {
-		if recover := recover(); recover != nil {
-			log.Println("Panic Recovered at BroadcastWebSocketJSON():  ", recover)
-			time.Sleep(time.Millisecond * 3000)
-			BroadcastWebSocketJSON(v)
-			return
-		}
-	}()
-
-	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
-		conn, _ := value.(*WebSocketConnection)
-
-		go func() {
-
-			unlocked := false
-			defer func() {
-				if recover := recover(); recover != nil {
-					if !unlocked {
-						conn.WriteLock.Unlock()
-					}
-					CustomLog("app->BroadcastWebSocketJSON", "Panic Recovered at BroadcastWebSocketJSON():  "+fmt.Sprintf("%+v", recover))
-				}
-			}()
-
-			conn.Connection.SetWriteDeadline(time.Now().Add(time.Duration(10000) * time.Millisecond))
-			conn.WriteLock.Lock()
-			conn.Connection.WriteJSON(v)
-			conn.WriteLock.Unlock()
-			unlocked = true
-		}()
-		return true
-	})
-
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Println("Failed to marshal web socket broadcast:  " + err.Error())
+		return
+	}
+	enqueueWebSocketAll(websocket.TextMessage, data)
+	publishToCluster(ClusterBroadcast, "", "", json.RawMessage(data))
 }
 
+//PublishWebSocketJSON queues a WebSocketPubSubPayload with the key and content to every connection of the cluster.
 func PublishWebSocketJSON(key string, v interface{}) {
-	defer func() {
-		if recover := recover(); recover != nil {
-			log.Println("Panic Recovered at PublishWebSocketJSON():  ", recover)
-			return
-		}
-	}()
+	publishWebSocketJSONLocal(key, v)
+	publishToCluster(ClusterPublish, "", key, v)
+}
+
+func publishWebSocketJSONLocal(key string, v interface{}) {
 	var payload WebSocketPubSubPayload
 	payload.Key = key
 	payload.Content = v
 
-	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
-		conn, _ := value.(*WebSocketConnection)
-		go func() {
-
-			unlocked := false
-			defer func() {
-				if recover := recover(); recover != nil {
-					if !unlocked {
-						conn.WriteLock.Unlock()
-					}
-					CustomLog("app->PublishWebSocketJSON", "Panic Recovered at PublishWebSocketJSON():  "+fmt.Sprintf("%+v", recover)+" payload: "+fmt.Sprintf("%+v", payload))
-				}
-			}()
-
-			conn.Connection.SetWriteDeadline(time.Now().Add(time.Duration(10000) * time.Millisecond))
-			conn.WriteLock.Lock()
-			conn.Connection.WriteJSON(payload)
-			conn.WriteLock.Unlock()
-			unlocked = true
-			// deadLockChan <- 0
-		}()
-		return true
-	})
-}
-
-func SetWebSocketTimeout(timeout int) {
-	defer func() {
-		if recover := recover(); recover != nil {
-			log.Println("Panic Recovered at SetWebSocketTimeout():  ", recover)
-			time.Sleep(time.Millisecond * 3000)
-			SetWebSocketTimeout(timeout)
-			return
-		}
-	}()
-
-	// if CustomLog != nil {
-	// 	CustomLog("app->SetWebSocketTimeout", "Checking for Web Socket Timeouts.")
-	// }
-
-	for {
-		webSocketConnectionsMeta.Range(func(key interface{}, value interface{}) bool {
-			meta, ok := value.(*WebSocketConnectionMeta)
-			if ok {
-				duration := time.Millisecond * time.Duration(timeout)
-				timeoutOverride := meta.TimeoutOverride.Get()
-				if timeoutOverride != 0 {
-					duration = time.Millisecond * time.Duration(timeoutOverride)
-				}
-				if meta.LastResponseTime.Get().Add(duration).Before(time.Now()) {
-					if CustomLog != nil {
-						CustomLog("app->SetWebSocketTimeout", "Removed Websocket due to timeout from :  "+meta.Conn.Connection.RemoteAddr().String())
-					}
-					log.Println("Removed Websocket due to timeout from :  " + meta.GetConnection().Connection.RemoteAddr().String())
-					deleteWebSocket(meta.GetConnection())
-				}
-			}
+	grace, _ := getResumeSettings()
+	if grace > 0 {
+		WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
+			ReplyToWebSocketPubSub(value.(*WebSocketConnection), payload.Key, payload.Content)
 			return true
 		})
-		time.Sleep(time.Millisecond * 2500)
+		publishToDetachedWebSocketSessions(payload)
+		return
 	}
 
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Failed to marshal web socket publish of " + key + ":  " + err.Error())
+		return
+	}
+	enqueueWebSocketAll(websocket.TextMessage, data)
 }
 
 func deleteWebSocket(c *WebSocketConnection) {
@@ -736,44 +684,61 @@ func deleteWebSocket(c *WebSocketConnection) {
 		}()
 
 		if CustomLog != nil {
-			CustomLog("app->deleteWebSocket", "Deleting Web Socket from client:  "+c.Connection.RemoteAddr().String())
+			CustomLog("app->deleteWebSocket", "Deleting Web Socket from client:  "+c.remoteAddr())
 		}
 
-		WebSocketConnections.Delete(c.Id)
+		//A resumed session stores its new connection under the same id.
+		current, ok := WebSocketConnections.Load(c.Id)
+		if ok && current == c {
+			WebSocketConnections.Delete(c.Id)
+		}
+		//The client is not told about a read timeout or failed write unless the socket is closed.
+		c.closeTransport()
+		c.cancelConnectionContext()
 
-		if store.OnChange != nil {
-			go func() {
+		if detachWebSocketSession(c) {
+			return
+		}
+		finalizeWebSocketRemoval(c)
+	}()
+
+}
+
+//finalizeWebSocketRemoval removes the rooms and meta of a deleted connection and notifies the removal callbacks.
+func finalizeWebSocketRemoval(c *WebSocketConnection) {
+
+	removeWebSocketMemberships(c)
+
+	if store.OnChange != nil {
+		go func() {
+			defer func() {
+				if recover := recover(); recover != nil {
+					log.Println("Panic Recovered at store.OnChange():  ", recover)
+					return
+				}
+			}()
+
+			store.OnChange(store.WebSocketStoreKey, "", store.PathRemove, nil, nil)
+		}()
+	}
+
+	if WebSocketRemovalCallback != nil {
+		info, ok := GetWebSocketMeta(c.Id)
+		if ok {
+			go func(c *WebSocketConnection) {
 				defer func() {
 					if recover := recover(); recover != nil {
-						log.Println("Panic Recovered at store.OnChange():  ", recover)
+						log.Println("Panic Recovered at deleteWebSocket():  ", recover)
 						return
 					}
 				}()
-
-				store.OnChange(store.WebSocketStoreKey, "", store.PathRemove, nil, nil)
-			}()
+				WebSocketRemovalCallback(*info)
+			}(c)
 		}
 
-		if WebSocketRemovalCallback != nil {
-			info, ok := GetWebSocketMeta(c.Id)
-			if ok {
-				go func(c *WebSocketConnection) {
-					defer func() {
-						if recover := recover(); recover != nil {
-							log.Println("Panic Recovered at deleteWebSocket():  ", recover)
-							return
-						}
-					}()
-					WebSocketRemovalCallback(*info)
-				}(c)
-			}
-
-		}
-
-		RemoveWebSocketMeta(c.Id)
-
-	}()
+	}
 
+	RemoveWebSocketMeta(c.Id)
 }
 
 func GetWebSocketMeta(id string) (info *WebSocketConnectionMeta, ok bool) {
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+//setWebConfig runs update with the webConfig locked and restores the previous webConfig when the test ends.
+func setWebConfig(t *testing.T, update func()) {
+	serverSettings.WebConfigMutex.Lock()
+	saved := serverSettings.WebConfig
+	update()
+	serverSettings.WebConfigMutex.Unlock()
+
+	t.Cleanup(func() {
+		serverSettings.WebConfigMutex.Lock()
+		serverSettings.WebConfig = saved
+		serverSettings.WebConfigMutex.Unlock()
+	})
+}
+
+//newTestConnection registers a long poll connection.  It has no writer, so its queued messages stay until takeMessages.
+func newTestConnection(t *testing.T) *WebSocketConnection {
+	conn := &WebSocketConnection{Id: randomString(20), Transport: TransportLongPoll, virtual: &virtualTransport{}}
+	meta := &WebSocketConnectionMeta{Conn: conn}
+	meta.LastResponseTime.Set(time.Now())
+	SetWebSocketMeta(conn.Id, meta)
+	WebSocketConnections.Store(conn.Id, conn)
+
+	t.Cleanup(func() {
+		conn.cancelConnectionContext()
+		WebSocketConnections.Delete(conn.Id)
+		removeWebSocketMemberships(conn)
+		RemoveWebSocketMeta(conn.Id)
+	})
+	return conn
+}
+
+//takeMessages returns the text of the messages queued on the connection.
+func takeMessages(conn *WebSocketConnection) (messages []string) {
+	for _, message := range conn.sendQueue().take(defaultSendQueueSize) {
+		messages = append(messages, string(message.data))
+	}
+	return
+}
+
+//eventually fails the test when condition is not true within two seconds.
+func eventually(t *testing.T, condition func() bool, message string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+//newTestWebSocketServer serves /ws like Run does.
+func newTestWebSocketServer(t *testing.T) *httptest.Server {
+	router := gin.New()
+	router.GET("/ws", func(c *gin.Context) {
+		webSocketHandler(c.Writer, c.Request, c)
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+//dialTestWebSocket opens a web socket to the server with the query string and returns the client and its server connection.
+func dialTestWebSocket(t *testing.T, server *httptest.Server, query string) (client *websocket.Conn, conn *WebSocketConnection) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	if query != "" {
+		url += "?" + query
+	}
+	client, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+	})
+
+	//The handler stores the connection after it starts reading, the client address identifies it.
+	eventually(t, func() bool {
+		conn = findWebSocketConnection(client.LocalAddr().String())
+		return conn != nil
+	}, "expected the server to register the web socket")
+	return
+}
+
+func findWebSocketConnection(remoteAddr string) (conn *WebSocketConnection) {
+	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
+		candidate := value.(*WebSocketConnection)
+		if candidate.Req != nil && candidate.Req.RemoteAddr == remoteAddr && candidate.Transport == TransportWebSocket {
+			conn = candidate
+			return false
+		}
+		return true
+	})
+	return
+}
+
+func isWebSocketStored(conn *WebSocketConnection) bool {
+	current, ok := WebSocketConnections.Load(conn.Id)
+	return ok && current == conn
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+//Kinds of ClusterMessage, one per publish and broadcast helper.
+const (
+	ClusterPublish       = "publish"
+	ClusterBroadcast     = "broadcast"
+	ClusterBroadcastData = "broadcastData"
+	ClusterRoomPublish   = "roomPublish"
+	ClusterRoomBroadcast = "roomBroadcast"
+	ClusterUserPublish   = "userPublish"
+)
+
+const clusterOutboxSize = 1024
+
+//ClusterMessage is a publish or broadcast sent to the other nodes.  Data is the json content, or the raw bytes of BroadcastWebSocketData.
+type ClusterMessage struct {
+	Node   string `json:"node" bson:"node"`
+	Kind   string `json:"kind" bson:"kind"`
+	Target string `json:"target,omitempty" bson:"target,omitempty"`
+	Key    string `json:"key,omitempty" bson:"key,omitempty"`
+	Data   []byte `json:"data" bson:"data"`
+}
+
+//ClusterBus carries web socket publishes between GoCore nodes.  Publish is called by a single go routine in order.
+//Subscribe is called once when the bus is set and receive is called for every message, including the ones the node published.
+type ClusterBus interface {
+	Publish(message ClusterMessage) error
+	Subscribe(receive func(message ClusterMessage)) error
+	Close() error
+}
+
+var clusterSynced = struct {
+	sync.RWMutex
+	bus    ClusterBus
+	outbox chan ClusterMessage
+}{}
+
+var clusterNodeId = newClusterNodeId()
+
+func newClusterNodeId() string {
+	id, err := newUUID()
+	if err != nil {
+		return randomString(20)
+	}
+	return id
+}
+
+//ClusterNodeId returns the id this node sends with its cluster messages.
+func ClusterNodeId() string {
+	return clusterNodeId
+}
+
+/*SetClusterBus fans out every publish and broadcast helper through the bus so sockets connected to the other nodes receive them.
+A previously set bus is closed.  Pass nil to publish locally only.
+Implementation example-----------
+fileCache.SetGroupCache([]string{"http://localhost:8081", "http://localhost:8082"})
+bus := app.NewHTTPClusterBus()
+bus.Self = "http://localhost:8081"
+bus.Secret = os.Getenv("CLUSTER_SECRET")
+err := app.SetClusterBus(bus)
+
+or
+
+err := app.SetClusterBus(app.NewMongoClusterBus("clusterBus", 16<<20))
+---------------------------------
+*/
+func SetClusterBus(bus ClusterBus) (err error) {
+	clusterSynced.Lock()
+	defer clusterSynced.Unlock()
+
+	if clusterSynced.bus != nil {
+		clusterSynced.bus.Close()
+		clusterSynced.bus = nil
+	}
+	if bus == nil {
+		return
+	}
+
+	err = bus.Subscribe(receiveClusterMessage)
+	if err != nil {
+		return
+	}
+	clusterSynced.bus = bus
+
+	if clusterSynced.outbox == nil {
+		clusterSynced.outbox = make(chan ClusterMessage, clusterOutboxSize)
+		go dispatchClusterMessages(clusterSynced.outbox)
+	}
+	return
+}
+
+func getClusterBus() ClusterBus {
+	clusterSynced.RLock()
+	defer clusterSynced.RUnlock()
+	return clusterSynced.bus
+}
+
+//publishToCluster queues a message for the bus.  v is marshaled to json unless it is the []byte of BroadcastWebSocketData.
+func publishToCluster(kind string, target string, key string, v interface{}) {
+	if getClusterBus() == nil {
+		return
+	}
+
+	message := ClusterMessage{Node: clusterNodeId, Kind: kind, Target: target, Key: key}
+	data, ok := v.([]byte)
+	if ok && kind == ClusterBroadcastData {
+		message.Data = data
+	} else {
+		var err error
+		message.Data, err = json.Marshal(v)
+		if err != nil {
+			log.Println("Failed to marshal cluster message " + kind + ":  " + err.Error())
+			return
+		}
+	}
+
+	clusterSynced.RLock()
+	outbox := clusterSynced.outbox
+	clusterSynced.RUnlock()
+
+	select {
+	case outbox <- message:
+	default:
+		if CustomLog != nil {
+			CustomLog("app->publishToCluster", "Dropped cluster message "+kind+" "+key+" with a full outbox.")
+		}
+	}
+}
+
+//dispatchClusterMessages publishes queued messages in order on the current bus.
+func dispatchClusterMessages(outbox chan ClusterMessage) {
+	for message := range outbox {
+		bus := getClusterBus()
+		if bus == nil {
+			continue
+		}
+		err := publishClusterMessage(bus, message)
+		if err != nil && CustomLog != nil {
+			CustomLog("app->dispatchClusterMessages", "Failed to publish cluster message "+message.Kind+":  "+err.Error())
+		}
+	}
+}
+
+func publishClusterMessage(bus ClusterBus, message ClusterMessage) (err error) {
+	defer func() {
+		if recover := recover(); recover != nil {
+			err = errors.New("Panic Recovered at publishClusterMessage()")
+		}
+	}()
+	return bus.Publish(message)
+}
+
+//receiveClusterMessage publishes a message of another node to the local connections.
+func receiveClusterMessage(message ClusterMessage) {
+	if message.Node == clusterNodeId {
+		return
+	}
+
+	content := json.RawMessage(message.Data)
+	switch message.Kind {
+	case ClusterPublish:
+		publishWebSocketJSONLocal(message.Key, content)
+	case ClusterBroadcast:
+		enqueueWebSocketAll(websocket.TextMessage, message.Data)
+	case ClusterBroadcastData:
+		enqueueWebSocketAll(websocket.BinaryMessage, message.Data)
+	case ClusterRoomPublish:
+		publishWebSocketRoomJSONLocal(message.Target, message.Key, content)
+	case ClusterRoomBroadcast:
+		broadcastWebSocketRoomJSONLocal(message.Target, content)
+	case ClusterUserPublish:
+		publishWebSocketUserJSONLocal(message.Target, message.Key, content)
+	default:
+		log.Println("Unknown cluster message kind " + message.Kind + " from node " + message.Node)
+	}
+}
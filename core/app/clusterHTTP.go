@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/atomicTypes"
+	"github.com/DanielRenne/GoCore/core/fileCache"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/gin-gonic/gin"
+)
+
+//DefaultClusterPath is the route the HTTPClusterBus receives messages on.
+const DefaultClusterPath = "/_gocore/cluster"
+
+const clusterSecretHeader = "X-GoCore-Cluster-Secret"
+
+//HTTPClusterBus posts messages to the peers set with fileCache.SetGroupCache, ie http://localhost:8081.
+//Self is the peer of this node and is skipped.  Secret is required and peers must send the same secret.
+//Each peer has its own queue and sender so a slow or dead peer only delays its own messages.
+type HTTPClusterBus struct {
+	Self       string
+	Path       string
+	Secret     string
+	Client     *http.Client
+	closed     atomicTypes.AtomicBool
+	subOnce    sync.Once
+	peersMutex sync.Mutex
+	peers      map[string]*httpClusterPeer
+}
+
+type httpClusterPeer struct {
+	url   string
+	queue chan []byte
+}
+
+//NewHTTPClusterBus returns an HTTPClusterBus on DefaultClusterPath with a five second timeout.  Set Secret before SetClusterBus.
+func NewHTTPClusterBus() *HTTPClusterBus {
+	return &HTTPClusterBus{Path: DefaultClusterPath, Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+//Publish queues the message for every peer but Self.  It returns an error naming the peers whose queue is full.
+func (obj *HTTPClusterBus) Publish(message ClusterMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool)
+	for _, peer := range fileCache.GetGroupCachePeers() {
+		peer = strings.TrimSuffix(peer, "/")
+		if peer == "" || peer == strings.TrimSuffix(obj.Self, "/") {
+			continue
+		}
+		current[peer] = true
+	}
+
+	obj.peersMutex.Lock()
+	defer obj.peersMutex.Unlock()
+
+	if obj.closed.Get() {
+		return errors.New("Cluster bus is closed.")
+	}
+	if obj.peers == nil {
+		obj.peers = make(map[string]*httpClusterPeer)
+	}
+
+	//Stop the senders of peers removed from the group cache.
+	for url, peer := range obj.peers {
+		if !current[url] {
+			close(peer.queue)
+			delete(obj.peers, url)
+		}
+	}
+
+	var full []string
+	for url := range current {
+		peer, ok := obj.peers[url]
+		if !ok {
+			peer = &httpClusterPeer{url: url, queue: make(chan []byte, clusterOutboxSize)}
+			obj.peers[url] = peer
+			go obj.send(peer)
+		}
+
+		select {
+		case peer.queue <- data:
+		default:
+			full = append(full, url)
+		}
+	}
+
+	if len(full) > 0 {
+		sort.Strings(full)
+		return errors.New("Dropped cluster message for peers with a full queue:  " + strings.Join(full, ", "))
+	}
+	return nil
+}
+
+//send posts the queued messages of a peer in order until its queue is closed.
+func (obj *HTTPClusterBus) send(peer *httpClusterPeer) {
+	for data := range peer.queue {
+		err := obj.post(peer.url, data)
+		if err != nil && CustomLog != nil {
+			CustomLog("app->HTTPClusterBus.send", "Failed to post cluster message to "+peer.url+":  "+err.Error())
+		}
+	}
+}
+
+func (obj *HTTPClusterBus) post(peer string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, peer+obj.Path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(clusterSecretHeader, obj.Secret)
+
+	resp, err := obj.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.New("Cluster peer " + peer + " responded " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+//Subscribe mounts Path on the gin router.  Routes cannot be removed, so a closed bus answers 503 and a bus is subscribed only once.
+//The route is not mounted without a Secret since anyone reaching it could publish to every connection.
+func (obj *HTTPClusterBus) Subscribe(receive func(message ClusterMessage)) (err error) {
+	if obj.Secret == "" {
+		return errors.New("The HTTPClusterBus requires a Secret.")
+	}
+	if ginServer.Router == nil {
+		return errors.New("The gin server must be initialized before subscribing to the cluster bus.")
+	}
+
+	obj.subOnce.Do(func() {
+		defer func() {
+			if recover := recover(); recover != nil {
+				err = errors.New("Failed to mount cluster route " + obj.Path + ".")
+			}
+		}()
+
+		ginServer.Router.POST(obj.Path, func(c *gin.Context) {
+			if obj.closed.Get() {
+				c.Status(http.StatusServiceUnavailable)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(c.Request.Header.Get(clusterSecretHeader)), []byte(obj.Secret)) != 1 {
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+
+			var message ClusterMessage
+			err := json.NewDecoder(c.Request.Body).Decode(&message)
+			if err != nil {
+				c.Status(http.StatusBadRequest)
+				return
+			}
+			receive(message)
+			c.Status(http.StatusNoContent)
+		})
+	})
+	return
+}
+
+//Close stops publishing and receiving.  Messages still queued for a peer are posted before its sender exits.
+func (obj *HTTPClusterBus) Close() error {
+	obj.peersMutex.Lock()
+	defer obj.peersMutex.Unlock()
+
+	obj.closed.Set(true)
+	for _, peer := range obj.peers {
+		close(peer.queue)
+	}
+	obj.peers = nil
+	return nil
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/fileCache"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/gin-gonic/gin"
+)
+
+type testClusterNode struct {
+	bus      *HTTPClusterBus
+	server   *httptest.Server
+	received chan ClusterMessage
+}
+
+//newTestClusterNode subscribes a bus on its own router, as a separate process would.
+func newTestClusterNode(t *testing.T, secret string) (node *testClusterNode) {
+	router := gin.New()
+	node = &testClusterNode{server: httptest.NewServer(router), received: make(chan ClusterMessage, 10)}
+	t.Cleanup(node.server.Close)
+
+	node.bus = NewHTTPClusterBus()
+	node.bus.Self = node.server.URL
+	node.bus.Secret = secret
+
+	previous := ginServer.Router
+	ginServer.Router = router
+	err := node.bus.Subscribe(func(message ClusterMessage) {
+		node.received <- message
+	})
+	ginServer.Router = previous
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		node.bus.Close()
+	})
+	return
+}
+
+func postClusterMessage(t *testing.T, node *testClusterNode, secret string) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, node.server.URL+DefaultClusterPath, bytes.NewReader([]byte(`{"kind":"publish"}`)))
+	if secret != "" {
+		req.Header.Set(clusterSecretHeader, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func expectNoClusterMessage(t *testing.T, node *testClusterNode, description string) {
+	t.Helper()
+	select {
+	case message := <-node.received:
+		t.Errorf("expected %s, got %+v", description, message)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHTTPClusterBus(t *testing.T) {
+	first := newTestClusterNode(t, "secret")
+	second := newTestClusterNode(t, "secret")
+
+	peers := fileCache.GetGroupCachePeers()
+	defer fileCache.SetGroupCache(peers)
+	//The dead peer must not delay the messages of the others.
+	fileCache.SetGroupCache([]string{first.server.URL, second.server.URL, "http://127.0.0.1:1"})
+
+	err := first.bus.Publish(ClusterMessage{Node: "first", Kind: ClusterPublish, Key: "Status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case message := <-second.received:
+		if message.Node != "first" || message.Key != "Status" {
+			t.Errorf("unexpected message %+v", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the other node to receive the message")
+	}
+	expectNoClusterMessage(t, second, "the message to be delivered once")
+	expectNoClusterMessage(t, first, "the node not to post to itself")
+
+	if status := postClusterMessage(t, second, ""); status != http.StatusUnauthorized {
+		t.Errorf("expected a message without the secret to be refused, got %d", status)
+	}
+	if status := postClusterMessage(t, second, "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("expected a message with a wrong secret to be refused, got %d", status)
+	}
+	expectNoClusterMessage(t, second, "refused messages not to be received")
+
+	second.bus.Close()
+	if status := postClusterMessage(t, second, "secret"); status != http.StatusServiceUnavailable {
+		t.Errorf("expected a closed bus to refuse messages, got %d", status)
+	}
+	err = first.bus.Publish(ClusterMessage{Node: "first", Kind: ClusterPublish, Key: "Status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectNoClusterMessage(t, second, "a closed bus not to receive messages")
+
+	first.bus.Close()
+	if first.bus.Publish(ClusterMessage{Kind: ClusterPublish}) == nil {
+		t.Error("expected a closed bus to refuse to publish")
+	}
+}
+
+func TestHTTPClusterBusRequiresSecret(t *testing.T) {
+	bus := NewHTTPClusterBus()
+	if bus.Subscribe(func(message ClusterMessage) {}) == nil {
+		t.Error("expected Subscribe to fail without a Secret")
+	}
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+const mongoClusterTailTimeout = time.Second
+
+//MongoClusterBus inserts messages into a capped collection that every node tails.  dbServices must be connected to mongoDB.
+type MongoClusterBus struct {
+	Collection string
+	MaxBytes   int
+	done       chan struct{}
+	doneOnce   sync.Once
+	closeOnce  sync.Once
+}
+
+type mongoClusterDocument struct {
+	Id             bson.ObjectId `bson:"_id"`
+	ClusterMessage `bson:",inline"`
+}
+
+//NewMongoClusterBus returns a MongoClusterBus on a capped collection of maxBytes, created on Subscribe when it does not exist.
+func NewMongoClusterBus(collection string, maxBytes int) *MongoClusterBus {
+	return &MongoClusterBus{Collection: collection, MaxBytes: maxBytes}
+}
+
+//getDone returns the channel closed by Close, creating it so a struct literal bus works too.
+func (obj *MongoClusterBus) getDone() chan struct{} {
+	obj.doneOnce.Do(func() {
+		obj.done = make(chan struct{})
+	})
+	return obj.done
+}
+
+func (obj *MongoClusterBus) collection() (*mgo.Session, *mgo.Collection, error) {
+	db := dbServices.ReadMongoDB()
+	if db == nil {
+		return nil, nil, errors.New("The mongo cluster bus requires a mongoDB connection.")
+	}
+	session := db.Session.Copy()
+	return session, session.DB(db.Name).C(obj.Collection), nil
+}
+
+//Publish inserts the message into the capped collection.
+func (obj *MongoClusterBus) Publish(message ClusterMessage) error {
+	session, c, err := obj.collection()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	return c.Insert(mongoClusterDocument{Id: bson.NewObjectId(), ClusterMessage: message})
+}
+
+//Subscribe creates the capped collection and tails it from the newest message until the bus is closed.
+func (obj *MongoClusterBus) Subscribe(receive func(message ClusterMessage)) error {
+	session, c, err := obj.collection()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	err = c.Create(&mgo.CollectionInfo{Capped: true, MaxBytes: obj.MaxBytes})
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return err
+	}
+
+	var last mongoClusterDocument
+	err = c.Find(nil).Sort("-$natural").One(&last)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+
+	go obj.tail(last.Id, receive)
+	return nil
+}
+
+//tail receives the messages after lastId, reopening the tailable cursor when it dies.
+func (obj *MongoClusterBus) tail(lastId bson.ObjectId, receive func(message ClusterMessage)) {
+	done := obj.getDone()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		lastId = obj.tailCursor(lastId, receive, done)
+
+		select {
+		case <-done:
+			return
+		case <-time.After(mongoClusterTailTimeout):
+		}
+	}
+}
+
+//tailCursor resumes after the $natural position of lastId, since ObjectIds of different nodes are not ordered like the inserts.
+//Documents up to lastId are skipped.  When lastId was already overwritten every remaining document is newer, so all are received.
+func (obj *MongoClusterBus) tailCursor(lastId bson.ObjectId, receive func(message ClusterMessage), done chan struct{}) bson.ObjectId {
+	defer func() {
+		if recover := recover(); recover != nil {
+			if CustomLog != nil {
+				CustomLog("app->MongoClusterBus", "Panic Recovered at tailCursor()")
+			}
+		}
+	}()
+
+	session, c, err := obj.collection()
+	if err != nil {
+		return lastId
+	}
+	defer session.Close()
+
+	skipping := false
+	if lastId != "" {
+		count, err := c.FindId(lastId).Count()
+		if err != nil {
+			return lastId
+		}
+		skipping = count > 0
+		if !skipping && CustomLog != nil {
+			CustomLog("app->MongoClusterBus", "Cluster bus messages were overwritten before the cursor resumed.")
+		}
+	}
+
+	iter := c.Find(nil).Tail(mongoClusterTailTimeout)
+	defer iter.Close()
+	return receiveClusterTail(iter, lastId, skipping, receive, done)
+}
+
+//clusterTailIter is the part of the tailable *mgo.Iter receiveClusterTail reads.
+type clusterTailIter interface {
+	Next(result interface{}) bool
+	Err() error
+	Timeout() bool
+}
+
+//receiveClusterTail receives the documents of the cursor, skipping up to lastId while skipping, and returns the last id received.
+func receiveClusterTail(iter clusterTailIter, lastId bson.ObjectId, skipping bool, receive func(message ClusterMessage), done chan struct{}) bson.ObjectId {
+	var document mongoClusterDocument
+	for {
+		for iter.Next(&document) {
+			if skipping {
+				skipping = document.Id != lastId
+				document = mongoClusterDocument{}
+				continue
+			}
+			lastId = document.Id
+			receive(document.ClusterMessage)
+			document = mongoClusterDocument{}
+		}
+		if iter.Err() != nil || !iter.Timeout() {
+			if iter.Err() != nil && CustomLog != nil {
+				CustomLog("app->MongoClusterBus", "Cluster bus cursor failed:  "+iter.Err().Error())
+			}
+			return lastId
+		}
+
+		select {
+		case <-done:
+			return lastId
+		default:
+		}
+	}
+}
+
+//Close stops tailing the collection.
+func (obj *MongoClusterBus) Close() error {
+	done := obj.getDone()
+	obj.closeOnce.Do(func() {
+		close(done)
+	})
+	return nil
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+//testClusterTail returns the documents once and then ends like a cursor that died.
+type testClusterTail struct {
+	documents []mongoClusterDocument
+}
+
+func (iter *testClusterTail) Next(result interface{}) bool {
+	if len(iter.documents) == 0 {
+		return false
+	}
+	*result.(*mongoClusterDocument) = iter.documents[0]
+	iter.documents = iter.documents[1:]
+	return true
+}
+
+func (iter *testClusterTail) Err() error {
+	return nil
+}
+
+func (iter *testClusterTail) Timeout() bool {
+	return false
+}
+
+func TestReceiveClusterTail(t *testing.T) {
+	ids := []bson.ObjectId{bson.NewObjectId(), bson.NewObjectId(), bson.NewObjectId(), bson.NewObjectId()}
+	documents := func(ids ...bson.ObjectId) (documents []mongoClusterDocument) {
+		for _, id := range ids {
+			documents = append(documents, mongoClusterDocument{Id: id, ClusterMessage: ClusterMessage{Key: id.Hex()}})
+		}
+		return
+	}
+
+	tests := []struct {
+		description string
+		documents   []mongoClusterDocument
+		lastId      bson.ObjectId
+		skipping    bool
+		received    []string
+	}{
+		{"a new cursor receives every document", documents(ids...), "", false, []string{ids[0].Hex(), ids[1].Hex(), ids[2].Hex(), ids[3].Hex()}},
+		{"a resumed cursor skips up to lastId", documents(ids...), ids[1], true, []string{ids[2].Hex(), ids[3].Hex()}},
+		{"a cursor whose lastId was overwritten receives every document", documents(ids[2], ids[3]), ids[1], false, []string{ids[2].Hex(), ids[3].Hex()}},
+		{"nothing after lastId", documents(ids[0], ids[1]), ids[1], true, nil},
+	}
+
+	for _, test := range tests {
+		var received []string
+		lastId := receiveClusterTail(&testClusterTail{documents: test.documents}, test.lastId, test.skipping, func(message ClusterMessage) {
+			received = append(received, message.Key)
+		}, make(chan struct{}))
+
+		if !reflect.DeepEqual(received, test.received) {
+			t.Errorf("%s: expected %v, got %v", test.description, test.received, received)
+		}
+		expectedLastId := test.lastId
+		if len(test.received) > 0 {
+			expectedLastId = bson.ObjectIdHex(test.received[len(test.received)-1])
+		}
+		if lastId != expectedLastId {
+			t.Errorf("%s: expected the cursor to resume after %s, got %s", test.description, expectedLastId.Hex(), lastId.Hex())
+		}
+	}
+}
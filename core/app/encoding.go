@@ -0,0 +1,158 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/ugorji/go/codec"
+)
+
+//Encodings a client can negotiate for controller calls.  JSON is the default.
+const (
+	EncodingJSON        = "json"
+	EncodingMessagePack = "msgpack"
+	EncodingCBOR        = "cbor"
+)
+
+//Content types of the binary encodings.
+const (
+	ContentTypeMessagePack = "application/msgpack"
+	ContentTypeCBOR        = "application/cbor"
+)
+
+//cborTagDateTime is the standard CBOR tag for RFC 3339 date strings and cborTagIdentifier the registered tag for identifiers.
+const (
+	cborTagDateTime   = 0
+	cborTagIdentifier = 39
+)
+
+var msgpackHandle = newMessagePackHandle()
+var cborHandle = newCBORHandle()
+
+//textExt encodes time.Time and bson.ObjectId as the same strings their json encoding produces.
+type textExt struct{}
+
+func (x textExt) WriteExt(v interface{}) []byte {
+	value, _ := x.ConvertExt(v).(string)
+	return []byte(value)
+}
+
+func (x textExt) ReadExt(dst interface{}, src []byte) {
+	x.UpdateExt(dst, string(src))
+}
+
+func (x textExt) ConvertExt(v interface{}) interface{} {
+	switch value := v.(type) {
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case *time.Time:
+		return value.Format(time.RFC3339Nano)
+	case bson.ObjectId:
+		return value.Hex()
+	case *bson.ObjectId:
+		return value.Hex()
+	}
+	return nil
+}
+
+func (x textExt) UpdateExt(dst interface{}, src interface{}) {
+	value, _ := src.(string)
+	switch typed := dst.(type) {
+	case *time.Time:
+		if value == "" {
+			*typed = time.Time{}
+			return
+		}
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			panic(err)
+		}
+		*typed = t
+	case *bson.ObjectId:
+		if value == "" {
+			*typed = ""
+			return
+		}
+		if !bson.IsObjectIdHex(value) {
+			panic(errors.New("Invalid ObjectId " + value))
+		}
+		*typed = bson.ObjectIdHex(value)
+	}
+}
+
+func newMessagePackHandle() *codec.MsgpackHandle {
+	h := new(codec.MsgpackHandle)
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.RawToString = true
+	//Extensions are written as plain strings so any msgpack library can read them.
+	h.WriteExt = false
+	h.SetBytesExt(reflect.TypeOf(time.Time{}), 1, textExt{})
+	h.SetBytesExt(reflect.TypeOf(bson.ObjectId("")), 2, textExt{})
+	return h
+}
+
+func newCBORHandle() *codec.CborHandle {
+	h := new(codec.CborHandle)
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.SetInterfaceExt(reflect.TypeOf(time.Time{}), cborTagDateTime, textExt{})
+	h.SetInterfaceExt(reflect.TypeOf(bson.ObjectId("")), cborTagIdentifier, textExt{})
+	return h
+}
+
+//IsBinaryEncoding reports whether the encoding is sent in binary web socket frames.
+func IsBinaryEncoding(encoding string) bool {
+	return encoding == EncodingMessagePack || encoding == EncodingCBOR
+}
+
+//EncodingFromContentType returns the encoding of a Content-Type or Accept header, EncodingJSON when it names neither binary encoding.
+func EncodingFromContentType(contentType string) string {
+	for _, part := range strings.Split(contentType, ",") {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.Split(part, ";")[0]))
+		switch mediaType {
+		case ContentTypeMessagePack, "application/x-msgpack", "application/vnd.msgpack":
+			return EncodingMessagePack
+		case ContentTypeCBOR:
+			return EncodingCBOR
+		}
+	}
+	return EncodingJSON
+}
+
+//ContentTypeOfEncoding returns the Content-Type header for an encoding.
+func ContentTypeOfEncoding(encoding string) string {
+	switch encoding {
+	case EncodingMessagePack:
+		return ContentTypeMessagePack
+	case EncodingCBOR:
+		return ContentTypeCBOR
+	}
+	return "application/json; charset=utf-8"
+}
+
+//MarshalEncoding encodes v with the encoding.  Struct fields use their json tags for every encoding.
+func MarshalEncoding(encoding string, v interface{}) (data []byte, err error) {
+	switch encoding {
+	case EncodingMessagePack:
+		err = codec.NewEncoderBytes(&data, msgpackHandle).Encode(v)
+	case EncodingCBOR:
+		err = codec.NewEncoderBytes(&data, cborHandle).Encode(v)
+	default:
+		data, err = json.Marshal(v)
+	}
+	return
+}
+
+//UnmarshalEncoding decodes data with the encoding into v.
+func UnmarshalEncoding(encoding string, data []byte, v interface{}) error {
+	switch encoding {
+	case EncodingMessagePack:
+		return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+	case EncodingCBOR:
+		return codec.NewDecoderBytes(data, cborHandle).Decode(v)
+	}
+	return json.Unmarshal(data, v)
+}
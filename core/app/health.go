@@ -0,0 +1,129 @@
+package app
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core"
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/DanielRenne/GoCore/core/logger"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gin-gonic/gin"
+)
+
+//Routes of the health checks and diagnostics.
+const (
+	HealthPath = "/healthz"
+	ReadyPath  = "/readyz"
+	DebugPath  = "/debug/gocore"
+)
+
+//cronTickWindow is how long the cron engine may go without a tick while running.  It ticks every 100 milliseconds.
+const cronTickWindow = 2 * time.Second
+
+//DebugAuthorizer decides who may view /debug/gocore.  When nil a request must send webConfig.json application.debugToken
+//as a bearer token, and the page is disabled while no token is configured.
+var DebugAuthorizer func(c *gin.Context) bool
+
+//ReadinessReport is the response of /readyz.  The application is ready when the database is connected, every collection
+//has bootstrapped, the cron engine is ticking if it was started and Shutdown has not started.
+type ReadinessReport struct {
+	Ready             bool     `json:"ready"`
+	ShuttingDown      bool     `json:"shuttingDown"`
+	Database          bool     `json:"database"`
+	DatabaseError     string   `json:"databaseError,omitempty"`
+	Bootstrapped      bool     `json:"bootstrapped"`
+	PendingBootstraps []string `json:"pendingBootstraps,omitempty"`
+	CronRunning       bool     `json:"cronRunning"`
+	CronTicking       bool     `json:"cronTicking"`
+}
+
+//DebugReport is the response of /debug/gocore.
+type DebugReport struct {
+	Readiness            ReadinessReport `json:"readiness"`
+	WebSocketConnections int             `json:"webSocketConnections"`
+	WebSocketTransports  map[string]int  `json:"webSocketTransports"`
+	CollectionCache      int             `json:"collectionCache"`
+	RunningGophers       int             `json:"runningGophers"`
+	GoRoutines           int             `json:"goRoutines"`
+	PendingTransactions  int             `json:"pendingTransactions"`
+}
+
+//GetReadiness checks the database, the bootstrapping of the collections and the cron engine.
+func GetReadiness() (report ReadinessReport) {
+	report.ShuttingDown = IsShuttingDown()
+	if !report.ShuttingDown {
+		err := dbServices.Ping()
+		report.Database = err == nil
+		if err != nil {
+			report.DatabaseError = err.Error()
+		}
+	}
+
+	report.PendingBootstraps = dbServices.GetPendingBootstraps()
+	report.Bootstrapped = len(report.PendingBootstraps) == 0
+
+	report.CronRunning = core.CronJobs.IsRunning()
+	report.CronTicking = report.CronRunning && time.Since(core.CronJobs.LastTick()) < cronTickWindow
+
+	report.Ready = !report.ShuttingDown && report.Database && report.Bootstrapped && (!report.CronRunning || report.CronTicking)
+	return
+}
+
+//GetDebugReport returns the readiness with the counts of connections, cached entities, go routines and pending transactions.
+func GetDebugReport() (report DebugReport) {
+	report.Readiness = GetReadiness()
+	report.WebSocketTransports = make(map[string]int)
+	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
+		report.WebSocketConnections++
+		report.WebSocketTransports[value.(*WebSocketConnection).Transport]++
+		return true
+	})
+	report.CollectionCache = dbServices.CollectionCache{}.Count()
+	report.RunningGophers = logger.RunningGopherCount()
+	report.GoRoutines = runtime.NumGoroutine()
+	report.PendingTransactions = dbServices.PendingTransactions()
+	return
+}
+
+//mountHealthRoutes adds /healthz, /readyz and /debug/gocore.
+func mountHealthRoutes() {
+	ginServer.Router.GET(HealthPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	ginServer.Router.GET(ReadyPath, func(c *gin.Context) {
+		report := GetReadiness()
+		if !report.Ready {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+
+	ginServer.Router.GET(DebugPath, func(c *gin.Context) {
+		if !authorizeDebug(c) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.JSON(http.StatusOK, GetDebugReport())
+	})
+}
+
+func authorizeDebug(c *gin.Context) bool {
+	if DebugAuthorizer != nil {
+		return DebugAuthorizer(c)
+	}
+
+	token := serverSettings.GetWebConfig().Application.DebugToken
+	if token == "" {
+		return false
+	}
+
+	bearer := strings.TrimPrefix(c.Request.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestHealthRouter(t *testing.T) *gin.Engine {
+	previous := ginServer.Router
+	ginServer.Router = gin.New()
+	router := ginServer.Router
+	mountHealthRoutes()
+	ginServer.Router = previous
+	return router
+}
+
+func getHealthRoute(router *gin.Engine, path string, authorization string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestHealthRoutes(t *testing.T) {
+	router := newTestHealthRouter(t)
+
+	boltDB := dbServices.BoltDB
+	dbServices.BoltDB = nil
+	defer func() {
+		dbServices.BoltDB = boltDB
+	}()
+
+	setWebConfig(t, func() {
+		serverSettings.WebConfig.DbConnection.Driver = ""
+	})
+	if recorder := getHealthRoute(router, HealthPath, ""); recorder.Code != http.StatusOK {
+		t.Errorf("expected %s to be ok, got %d", HealthPath, recorder.Code)
+	}
+
+	var report ReadinessReport
+	recorder := getHealthRoute(router, ReadyPath, "")
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &report) != nil || !report.Ready {
+		t.Errorf("expected the application to be ready without a database, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	serverSettings.WebConfigMutex.Lock()
+	serverSettings.WebConfig.DbConnection.Driver = dbServices.DATABASE_DRIVER_BOLTDB
+	serverSettings.WebConfigMutex.Unlock()
+
+	report = ReadinessReport{}
+	recorder = getHealthRoute(router, ReadyPath, "")
+	if recorder.Code != http.StatusServiceUnavailable || json.Unmarshal(recorder.Body.Bytes(), &report) != nil || report.Ready || report.Database || report.DatabaseError == "" {
+		t.Errorf("expected the application not to be ready without its database, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder = getHealthRoute(router, HealthPath, ""); recorder.Code != http.StatusOK {
+		t.Errorf("expected %s to stay ok while not ready, got %d", HealthPath, recorder.Code)
+	}
+}
+
+func TestDebugRoute(t *testing.T) {
+	router := newTestHealthRouter(t)
+
+	tests := []struct {
+		token         string
+		authorization string
+		authorizer    func(c *gin.Context) bool
+		status        int
+	}{
+		{"", "", nil, http.StatusNotFound},
+		{"", "Bearer ", nil, http.StatusNotFound},
+		{"secret", "", nil, http.StatusNotFound},
+		{"secret", "Bearer wrong", nil, http.StatusNotFound},
+		{"secret", "Bearer secret", nil, http.StatusOK},
+		{"secret", "Bearer secret", func(c *gin.Context) bool { return false }, http.StatusNotFound},
+		{"", "", func(c *gin.Context) bool { return true }, http.StatusOK},
+	}
+
+	defer func() {
+		DebugAuthorizer = nil
+	}()
+	for _, test := range tests {
+		setWebConfig(t, func() {
+			serverSettings.WebConfig.Application.DebugToken = test.token
+		})
+		DebugAuthorizer = test.authorizer
+
+		recorder := getHealthRoute(router, DebugPath, test.authorization)
+		if recorder.Code != test.status {
+			t.Errorf("token %q with %q: expected %d, got %d", test.token, test.authorization, test.status, recorder.Code)
+			continue
+		}
+		var report DebugReport
+		if test.status == http.StatusOK && (json.Unmarshal(recorder.Body.Bytes(), &report) != nil || report.WebSocketTransports == nil) {
+			t.Errorf("expected the debug report, got %s", recorder.Body.String())
+		}
+	}
+}
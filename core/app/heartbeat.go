@@ -0,0 +1,106 @@
+package app
+
+import (
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/atomicTypes"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultWebSocketPingInterval = 25 * time.Second
+	defaultWebSocketPongTimeout  = 60 * time.Second
+	webSocketPingWriteWait       = 10 * time.Second
+)
+
+var webSocketTimeoutDefault atomicTypes.AtomicInt
+
+/*SetWebSocketTimeout sets the default milliseconds a connection may go without a pong or message before it is closed.
+Heartbeats run per connection so it no longer needs to run in a go routine and returns immediately.
+webConfig.json application.webSocket.pongTimeout takes precedence when set.
+Deprecated: configure application.webSocket in webConfig.json instead.
+*/
+func SetWebSocketTimeout(timeout int) {
+	webSocketTimeoutDefault.Set(timeout)
+}
+
+func webSocketPingInterval() time.Duration {
+	interval := serverSettings.GetWebConfig().Application.WebSocket.PingInterval
+	if interval <= 0 {
+		return defaultWebSocketPingInterval
+	}
+	return time.Duration(interval) * time.Millisecond
+}
+
+//webSocketPongTimeout returns the TimeoutOverride of the connection, the configured pongTimeout or the SetWebSocketTimeout default.
+func webSocketPongTimeout(id string) time.Duration {
+	meta, ok := GetWebSocketMeta(id)
+	if ok {
+		timeoutOverride := meta.TimeoutOverride.Get()
+		if timeoutOverride != 0 {
+			return time.Duration(timeoutOverride) * time.Millisecond
+		}
+	}
+
+	timeout := serverSettings.GetWebConfig().Application.WebSocket.PongTimeout
+	if timeout <= 0 {
+		timeout = webSocketTimeoutDefault.Get()
+	}
+	if timeout <= 0 {
+		return defaultWebSocketPongTimeout
+	}
+	return time.Duration(timeout) * time.Millisecond
+}
+
+//extendWebSocketDeadline marks the connection alive and pushes back the read deadline.  The reader fails with a timeout
+//and deletes the connection once the deadline passes.
+func extendWebSocketDeadline(conn *WebSocketConnection) {
+	now := time.Now()
+	meta, ok := GetWebSocketMeta(conn.Id)
+	if ok {
+		meta.LastResponseTime.Set(now)
+	}
+	conn.Connection.SetReadDeadline(now.Add(webSocketPongTimeout(conn.Id)))
+}
+
+//startWebSocketHeartbeat sends ping control frames until the connection is deleted.  Browsers answer pings automatically.
+func startWebSocketHeartbeat(conn *WebSocketConnection) {
+
+	extendWebSocketDeadline(conn)
+	conn.Connection.SetPongHandler(func(string) error {
+		extendWebSocketDeadline(conn)
+		return nil
+	})
+
+	go func() {
+		defer func() {
+			if recover := recover(); recover != nil {
+				if CustomLog != nil {
+					CustomLog("app->startWebSocketHeartbeat", "Panic Recovered at startWebSocketHeartbeat()")
+				}
+			}
+		}()
+
+		done := conn.ConnectionContext().Done()
+		for {
+			timer := time.NewTimer(webSocketPingInterval())
+			select {
+			case <-done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			//WriteControl may be called concurrently with the other write methods.
+			err := conn.Connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketPingWriteWait))
+			if err != nil && err != websocket.ErrCloseSent {
+				if CustomLog != nil {
+					CustomLog("app->startWebSocketHeartbeat", "Deleting Web Socket after failed ping:  "+err.Error()+":  "+conn.remoteAddr())
+				}
+				deleteWebSocket(conn)
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+func TestWebSocketHeartbeat(t *testing.T) {
+	setWebConfig(t, func() {
+		serverSettings.WebConfig.Application.WebSocket.PingInterval = 20
+		serverSettings.WebConfig.Application.WebSocket.PongTimeout = 150
+	})
+	server := newTestWebSocketServer(t)
+
+	//The client answers pings while it reads.
+	alive, aliveConn := dialTestWebSocket(t, server, "")
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	silent, silentConn := dialTestWebSocket(t, server, "")
+	silent.SetPingHandler(func(string) error {
+		return nil
+	})
+	silentClosed := make(chan struct{})
+	go func() {
+		for {
+			if _, _, err := silent.ReadMessage(); err != nil {
+				close(silentClosed)
+				return
+			}
+		}
+	}()
+
+	eventually(t, func() bool {
+		return !isWebSocketStored(silentConn)
+	}, "expected the connection without pongs to be deleted after the pong timeout")
+	select {
+	case <-silentClosed:
+	case <-time.After(2 * time.Second):
+		t.Error("expected the connection without pongs to be closed")
+	}
+	if _, ok := GetWebSocketMeta(silentConn.Id); ok {
+		t.Error("expected the meta of the deleted connection to be removed")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if !isWebSocketStored(aliveConn) {
+		t.Error("expected the connection answering pings to be kept past the pong timeout")
+	}
+}
+
+func TestWebSocketPongTimeout(t *testing.T) {
+	conn := newTestConnection(t)
+	meta, _ := GetWebSocketMeta(conn.Id)
+
+	setWebConfig(t, func() {
+		serverSettings.WebConfig.Application.WebSocket.PongTimeout = 0
+	})
+	SetWebSocketTimeout(0)
+	if timeout := webSocketPongTimeout(conn.Id); timeout != defaultWebSocketPongTimeout {
+		t.Errorf("expected the default pong timeout, got %v", timeout)
+	}
+
+	SetWebSocketTimeout(5000)
+	defer SetWebSocketTimeout(0)
+	if timeout := webSocketPongTimeout(conn.Id); timeout != 5*time.Second {
+		t.Errorf("expected the SetWebSocketTimeout default, got %v", timeout)
+	}
+
+	serverSettings.WebConfigMutex.Lock()
+	serverSettings.WebConfig.Application.WebSocket.PongTimeout = 3000
+	serverSettings.WebConfigMutex.Unlock()
+	if timeout := webSocketPongTimeout(conn.Id); timeout != 3*time.Second {
+		t.Errorf("expected the configured pong timeout to take precedence, got %v", timeout)
+	}
+
+	meta.SetTimeoutOverride(1000)
+	if timeout := webSocketPongTimeout(conn.Id); timeout != time.Second {
+		t.Errorf("expected the TimeoutOverride of the connection to take precedence, got %v", timeout)
+	}
+}
@@ -0,0 +1,306 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+//WebSocketRoomFilter is called when a client asks to join a room with a joinRoom control message.
+//Returning false denies the join.  When nil every client join is denied and rooms can only be joined from the server with JoinWebSocketRoom.
+var WebSocketRoomFilter func(conn *WebSocketConnection, room string) bool
+
+//webSocketRoomControl is the control message a client sends to join or leave a room, ie {"joinRoom": "chat"}.
+type webSocketRoomControl struct {
+	JoinRoom  string `json:"joinRoom"`
+	LeaveRoom string `json:"leaveRoom"`
+}
+
+//WebSocketRoomReply is sent to the client after a joinRoom or leaveRoom control message.
+type WebSocketRoomReply struct {
+	JoinedRoom string `json:"joinedRoom,omitempty"`
+	LeftRoom   string `json:"leftRoom,omitempty"`
+	RoomError  string `json:"roomError,omitempty"`
+}
+
+//webSocketGroup is the set of connections in a room or signed in as a user.  A removed group is empty and no longer registered.
+type webSocketGroup struct {
+	sync.RWMutex
+	connections map[string]*WebSocketConnection
+	removed     bool
+}
+
+type webSocketMembership struct {
+	sync.RWMutex
+	rooms  map[string]bool
+	userId string
+}
+
+var webSocketRooms sync.Map
+var webSocketUsers sync.Map
+var webSocketMemberships sync.Map
+
+func addToWebSocketGroup(registry *sync.Map, key string, conn *WebSocketConnection) {
+	for {
+		obj, _ := registry.LoadOrStore(key, &webSocketGroup{connections: make(map[string]*WebSocketConnection)})
+		group := obj.(*webSocketGroup)
+		group.Lock()
+		if group.removed {
+			group.Unlock()
+			continue
+		}
+		group.connections[conn.Id] = conn
+		group.Unlock()
+		return
+	}
+}
+
+func removeFromWebSocketGroup(registry *sync.Map, key string, id string) {
+	obj, ok := registry.Load(key)
+	if !ok {
+		return
+	}
+	group := obj.(*webSocketGroup)
+	group.Lock()
+	defer group.Unlock()
+	delete(group.connections, id)
+	if len(group.connections) == 0 && !group.removed {
+		group.removed = true
+		registry.Delete(key)
+	}
+}
+
+func getWebSocketGroup(registry *sync.Map, key string) (connections []*WebSocketConnection) {
+	obj, ok := registry.Load(key)
+	if !ok {
+		return
+	}
+	group := obj.(*webSocketGroup)
+	group.RLock()
+	defer group.RUnlock()
+	for _, conn := range group.connections {
+		connections = append(connections, conn)
+	}
+	return
+}
+
+func getWebSocketMembership(id string) *webSocketMembership {
+	obj, _ := webSocketMemberships.LoadOrStore(id, &webSocketMembership{rooms: make(map[string]bool)})
+	return obj.(*webSocketMembership)
+}
+
+//JoinWebSocketRoom adds the connection to a room.  Rooms are created on the first join and removed when the last connection leaves.
+func JoinWebSocketRoom(conn *WebSocketConnection, room string) {
+	if room == "" {
+		return
+	}
+	conn = currentWebSocketConnection(conn)
+	membership := getWebSocketMembership(conn.Id)
+	membership.Lock()
+	membership.rooms[room] = true
+	membership.Unlock()
+	addToWebSocketGroup(&webSocketRooms, room, conn)
+
+	//The connection was deleted while joining.
+	if isWebSocketGone(conn) {
+		removeWebSocketMemberships(conn)
+	}
+}
+
+//LeaveWebSocketRoom removes the connection from a room.
+func LeaveWebSocketRoom(conn *WebSocketConnection, room string) {
+	obj, ok := webSocketMemberships.Load(conn.Id)
+	if ok {
+		membership := obj.(*webSocketMembership)
+		membership.Lock()
+		delete(membership.rooms, room)
+		membership.Unlock()
+	}
+	removeFromWebSocketGroup(&webSocketRooms, room, conn.Id)
+}
+
+//SetWebSocketUser associates the connection with a user id so PublishWebSocketUserJSON reaches every connection of the user.
+//An empty userId removes the association.
+func SetWebSocketUser(conn *WebSocketConnection, userId string) {
+	conn = currentWebSocketConnection(conn)
+	membership := getWebSocketMembership(conn.Id)
+	membership.Lock()
+	previous := membership.userId
+	membership.userId = userId
+	membership.Unlock()
+
+	if previous != "" && previous != userId {
+		removeFromWebSocketGroup(&webSocketUsers, previous, conn.Id)
+	}
+	if userId != "" {
+		addToWebSocketGroup(&webSocketUsers, userId, conn)
+	}
+
+	if isWebSocketGone(conn) {
+		removeWebSocketMemberships(conn)
+	}
+}
+
+//GetWebSocketRoomConnections returns the connections in a room.
+func GetWebSocketRoomConnections(room string) []*WebSocketConnection {
+	return getWebSocketGroup(&webSocketRooms, room)
+}
+
+//GetWebSocketUserConnections returns the connections associated with a user id.
+func GetWebSocketUserConnections(userId string) []*WebSocketConnection {
+	return getWebSocketGroup(&webSocketUsers, userId)
+}
+
+//GetWebSocketRooms returns the names of every room with at least one connection.
+func GetWebSocketRooms() (rooms []string) {
+	webSocketRooms.Range(func(key interface{}, value interface{}) bool {
+		rooms = append(rooms, key.(string))
+		return true
+	})
+	sort.Strings(rooms)
+	return
+}
+
+/*PublishWebSocketRoomJSON publishes a WebSocketPubSubPayload to every connection in a room on every node of the cluster.
+Implementation example-----------
+app.JoinWebSocketRoom(conn, "device:"+deviceId)
+app.PublishWebSocketRoomJSON("device:"+deviceId, "DeviceStatus", status)
+---------------------------------
+*/
+func PublishWebSocketRoomJSON(room string, key string, v interface{}) {
+	publishWebSocketRoomJSONLocal(room, key, v)
+	publishToCluster(ClusterRoomPublish, room, key, v)
+}
+
+func publishWebSocketRoomJSONLocal(room string, key string, v interface{}) {
+	for _, conn := range GetWebSocketRoomConnections(room) {
+		ReplyToWebSocketPubSub(conn, key, v)
+	}
+}
+
+//BroadcastWebSocketRoomJSON writes v to every connection in a room on every node of the cluster.
+func BroadcastWebSocketRoomJSON(room string, v interface{}) {
+	broadcastWebSocketRoomJSONLocal(room, v)
+	publishToCluster(ClusterRoomBroadcast, room, "", v)
+}
+
+func broadcastWebSocketRoomJSONLocal(room string, v interface{}) {
+	for _, conn := range GetWebSocketRoomConnections(room) {
+		ReplyToWebSocketJSON(conn, v)
+	}
+}
+
+//PublishWebSocketUserJSON publishes a WebSocketPubSubPayload to every connection associated with the user id on every node of the cluster.
+func PublishWebSocketUserJSON(userId string, key string, v interface{}) {
+	publishWebSocketUserJSONLocal(userId, key, v)
+	publishToCluster(ClusterUserPublish, userId, key, v)
+}
+
+func publishWebSocketUserJSONLocal(userId string, key string, v interface{}) {
+	for _, conn := range GetWebSocketUserConnections(userId) {
+		ReplyToWebSocketPubSub(conn, key, v)
+	}
+}
+
+//GetRooms returns the rooms the connection has joined.
+func (obj *WebSocketConnectionMeta) GetRooms() (rooms []string) {
+	if obj.Conn == nil {
+		return
+	}
+	value, ok := webSocketMemberships.Load(obj.Conn.Id)
+	if !ok {
+		return
+	}
+	membership := value.(*webSocketMembership)
+	membership.RLock()
+	for room := range membership.rooms {
+		rooms = append(rooms, room)
+	}
+	membership.RUnlock()
+	sort.Strings(rooms)
+	return
+}
+
+//InRoom returns true when the connection has joined the room.
+func (obj *WebSocketConnectionMeta) InRoom(room string) bool {
+	if obj.Conn == nil {
+		return false
+	}
+	value, ok := webSocketMemberships.Load(obj.Conn.Id)
+	if !ok {
+		return false
+	}
+	membership := value.(*webSocketMembership)
+	membership.RLock()
+	defer membership.RUnlock()
+	return membership.rooms[room]
+}
+
+//GetUserId returns the user id set with SetWebSocketUser.
+func (obj *WebSocketConnectionMeta) GetUserId() string {
+	if obj.Conn == nil {
+		return ""
+	}
+	value, ok := webSocketMemberships.Load(obj.Conn.Id)
+	if !ok {
+		return ""
+	}
+	membership := value.(*webSocketMembership)
+	membership.RLock()
+	defer membership.RUnlock()
+	return membership.userId
+}
+
+//removeWebSocketMemberships removes a deleted connection from every room and its user.
+func removeWebSocketMemberships(conn *WebSocketConnection) {
+	value, ok := webSocketMemberships.Load(conn.Id)
+	if !ok {
+		return
+	}
+	webSocketMemberships.Delete(conn.Id)
+
+	membership := value.(*webSocketMembership)
+	membership.Lock()
+	rooms := membership.rooms
+	userId := membership.userId
+	membership.rooms = make(map[string]bool)
+	membership.userId = ""
+	membership.Unlock()
+
+	for room := range rooms {
+		removeFromWebSocketGroup(&webSocketRooms, room, conn.Id)
+	}
+	if userId != "" {
+		removeFromWebSocketGroup(&webSocketUsers, userId, conn.Id)
+	}
+}
+
+//handleWebSocketRoomControl joins or leaves rooms for joinRoom and leaveRoom control messages.  It returns false for any other message.
+func handleWebSocketRoomControl(conn *WebSocketConnection, messageType int, data []byte) bool {
+	if messageType != websocket.TextMessage || (!bytes.Contains(data, []byte(`"joinRoom"`)) && !bytes.Contains(data, []byte(`"leaveRoom"`))) {
+		return false
+	}
+
+	var control webSocketRoomControl
+	if json.Unmarshal(data, &control) != nil || (control.JoinRoom == "" && control.LeaveRoom == "") {
+		return false
+	}
+
+	if control.LeaveRoom != "" {
+		LeaveWebSocketRoom(conn, control.LeaveRoom)
+		ReplyToWebSocketJSON(conn, WebSocketRoomReply{LeftRoom: control.LeaveRoom})
+	}
+
+	if control.JoinRoom != "" {
+		if WebSocketRoomFilter == nil || !WebSocketRoomFilter(conn, control.JoinRoom) {
+			ReplyToWebSocketJSON(conn, WebSocketRoomReply{RoomError: "Not allowed to join room " + control.JoinRoom + "."})
+			return true
+		}
+		JoinWebSocketRoom(conn, control.JoinRoom)
+		ReplyToWebSocketJSON(conn, WebSocketRoomReply{JoinedRoom: control.JoinRoom})
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func roomControl(t *testing.T, conn *WebSocketConnection, control string) (reply WebSocketRoomReply) {
+	t.Helper()
+	if !handleWebSocketRoomControl(conn, websocket.TextMessage, []byte(control)) {
+		t.Fatalf("expected %s to be handled as a room control message", control)
+	}
+	messages := takeMessages(conn)
+	if len(messages) != 1 {
+		t.Fatalf("expected one reply to %s, got %v", control, messages)
+	}
+	err := json.Unmarshal([]byte(messages[0]), &reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestWebSocketRoomFilter(t *testing.T) {
+	conn := newTestConnection(t)
+	meta, _ := GetWebSocketMeta(conn.Id)
+
+	WebSocketRoomFilter = nil
+	reply := roomControl(t, conn, `{"joinRoom": "public:lobby"}`)
+	if reply.RoomError == "" || meta.InRoom("public:lobby") {
+		t.Errorf("expected client joins to be denied without a filter, got %+v", reply)
+	}
+
+	WebSocketRoomFilter = func(conn *WebSocketConnection, room string) bool {
+		return strings.HasPrefix(room, "public:")
+	}
+	defer func() {
+		WebSocketRoomFilter = nil
+	}()
+
+	reply = roomControl(t, conn, `{"joinRoom": "public:lobby"}`)
+	if reply.JoinedRoom != "public:lobby" || !meta.InRoom("public:lobby") {
+		t.Errorf("expected the filter to allow the join, got %+v", reply)
+	}
+
+	reply = roomControl(t, conn, `{"joinRoom": "admin"}`)
+	if reply.RoomError == "" || meta.InRoom("admin") {
+		t.Errorf("expected the filter to deny the join, got %+v", reply)
+	}
+
+	JoinWebSocketRoom(conn, "admin")
+	if !reflect.DeepEqual(meta.GetRooms(), []string{"admin", "public:lobby"}) {
+		t.Errorf("expected the server to join rooms regardless of the filter, got %v", meta.GetRooms())
+	}
+
+	reply = roomControl(t, conn, `{"leaveRoom": "public:lobby"}`)
+	if reply.LeftRoom != "public:lobby" || meta.InRoom("public:lobby") || len(GetWebSocketRoomConnections("public:lobby")) != 0 {
+		t.Errorf("expected the connection to leave the room, got %+v", reply)
+	}
+
+	if handleWebSocketRoomControl(conn, websocket.TextMessage, []byte(`{"controller": "Users"}`)) {
+		t.Error("expected other messages not to be handled")
+	}
+}
+
+func TestWebSocketRoomPublish(t *testing.T) {
+	member := newTestConnection(t)
+	other := newTestConnection(t)
+	JoinWebSocketRoom(member, "device:1")
+	JoinWebSocketRoom(other, "device:2")
+
+	publishWebSocketRoomJSONLocal("device:1", "Status", "on")
+
+	var payload WebSocketPubSubPayload
+	messages := takeMessages(member)
+	if len(messages) != 1 || json.Unmarshal([]byte(messages[0]), &payload) != nil || payload.Key != "Status" || payload.Content != "on" {
+		t.Errorf("expected the member to receive the publish, got %v", messages)
+	}
+	if messages = takeMessages(other); len(messages) != 0 {
+		t.Errorf("expected the connection in another room not to receive the publish, got %v", messages)
+	}
+
+	removeWebSocketMemberships(member)
+	if len(GetWebSocketRoomConnections("device:1")) != 0 {
+		t.Error("expected the room to be removed with its last connection")
+	}
+	for _, room := range GetWebSocketRooms() {
+		if room == "device:1" {
+			t.Error("expected the empty room not to be listed")
+		}
+	}
+}
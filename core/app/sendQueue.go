@@ -0,0 +1,236 @@
+package app
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+//Policies applied when the send queue of a connection is full.
+const (
+	SendQueueDropOldest = "dropOldest"
+	SendQueueDropNewest = "dropNewest"
+	SendQueueDisconnect = "disconnect"
+)
+
+const (
+	defaultSendQueueSize  = 256
+	webSocketWriteTimeout = 10 * time.Second
+)
+
+//WebSocketSendQueueStat reports the outbound queue of a connection.
+type WebSocketSendQueueStat struct {
+	Id       string `json:"id"`
+	Depth    int    `json:"depth"`
+	MaxDepth int    `json:"maxDepth"`
+	Sent     int64  `json:"sent"`
+	Dropped  int64  `json:"dropped"`
+}
+
+type webSocketMessage struct {
+	messageType int
+	data        []byte
+}
+
+//webSocketSendQueue holds the messages of a connection in order for its single writer go routine.
+type webSocketSendQueue struct {
+	sync.Mutex
+	messages []webSocketMessage
+	signal   chan struct{}
+	maxDepth int
+	closed   bool
+	sent     int64
+	dropped  int64
+}
+
+func getSendQueueSettings() (size int, policy string) {
+	webSocket := serverSettings.GetWebConfig().Application.WebSocket
+	size = webSocket.SendQueueSize
+	policy = webSocket.SendQueuePolicy
+	if size <= 0 {
+		size = defaultSendQueueSize
+	}
+	if policy == "" {
+		policy = SendQueueDropOldest
+	}
+	return
+}
+
+//sendQueue returns the queue of the connection, starting its writer on first use.  Long poll requests take from the queue themselves.
+func (obj *WebSocketConnection) sendQueue() *webSocketSendQueue {
+	obj.sendQueueOnce.Do(func() {
+		obj.queue = &webSocketSendQueue{signal: make(chan struct{}, 1)}
+		if obj.Transport != TransportLongPoll {
+			go obj.writeSendQueue(obj.queue)
+		}
+	})
+	return obj.queue
+}
+
+//enqueue adds a message to the send queue and applies the configured policy when the queue is full.
+func (obj *WebSocketConnection) enqueue(messageType int, data []byte) {
+	queue := obj.sendQueue()
+	size, policy := getSendQueueSettings()
+
+	queue.Lock()
+	if queue.closed {
+		queue.Unlock()
+		return
+	}
+
+	if len(queue.messages) >= size {
+		queue.dropped++
+		switch policy {
+		case SendQueueDropNewest:
+			queue.Unlock()
+			return
+		case SendQueueDisconnect:
+			queue.closed = true
+			queue.messages = nil
+			queue.Unlock()
+			if CustomLog != nil {
+				CustomLog("app->enqueue", "Disconnecting Web Socket with a full send queue:  "+obj.remoteAddr())
+			}
+			obj.closeTransport()
+			deleteWebSocket(obj)
+			return
+		default:
+			queue.messages[0] = webSocketMessage{}
+			queue.messages = queue.messages[1:]
+		}
+	}
+
+	queue.messages = append(queue.messages, webSocketMessage{messageType: messageType, data: data})
+	if len(queue.messages) > queue.maxDepth {
+		queue.maxDepth = len(queue.messages)
+	}
+	queue.Unlock()
+
+	select {
+	case queue.signal <- struct{}{}:
+	default:
+	}
+}
+
+//writeSendQueue is the only go routine writing queued messages to the connection, so they are delivered in order.
+func (obj *WebSocketConnection) writeSendQueue(queue *webSocketSendQueue) {
+
+	defer func() {
+		if recover := recover(); recover != nil {
+			if CustomLog != nil {
+				CustomLog("app->writeSendQueue", "Panic Recovered at writeSendQueue()")
+			}
+		}
+		queue.Lock()
+		queue.closed = true
+		queue.messages = nil
+		queue.Unlock()
+	}()
+
+	done := obj.ConnectionContext().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-queue.signal:
+		}
+
+		for {
+			queue.Lock()
+			if len(queue.messages) == 0 || queue.closed {
+				queue.Unlock()
+				break
+			}
+			message := queue.messages[0]
+			queue.messages[0] = webSocketMessage{}
+			queue.messages = queue.messages[1:]
+			queue.Unlock()
+
+			err := obj.writeMessage(message.messageType, message.data)
+
+			if err != nil {
+				if CustomLog != nil {
+					CustomLog("app->writeSendQueue", "Deleting Web Socket after failed write:  "+err.Error()+":  "+obj.remoteAddr())
+				}
+				deleteWebSocket(obj)
+				return
+			}
+			atomic.AddInt64(&queue.sent, 1)
+		}
+	}
+}
+
+//writeMessage writes a message to the web socket or the event stream of the connection.
+func (obj *WebSocketConnection) writeMessage(messageType int, data []byte) error {
+	if obj.virtual != nil {
+		return obj.virtual.write(obj, messageType, data)
+	}
+	obj.WriteLock.Lock()
+	defer obj.WriteLock.Unlock()
+	obj.Connection.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	return obj.Connection.WriteMessage(messageType, data)
+}
+
+//take removes up to max messages from the front of the queue.
+func (queue *webSocketSendQueue) take(max int) (messages []webSocketMessage) {
+	queue.Lock()
+	defer queue.Unlock()
+	if max > len(queue.messages) {
+		max = len(queue.messages)
+	}
+	messages = append(messages, queue.messages[:max]...)
+	for i := 0; i < max; i++ {
+		queue.messages[i] = webSocketMessage{}
+	}
+	queue.messages = queue.messages[max:]
+	atomic.AddInt64(&queue.sent, int64(max))
+	return
+}
+
+//SendQueueStats returns the depth, high water mark, sent and dropped counts of the outbound queue.
+func (obj *WebSocketConnection) SendQueueStats() (stat WebSocketSendQueueStat) {
+	stat.Id = obj.Id
+	queue := obj.sendQueue()
+	queue.Lock()
+	stat.Depth = len(queue.messages)
+	stat.MaxDepth = queue.maxDepth
+	stat.Dropped = queue.dropped
+	queue.Unlock()
+	stat.Sent = atomic.LoadInt64(&queue.sent)
+	return
+}
+
+/*GetWebSocketSendQueueStats returns the outbound queue of every connection, deepest first.
+Implementation example-----------
+ginServer.Router.GET("/sendQueues", func(c *gin.Context) {
+	c.JSON(http.StatusOK, app.GetWebSocketSendQueueStats())
+})
+---------------------------------
+*/
+func GetWebSocketSendQueueStats() (stats []WebSocketSendQueueStat) {
+	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
+		stats = append(stats, value.(*WebSocketConnection).SendQueueStats())
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Depth != stats[j].Depth {
+			return stats[i].Depth > stats[j].Depth
+		}
+		if stats[i].Dropped != stats[j].Dropped {
+			return stats[i].Dropped > stats[j].Dropped
+		}
+		return stats[i].Id < stats[j].Id
+	})
+	return
+}
+
+//enqueueWebSocketAll adds a message to the send queue of every connection.
+func enqueueWebSocketAll(messageType int, data []byte) {
+	WebSocketConnections.Range(func(key interface{}, value interface{}) bool {
+		value.(*WebSocketConnection).enqueue(messageType, data)
+		return true
+	})
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+func TestSendQueuePolicies(t *testing.T) {
+	tests := []struct {
+		policy   string
+		messages []string
+		closed   bool
+	}{
+		{SendQueueDropOldest, []string{"2", "3"}, false},
+		{"", []string{"2", "3"}, false},
+		{SendQueueDropNewest, []string{"1", "2"}, false},
+		{SendQueueDisconnect, nil, true},
+	}
+
+	for _, test := range tests {
+		setWebConfig(t, func() {
+			serverSettings.WebConfig.Application.WebSocket.SendQueueSize = 2
+			serverSettings.WebConfig.Application.WebSocket.SendQueuePolicy = test.policy
+		})
+
+		conn := newTestConnection(t)
+		for _, message := range []string{"1", "2", "3"} {
+			ReplyToWebSocket(conn, []byte(message))
+		}
+
+		stats := conn.SendQueueStats()
+		if stats.Dropped != 1 || stats.MaxDepth != 2 {
+			t.Errorf("%s: expected one dropped message and a max depth of 2, got %+v", test.policy, stats)
+		}
+		if messages := takeMessages(conn); !reflect.DeepEqual(messages, test.messages) {
+			t.Errorf("%s: expected %v to be queued, got %v", test.policy, test.messages, messages)
+		}
+
+		if !test.closed {
+			if !isWebSocketStored(conn) {
+				t.Errorf("%s: expected the connection to be kept", test.policy)
+			}
+			continue
+		}
+
+		eventually(t, func() bool {
+			return !isWebSocketStored(conn) && conn.ConnectionContext().Err() != nil
+		}, test.policy+": expected the connection to be deleted")
+		ReplyToWebSocket(conn, []byte("4"))
+		if messages := takeMessages(conn); len(messages) != 0 {
+			t.Errorf("%s: expected the closed queue to refuse messages, got %v", test.policy, messages)
+		}
+	}
+}
+
+func TestSendQueueOrder(t *testing.T) {
+	server := newTestWebSocketServer(t)
+	client, conn := dialTestWebSocket(t, server, "")
+
+	for _, message := range []string{"1", "2", "3", "4", "5"} {
+		ReplyToWebSocket(conn, []byte(message))
+	}
+	for _, expected := range []string{"1", "2", "3", "4", "5"} {
+		_, data, err := client.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("expected message %s, got %s", expected, data)
+		}
+	}
+
+	eventually(t, func() bool {
+		return conn.SendQueueStats().Sent == 5
+	}, "expected the writer to count the sent messages")
+}
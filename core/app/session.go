@@ -0,0 +1,266 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gorilla/websocket"
+)
+
+const defaultResumeBufferSize = 256
+
+//WebSocketSessionInfo is the first message sent on a connection when resumable sessions are enabled.
+//Clients reconnect to /ws?resumeToken={ResumeToken}&lastSeq={Seq of the last WebSocketPubSubPayload received}
+//within the grace window to get the same connection id, rooms and meta back with the missed messages replayed in order.
+type WebSocketSessionInfo struct {
+	ResumeToken string `json:"resumeToken"`
+	SessionId   string `json:"sessionId"`
+	Resumed     bool   `json:"resumed"`
+	LastSeq     uint64 `json:"lastSeq"`
+}
+
+type bufferedWebSocketMessage struct {
+	seq  uint64
+	data []byte
+}
+
+//webSocketSession outlives its connection for the grace window.  conn is nil while the client is disconnected.
+type webSocketSession struct {
+	sync.Mutex
+	token    string
+	id       string
+	conn     *WebSocketConnection
+	last     *WebSocketConnection
+	seq      uint64
+	buffer   []bufferedWebSocketMessage
+	detached time.Time
+	expired  bool
+}
+
+var webSocketSessions sync.Map
+
+func getResumeSettings() (grace time.Duration, bufferSize int) {
+	webSocket := serverSettings.GetWebConfig().Application.WebSocket
+	bufferSize = webSocket.ResumeBufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultResumeBufferSize
+	}
+	return time.Duration(webSocket.ResumeGraceWindow) * time.Millisecond, bufferSize
+}
+
+func newResumeToken() string {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		return randomString(64)
+	}
+	return hex.EncodeToString(token)
+}
+
+//resumeWebSocketSession attaches the new connection to the session of the resumeToken query parameter, giving it
+//the id of the session, and queues the missed messages.  The session lock is held while replaying so messages published
+//meanwhile are queued after the replay.  A connection the server has not noticed is dead yet is closed and replaced, since
+//only the client holding the token can resume.  It returns false when the token is unknown or expired.
+func resumeWebSocketSession(wsConn *WebSocketConnection, r *http.Request) bool {
+	token := r.URL.Query().Get("resumeToken")
+	if token == "" {
+		return false
+	}
+	obj, ok := webSocketSessions.Load(token)
+	if !ok {
+		return false
+	}
+
+	session := obj.(*webSocketSession)
+	session.Lock()
+	defer session.Unlock()
+	if session.expired {
+		return false
+	}
+	if session.conn != nil {
+		stale := session.conn
+		if CustomLog != nil {
+			CustomLog("app->resumeWebSocketSession", "Replacing stale Web Socket of session "+session.id+" from "+stale.remoteAddr())
+		}
+		//The session no longer points at the stale connection so its removal keeps the rooms and meta.
+		defer func() {
+			stale.closeTransport()
+			deleteWebSocket(stale)
+		}()
+	}
+	session.conn = wsConn
+	session.last = wsConn
+	wsConn.Id = session.id
+	wsConn.session = session
+
+	lastSeq, _ := strconv.ParseUint(r.URL.Query().Get("lastSeq"), 10, 64)
+	ReplyToWebSocketJSON(wsConn, WebSocketSessionInfo{ResumeToken: session.token, SessionId: session.id, Resumed: true, LastSeq: session.seq})
+	for _, message := range session.buffer {
+		if message.seq > lastSeq {
+			wsConn.enqueue(websocket.TextMessage, message.data)
+		}
+	}
+	return true
+}
+
+//startWebSocketSession issues a resume token for a new connection.
+func startWebSocketSession(wsConn *WebSocketConnection) {
+	session := &webSocketSession{token: newResumeToken(), id: wsConn.Id, conn: wsConn, last: wsConn}
+	wsConn.session = session
+	webSocketSessions.Store(session.token, session)
+	ReplyToWebSocketJSON(wsConn, WebSocketSessionInfo{ResumeToken: session.token, SessionId: session.id})
+}
+
+//replaceWebSocketMemberships points the rooms and user of a resumed session at the new connection.
+func replaceWebSocketMemberships(conn *WebSocketConnection) {
+	obj, ok := webSocketMemberships.Load(conn.Id)
+	if !ok {
+		return
+	}
+	membership := obj.(*webSocketMembership)
+	membership.RLock()
+	rooms := []string{}
+	for room := range membership.rooms {
+		rooms = append(rooms, room)
+	}
+	userId := membership.userId
+	membership.RUnlock()
+
+	for _, room := range rooms {
+		addToWebSocketGroup(&webSocketRooms, room, conn)
+	}
+	if userId != "" {
+		addToWebSocketGroup(&webSocketUsers, userId, conn)
+	}
+}
+
+//detachWebSocketSession keeps the session of a deleted connection for the grace window.  It returns true when the
+//removal of the rooms and meta must wait for the window to expire.
+func detachWebSocketSession(conn *WebSocketConnection) bool {
+	session := conn.session
+	if session == nil {
+		return false
+	}
+
+	grace, _ := getResumeSettings()
+
+	session.Lock()
+	defer session.Unlock()
+
+	if session.expired {
+		return false
+	}
+	if session.conn != conn {
+		//Already detached or resumed by a newer connection.
+		return true
+	}
+	if grace <= 0 {
+		session.expired = true
+		webSocketSessions.Delete(session.token)
+		return false
+	}
+
+	session.conn = nil
+	detached := time.Now()
+	session.detached = detached
+	time.AfterFunc(grace, func() {
+		expireWebSocketSession(session, detached)
+	})
+	return true
+}
+
+func expireWebSocketSession(session *webSocketSession, detached time.Time) {
+	session.Lock()
+	if session.conn != nil || session.expired || !session.detached.Equal(detached) {
+		session.Unlock()
+		return
+	}
+	session.expired = true
+	session.buffer = nil
+	conn := session.last
+	session.Unlock()
+
+	webSocketSessions.Delete(session.token)
+	if CustomLog != nil {
+		CustomLog("app->expireWebSocketSession", "Web Socket session expired:  "+session.id)
+	}
+	finalizeWebSocketRemoval(conn)
+}
+
+//publishToWebSocketSession numbers the pub sub payload, keeps it for replay and queues it when the client is connected.
+func publishToWebSocketSession(session *webSocketSession, payload WebSocketPubSubPayload) {
+	_, bufferSize := getResumeSettings()
+
+	session.Lock()
+	defer session.Unlock()
+	if session.expired {
+		return
+	}
+
+	session.seq++
+	payload.Seq = session.seq
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Failed to marshal web socket publish of " + payload.Key + ":  " + err.Error())
+		return
+	}
+
+	session.buffer = append(session.buffer, bufferedWebSocketMessage{seq: session.seq, data: data})
+	if len(session.buffer) > bufferSize {
+		session.buffer[0] = bufferedWebSocketMessage{}
+		session.buffer = session.buffer[1:]
+	}
+
+	if session.conn != nil {
+		session.conn.enqueue(websocket.TextMessage, data)
+	}
+}
+
+//publishToDetachedWebSocketSessions buffers a payload for every disconnected session so PublishWebSocketJSON reaches them on resume.
+func publishToDetachedWebSocketSessions(payload WebSocketPubSubPayload) {
+	webSocketSessions.Range(func(key interface{}, value interface{}) bool {
+		session := value.(*webSocketSession)
+		session.Lock()
+		detached := session.conn == nil
+		session.Unlock()
+		if detached {
+			publishToWebSocketSession(session, payload)
+		}
+		return true
+	})
+}
+
+//currentWebSocketConnection returns the connection that resumed the session of conn, or conn.
+func currentWebSocketConnection(conn *WebSocketConnection) *WebSocketConnection {
+	session := conn.session
+	if session == nil {
+		return conn
+	}
+	session.Lock()
+	defer session.Unlock()
+	if session.conn != nil {
+		return session.conn
+	}
+	return conn
+}
+
+//isWebSocketGone returns true once the connection is deleted and its session can no longer be resumed.
+func isWebSocketGone(conn *WebSocketConnection) bool {
+	if conn.ConnectionContext().Err() == nil {
+		return false
+	}
+	session := conn.session
+	if session == nil {
+		return true
+	}
+	session.Lock()
+	defer session.Unlock()
+	return session.expired
+}
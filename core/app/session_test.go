@@ -0,0 +1,135 @@
+package app
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gorilla/websocket"
+)
+
+func setResumeGraceWindow(t *testing.T, grace int) {
+	setWebConfig(t, func() {
+		serverSettings.WebConfig.Application.WebSocket.ResumeGraceWindow = grace
+	})
+}
+
+func readSessionInfo(t *testing.T, client *websocket.Conn) (info WebSocketSessionInfo) {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	err := client.ReadJSON(&info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func readPubSubSeqs(t *testing.T, client *websocket.Conn, count int) (seqs []uint64) {
+	t.Helper()
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for i := 0; i < count; i++ {
+		var payload WebSocketPubSubPayload
+		err := client.ReadJSON(&payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs = append(seqs, payload.Seq)
+	}
+	return
+}
+
+func resumeQuery(info WebSocketSessionInfo, lastSeq uint64) string {
+	return "resumeToken=" + url.QueryEscape(info.ResumeToken) + "&lastSeq=" + strconv.FormatUint(lastSeq, 10)
+}
+
+func isSessionDetached(conn *WebSocketConnection) bool {
+	conn.session.Lock()
+	defer conn.session.Unlock()
+	return conn.session.conn == nil
+}
+
+func TestWebSocketSessionResume(t *testing.T) {
+	setResumeGraceWindow(t, 5000)
+	server := newTestWebSocketServer(t)
+
+	client, conn := dialTestWebSocket(t, server, "")
+	info := readSessionInfo(t, client)
+	if info.ResumeToken == "" || info.SessionId != conn.Id || info.Resumed {
+		t.Fatalf("expected a new session, got %+v", info)
+	}
+	JoinWebSocketRoom(conn, "chat")
+
+	for i := 1; i <= 3; i++ {
+		ReplyToWebSocketPubSub(conn, "Count", i)
+	}
+	if seqs := readPubSubSeqs(t, client, 3); seqs[0] != 1 || seqs[2] != 3 {
+		t.Fatalf("expected the publishes to be numbered, got %v", seqs)
+	}
+
+	client.Close()
+	eventually(t, func() bool {
+		return isSessionDetached(conn)
+	}, "expected the session to be detached")
+
+	//Published while the client is away.
+	ReplyToWebSocketPubSub(conn, "Count", 4)
+	ReplyToWebSocketPubSub(conn, "Count", 5)
+
+	resumed, resumedConn := dialTestWebSocket(t, server, resumeQuery(info, 2))
+	resumedInfo := readSessionInfo(t, resumed)
+	if !resumedInfo.Resumed || resumedInfo.SessionId != info.SessionId || resumedInfo.LastSeq != 5 || resumedConn.Id != conn.Id {
+		t.Fatalf("expected the session to be resumed, got %+v", resumedInfo)
+	}
+	if seqs := readPubSubSeqs(t, resumed, 3); seqs[0] != 3 || seqs[1] != 4 || seqs[2] != 5 {
+		t.Errorf("expected the messages after lastSeq to be replayed in order, got %v", seqs)
+	}
+
+	rooms := GetWebSocketRoomConnections("chat")
+	if len(rooms) != 1 || rooms[0] != resumedConn {
+		t.Errorf("expected the resumed connection to keep the rooms, got %v", rooms)
+	}
+	if _, ok := GetWebSocketMeta(conn.Id); !ok {
+		t.Error("expected the resumed connection to keep the meta")
+	}
+
+	//A second client with the token replaces a connection the server has not noticed is gone.
+	replacement, replacementConn := dialTestWebSocket(t, server, resumeQuery(info, 5))
+	if replacementInfo := readSessionInfo(t, replacement); !replacementInfo.Resumed {
+		t.Fatalf("expected the session to be resumed, got %+v", replacementInfo)
+	}
+	resumed.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := resumed.ReadMessage(); err == nil {
+		t.Error("expected the replaced connection to be closed")
+	}
+	eventually(t, func() bool {
+		return isWebSocketStored(replacementConn)
+	}, "expected the replacement to be stored under the session id")
+
+	LeaveWebSocketRoom(replacementConn, "chat")
+}
+
+func TestWebSocketSessionExpires(t *testing.T) {
+	setResumeGraceWindow(t, 100)
+	server := newTestWebSocketServer(t)
+
+	client, conn := dialTestWebSocket(t, server, "")
+	info := readSessionInfo(t, client)
+	JoinWebSocketRoom(conn, "expiring")
+
+	client.Close()
+	eventually(t, func() bool {
+		_, ok := GetWebSocketMeta(conn.Id)
+		return !ok
+	}, "expected the meta to be removed once the grace window expires")
+	if len(GetWebSocketRoomConnections("expiring")) != 0 {
+		t.Error("expected the expired session to leave its rooms")
+	}
+
+	late, lateConn := dialTestWebSocket(t, server, resumeQuery(info, 0))
+	lateInfo := readSessionInfo(t, late)
+	if lateInfo.Resumed || lateInfo.SessionId == info.SessionId || lateConn.Id == conn.Id {
+		t.Errorf("expected a new session after the grace window, got %+v", lateInfo)
+	}
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core"
+	"github.com/DanielRenne/GoCore/core/dbServices"
+	"github.com/DanielRenne/GoCore/core/fileCache"
+	"github.com/DanielRenne/GoCore/core/pubsub"
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+var shutdownSynced = struct {
+	sync.RWMutex
+	servers      []*http.Server
+	shuttingDown bool
+	inFlight     sync.WaitGroup
+	once         sync.Once
+	done         chan struct{}
+	err          error
+}{done: make(chan struct{})}
+
+var shutdownSignalsOnce sync.Once
+
+//BeginRequest counts an in-flight controller call so Shutdown waits for it.  It returns false once Shutdown has started.
+func BeginRequest() (done func(), ok bool) {
+	shutdownSynced.RLock()
+	defer shutdownSynced.RUnlock()
+	if shutdownSynced.shuttingDown {
+		return
+	}
+	shutdownSynced.inFlight.Add(1)
+	return shutdownSynced.inFlight.Done, true
+}
+
+//IsShuttingDown returns true once Shutdown has started.
+func IsShuttingDown() bool {
+	shutdownSynced.RLock()
+	defer shutdownSynced.RUnlock()
+	return shutdownSynced.shuttingDown
+}
+
+/*Shutdown stops the servers started by Run or RunLite and waits until ctx is done for the application to stop.
+New connections are refused, in-flight controller calls finish, sockets are sent a close frame, the cron job engine and
+pub sub callbacks finish, the fileCache job file is written and the database handles are closed.  Run returns after
+Shutdown completes.  SIGTERM and SIGINT call Shutdown with webConfig.json application.shutdownTimeout milliseconds.
+Implementation example-----------
+ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+defer cancel()
+err := app.Shutdown(ctx)
+---------------------------------
+*/
+func Shutdown(ctx context.Context) error {
+	shutdownSynced.once.Do(func() {
+		go func() {
+			shutdownSynced.err = shutdown(ctx)
+			close(shutdownSynced.done)
+		}()
+	})
+
+	select {
+	case <-shutdownSynced.done:
+		return shutdownSynced.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func shutdown(ctx context.Context) (err error) {
+	log.Println("GoCore Application Shutting Down")
+
+	record := func(e error) {
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+
+	shutdownSynced.Lock()
+	shutdownSynced.shuttingDown = true
+	servers := append([]*http.Server{}, shutdownSynced.servers...)
+	shutdownSynced.Unlock()
+
+	serverSettings.StopWatchingWebConfig()
+
+	cronStopped := make(chan error, 1)
+	go func() {
+		cronStopped <- core.CronJobs.Stop(ctx)
+	}()
+
+	var serversStopped sync.WaitGroup
+	for i := range servers {
+		serversStopped.Add(1)
+		go func(s *http.Server) {
+			defer serversStopped.Done()
+			if s.Shutdown(ctx) != nil {
+				s.Close()
+			}
+		}(servers[i])
+	}
+
+	record(waitWithContext(ctx, &shutdownSynced.inFlight))
+	CloseAllSockets()
+	record(waitWithContext(ctx, &serversStopped))
+	record(<-cronStopped)
+	record(pubsub.Shutdown(ctx))
+	record(fileCache.Flush())
+	record(dbServices.Close())
+
+	if err != nil {
+		log.Println("GoCore Application Stopped before everything finished:  " + err.Error())
+		return
+	}
+	log.Println("GoCore Application Stopped")
+	return
+}
+
+func waitWithContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//listenAndServe serves until Shutdown.  It returns http.ErrServerClosed without serving once Shutdown has started.
+func listenAndServe(s *http.Server, certFile string, keyFile string) error {
+	shutdownSynced.Lock()
+	if shutdownSynced.shuttingDown {
+		shutdownSynced.Unlock()
+		return http.ErrServerClosed
+	}
+	shutdownSynced.servers = append(shutdownSynced.servers, s)
+	shutdownSynced.Unlock()
+
+	if certFile != "" {
+		return s.ListenAndServeTLS(certFile, keyFile)
+	}
+	return s.ListenAndServe()
+}
+
+//serveUntilShutdown serves the main server of Run and RunLite and returns after Shutdown completes.
+func serveUntilShutdown(s *http.Server) {
+	handleShutdownSignals()
+
+	err := listenAndServe(s, "", "")
+	if err != http.ErrServerClosed {
+		log.Println("GoCore Application failed to serve on " + s.Addr + ":  " + err.Error())
+		return
+	}
+	<-shutdownSynced.done
+}
+
+func shutdownTimeout() time.Duration {
+	timeout := serverSettings.GetWebConfig().Application.ShutdownTimeout
+	if timeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return time.Duration(timeout) * time.Millisecond
+}
+
+//handleShutdownSignals calls Shutdown on the first SIGTERM or SIGINT and exits.  A second signal exits immediately.
+func handleShutdownSignals() {
+	shutdownSignalsOnce.Do(func() {
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+		go func() {
+			sig := <-signals
+			log.Println("Received " + sig.String() + ", shutting down.")
+			go func() {
+				<-signals
+				log.Println("Received a second signal, exiting.")
+				os.Exit(1)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+			err := Shutdown(ctx)
+			cancel()
+			if err != nil {
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}()
+	})
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+//resetShutdown lets the next test call Shutdown again once the shutdown of this test finished, which may be after Shutdown returned.
+func resetShutdown(t *testing.T) {
+	t.Cleanup(func() {
+		shutdownSynced.RLock()
+		done := shutdownSynced.done
+		shutdownSynced.RUnlock()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("expected the shutdown to finish")
+		}
+
+		shutdownSynced.Lock()
+		shutdownSynced.servers = nil
+		shutdownSynced.shuttingDown = false
+		shutdownSynced.once = sync.Once{}
+		shutdownSynced.done = make(chan struct{})
+		shutdownSynced.err = nil
+		shutdownSynced.Unlock()
+	})
+}
+
+func isServerRegistered(s *http.Server) bool {
+	shutdownSynced.RLock()
+	defer shutdownSynced.RUnlock()
+	for _, server := range shutdownSynced.servers {
+		if server == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestShutdown(t *testing.T) {
+	resetShutdown(t)
+	server := newTestWebSocketServer(t)
+	client, conn := dialTestWebSocket(t, server, "")
+
+	s := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	served := make(chan error, 1)
+	go func() {
+		served <- listenAndServe(s, "", "")
+	}()
+	eventually(t, func() bool {
+		return isServerRegistered(s)
+	}, "expected the server to be registered")
+
+	done, ok := BeginRequest()
+	if !ok {
+		t.Fatal("expected the call to begin before Shutdown")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- Shutdown(ctx)
+	}()
+
+	eventually(t, IsShuttingDown, "expected Shutdown to start")
+	if _, ok := BeginRequest(); ok {
+		t.Error("expected new calls to be refused once Shutdown started")
+	}
+	if listenAndServe(&http.Server{Addr: "127.0.0.1:0"}, "", "") != http.ErrServerClosed {
+		t.Error("expected new servers to be refused once Shutdown started")
+	}
+
+	//Sockets stay open until the in-flight calls are drained.
+	select {
+	case err := <-stopped:
+		t.Fatalf("expected Shutdown to wait for the in-flight call, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if !isWebSocketStored(conn) {
+		t.Error("expected the sockets to be closed after the in-flight calls finished")
+	}
+
+	done()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("expected Shutdown to complete, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Shutdown to complete once the in-flight call finished")
+	}
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := client.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("expected a going away close frame, got %v", err)
+	}
+	select {
+	case err = <-served:
+		if err != http.ErrServerClosed {
+			t.Errorf("expected the server to be closed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expected the server to stop serving")
+	}
+
+	if Shutdown(context.Background()) != nil {
+		t.Error("expected a second Shutdown to return the result of the first")
+	}
+}
+
+func TestWaitWithContext(t *testing.T) {
+	var inFlight sync.WaitGroup
+	inFlight.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := waitWithContext(ctx, &inFlight); err != context.DeadlineExceeded {
+		t.Errorf("expected the wait to give up on the in-flight call, got %v", err)
+	}
+
+	inFlight.Done()
+	if err := waitWithContext(context.Background(), &inFlight); err != nil {
+		t.Errorf("expected the wait to finish, got %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package app
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/ginServer"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+//Transports of a WebSocketConnection.
+const (
+	TransportWebSocket = "websocket"
+	TransportSSE       = "sse"
+	TransportLongPoll  = "longPoll"
+)
+
+//Routes of the fallback transports for clients that cannot upgrade to a web socket.
+const (
+	SSEPath      = "/ws/sse"
+	LongPollPath = "/ws/poll"
+	SendPath     = "/ws/send"
+)
+
+const (
+	longPollWait      = 25 * time.Second
+	longPollBatchSize = 100
+)
+
+//VirtualWebSocketOpen is the first event of an event stream and the response of a long poll without an id.
+//Clients POST their messages to /ws/send?id={Id}&token={Token}.  Only the client that opened the connection receives the token.
+type VirtualWebSocketOpen struct {
+	Id    string `json:"id"`
+	Token string `json:"token"`
+}
+
+//LongPollMessage is a message delivered by long poll.  Text holds a text frame and Binary the bytes of a binary frame.
+type LongPollMessage struct {
+	Text   string `json:"text,omitempty"`
+	Binary []byte `json:"binary,omitempty"`
+}
+
+//LongPollResponse is the response to /ws/poll?id={Id}&token={Token}&ack={Seq}.  Messages is empty when the poll timed out.
+//The messages of a response are delivered again until the next poll acknowledges its Seq.
+type LongPollResponse struct {
+	Id       string            `json:"id"`
+	Seq      uint64            `json:"seq"`
+	Messages []LongPollMessage `json:"messages"`
+}
+
+//virtualTransport delivers the send queue of a connection without a web socket.  Long poll connections have no writer
+//and keep the last batch in pending until it is acknowledged.  The fields are guarded by the WriteLock of the connection.
+type virtualTransport struct {
+	token   string
+	writer  io.Writer
+	flush   func() error
+	closed  bool
+	polling bool
+	pending []LongPollMessage
+	seq     uint64
+}
+
+//write sends a message as a server sent event.  Text frames are data events and binary frames base64 encoded binary events.
+func (obj *virtualTransport) write(conn *WebSocketConnection, messageType int, data []byte) error {
+	if messageType == websocket.BinaryMessage {
+		return obj.writeEvent(conn, "binary", []byte(base64.StdEncoding.EncodeToString(data)))
+	}
+	return obj.writeEvent(conn, "", data)
+}
+
+func (obj *virtualTransport) writeEvent(conn *WebSocketConnection, event string, data []byte) error {
+	conn.WriteLock.Lock()
+	defer conn.WriteLock.Unlock()
+
+	if obj.closed || obj.writer == nil {
+		return errors.New("Event stream is closed.")
+	}
+
+	var buffer bytes.Buffer
+	if event != "" {
+		buffer.WriteString("event: " + event + "\n")
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buffer.WriteString("data: ")
+		buffer.Write(line)
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("\n")
+
+	_, err := obj.writer.Write(buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	return obj.flush()
+}
+
+func (obj *virtualTransport) writePing(conn *WebSocketConnection) error {
+	conn.WriteLock.Lock()
+	defer conn.WriteLock.Unlock()
+
+	if obj.closed || obj.writer == nil {
+		return nil
+	}
+	_, err := obj.writer.Write([]byte(": ping\n\n"))
+	if err != nil {
+		return err
+	}
+	return obj.flush()
+}
+
+//close stops writing once the handler of the event stream returns.
+func (obj *virtualTransport) close(conn *WebSocketConnection) {
+	conn.WriteLock.Lock()
+	obj.closed = true
+	conn.WriteLock.Unlock()
+}
+
+//mountVirtualWebSocketRoutes adds the server sent events, long poll and send routes next to /ws.
+func mountVirtualWebSocketRoutes() {
+	ginServer.Router.GET(SSEPath, sseHandler)
+	ginServer.Router.GET(LongPollPath, longPollHandler)
+	ginServer.Router.POST(SendPath, virtualSendHandler)
+}
+
+//newVirtualWebSocket creates a connection for a fallback transport.  Its encoding comes from the encoding query parameter.
+func newVirtualWebSocket(c *gin.Context, transportType string, transport *virtualTransport) *WebSocketConnection {
+	wsConn := new(WebSocketConnection)
+	wsConn.Req = c.Request
+	wsConn.GinContextSync.Context = c.Copy()
+	wsConn.Transport = transportType
+	wsConn.virtual = transport
+	transport.token = newResumeToken()
+
+	wsConn.Encoding = EncodingJSON
+	encoding := c.Query("encoding")
+	if IsBinaryEncoding(encoding) {
+		wsConn.Encoding = encoding
+	}
+
+	uuid, err := newUUID()
+	if err == nil {
+		wsConn.Id = uuid
+	} else {
+		wsConn.Id = randomString(20)
+	}
+
+	socketMeta := new(WebSocketConnectionMeta)
+	socketMeta.Conn = wsConn
+	socketMeta.LastResponseTime.Set(time.Now())
+	SetWebSocketMeta(wsConn.Id, socketMeta)
+	return wsConn
+}
+
+//registerVirtualWebSocket adds the connection so replies and publishes reach it and watches it for timeouts.
+func registerVirtualWebSocket(wsConn *WebSocketConnection) {
+	if CustomLog != nil {
+		CustomLog("app->registerVirtualWebSocket", "Added "+wsConn.Transport+" Connection from "+wsConn.remoteAddr())
+	}
+	WebSocketConnections.Store(wsConn.Id, wsConn)
+	startVirtualWebSocketHeartbeat(wsConn)
+}
+
+//getVirtualWebSocket returns the connection of the id query parameter when the token query parameter is the one issued when it was opened.
+func getVirtualWebSocket(c *gin.Context) (wsConn *WebSocketConnection, ok bool) {
+	value, ok := WebSocketConnections.Load(c.Query("id"))
+	if !ok {
+		return
+	}
+	wsConn = value.(*WebSocketConnection)
+	if wsConn.virtual == nil || (wsConn.Transport != TransportSSE && wsConn.Transport != TransportLongPoll) {
+		ok = false
+		return
+	}
+	ok = subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(wsConn.virtual.token)) == 1
+	return
+}
+
+//startVirtualWebSocketHeartbeat sends comment pings on event streams and deletes long poll connections that stop polling.
+func startVirtualWebSocketHeartbeat(conn *WebSocketConnection) {
+	go func() {
+		defer func() {
+			if recover := recover(); recover != nil {
+				if CustomLog != nil {
+					CustomLog("app->startVirtualWebSocketHeartbeat", "Panic Recovered at startVirtualWebSocketHeartbeat()")
+				}
+			}
+		}()
+
+		done := conn.ConnectionContext().Done()
+		for {
+			timer := time.NewTimer(webSocketPingInterval())
+			select {
+			case <-done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if conn.Transport == TransportSSE {
+				err := conn.virtual.writePing(conn)
+				if err != nil {
+					if CustomLog != nil {
+						CustomLog("app->startVirtualWebSocketHeartbeat", "Deleting event stream after failed ping:  "+err.Error()+":  "+conn.remoteAddr())
+					}
+					deleteWebSocket(conn)
+					return
+				}
+				continue
+			}
+
+			meta, ok := GetWebSocketMeta(conn.Id)
+			if !ok || time.Since(meta.LastResponseTime.Get()) > webSocketPongTimeout(conn.Id) {
+				if CustomLog != nil {
+					CustomLog("app->startVirtualWebSocketHeartbeat", "Deleting long poll connection without polls:  "+conn.remoteAddr())
+				}
+				deleteWebSocket(conn)
+				return
+			}
+		}
+	}()
+}
+
+func touchVirtualWebSocket(conn *WebSocketConnection) {
+	meta, ok := GetWebSocketMeta(conn.Id)
+	if ok {
+		meta.LastResponseTime.Set(time.Now())
+	}
+}
+
+//openEventStream writes the headers of an event stream.  HTTP/1 connections are hijacked to clear the deadlines set by
+//the ReadTimeout and WriteTimeout of the server, which would otherwise end the stream.  disconnected is closed when the
+//client goes away and closeStream must be called once the stream is done.
+func openEventStream(c *gin.Context) (transport *virtualTransport, disconnected <-chan struct{}, closeStream func(), ok bool) {
+	hijacker, ok := c.Writer.(http.Hijacker)
+	if ok {
+		netConn, rw, err := hijacker.Hijack()
+		if err == nil {
+			netConn.SetDeadline(time.Time{})
+			rw.WriteString("HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nCache-Control: no-cache\r\nConnection: close\r\nX-Accel-Buffering: no\r\n\r\n")
+
+			//The client sends nothing more on the stream, so the read returns when it disconnects.
+			closed := make(chan struct{})
+			go func() {
+				io.Copy(ioutil.Discard, rw.Reader)
+				close(closed)
+			}()
+
+			transport = &virtualTransport{writer: rw, flush: rw.Flush}
+			return transport, closed, func() { netConn.Close() }, true
+		}
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		return
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Writer.WriteHeader(http.StatusOK)
+
+	transport = &virtualTransport{writer: c.Writer, flush: func() error {
+		flusher.Flush()
+		return nil
+	}}
+	return transport, c.Request.Context().Done(), func() {}, true
+}
+
+//sseHandler streams the messages of a virtual connection as server sent events until the client disconnects.
+func sseHandler(c *gin.Context) {
+	transport, disconnected, closeStream, ok := openEventStream(c)
+	if !ok {
+		c.Status(http.StatusNotImplemented)
+		return
+	}
+	defer closeStream()
+
+	wsConn := newVirtualWebSocket(c, TransportSSE, transport)
+	defer transport.close(wsConn)
+
+	data, _ := json.Marshal(VirtualWebSocketOpen{Id: wsConn.Id, Token: transport.token})
+	err := transport.writeEvent(wsConn, "open", data)
+	if err != nil {
+		RemoveWebSocketMeta(wsConn.Id)
+		return
+	}
+	registerVirtualWebSocket(wsConn)
+
+	select {
+	case <-disconnected:
+		deleteWebSocket(wsConn)
+	case <-wsConn.ConnectionContext().Done():
+	}
+}
+
+//longPollHandler opens a virtual connection when called without an id.  With an id it returns the unacknowledged batch
+//again, or waits for queued messages and returns up to a batch of them, or none after the poll wait.
+//A connection is polled by one request at a time.
+func longPollHandler(c *gin.Context) {
+	if c.Query("id") == "" {
+		transport := &virtualTransport{}
+		wsConn := newVirtualWebSocket(c, TransportLongPoll, transport)
+		registerVirtualWebSocket(wsConn)
+		c.JSON(http.StatusOK, VirtualWebSocketOpen{Id: wsConn.Id, Token: transport.token})
+		return
+	}
+
+	wsConn, ok := getVirtualWebSocket(c)
+	if !ok || wsConn.Transport != TransportLongPoll {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	touchVirtualWebSocket(wsConn)
+
+	transport := wsConn.virtual
+	ack, _ := strconv.ParseUint(c.Query("ack"), 10, 64)
+
+	wsConn.WriteLock.Lock()
+	if transport.polling {
+		wsConn.WriteLock.Unlock()
+		c.Status(http.StatusConflict)
+		return
+	}
+	transport.polling = true
+	if ack >= transport.seq {
+		transport.pending = nil
+	}
+	response := LongPollResponse{Id: wsConn.Id, Seq: transport.seq, Messages: transport.pending}
+	wsConn.WriteLock.Unlock()
+
+	defer func() {
+		wsConn.WriteLock.Lock()
+		transport.polling = false
+		wsConn.WriteLock.Unlock()
+	}()
+
+	if len(response.Messages) > 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	response.Messages = []LongPollMessage{}
+
+	wait := longPollWait
+	if timeout := webSocketPongTimeout(wsConn.Id) / 2; timeout < wait {
+		wait = timeout
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	queue := wsConn.sendQueue()
+	for {
+		messages := queue.take(longPollBatchSize)
+		if len(messages) > 0 {
+			for _, message := range messages {
+				if message.messageType == websocket.BinaryMessage {
+					response.Messages = append(response.Messages, LongPollMessage{Binary: message.data})
+				} else {
+					response.Messages = append(response.Messages, LongPollMessage{Text: string(message.data)})
+				}
+			}
+
+			//Keep the batch until the next poll acknowledges it, in case this response never reaches the client.
+			wsConn.WriteLock.Lock()
+			transport.seq++
+			transport.pending = response.Messages
+			response.Seq = transport.seq
+			wsConn.WriteLock.Unlock()
+			break
+		}
+
+		select {
+		case <-queue.signal:
+			continue
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+			return
+		case <-wsConn.ConnectionContext().Done():
+			c.Status(http.StatusGone)
+			return
+		}
+		break
+	}
+
+	touchVirtualWebSocket(wsConn)
+	c.JSON(http.StatusOK, response)
+}
+
+//virtualSendHandler dispatches the body as a message from the client of an event stream or long poll connection.
+//A msgpack or cbor Content-Type is dispatched as a binary frame.
+func virtualSendHandler(c *gin.Context) {
+	wsConn, ok := getVirtualWebSocket(c)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	data, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	messageType := websocket.TextMessage
+	if IsBinaryEncoding(EncodingFromContentType(c.ContentType())) {
+		messageType = websocket.BinaryMessage
+	}
+
+	go dispatchWebSocketMessage(wsConn, wsConn.GinContextSync.Context, messageType, data)
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,244 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func newTestVirtualServer(t *testing.T) *httptest.Server {
+	router := gin.New()
+	router.GET(SSEPath, sseHandler)
+	router.GET(LongPollPath, longPollHandler)
+	router.POST(SendPath, virtualSendHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func virtualURL(server *httptest.Server, path string, id string, token string) string {
+	return server.URL + path + "?id=" + url.QueryEscape(id) + "&token=" + url.QueryEscape(token)
+}
+
+func getVirtualConnection(t *testing.T, id string) *WebSocketConnection {
+	t.Helper()
+	value, ok := WebSocketConnections.Load(id)
+	if !ok {
+		t.Fatalf("expected connection %s to be registered", id)
+	}
+	conn := value.(*WebSocketConnection)
+	t.Cleanup(func() {
+		deleteWebSocket(conn)
+	})
+	return conn
+}
+
+//receiveVirtualMessages records the messages dispatched from virtual clients.
+func receiveVirtualMessages(t *testing.T) chan string {
+	received := make(chan string, 10)
+	WebSocketCallbacks.Store(t.Name(), WebSocketCallback(func(conn *WebSocketConnection, c *gin.Context, messageType int, id string, data []byte) {
+		received <- id + ":" + string(data)
+	}))
+	t.Cleanup(func() {
+		WebSocketCallbacks.Delete(t.Name())
+	})
+	return received
+}
+
+func postVirtualMessage(t *testing.T, server *httptest.Server, id string, token string, message string) int {
+	t.Helper()
+	resp, err := http.Post(virtualURL(server, SendPath, id, token), "application/json", strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+//readEvent reads the next server sent event, skipping comment pings.
+func readEvent(t *testing.T, reader *bufio.Reader) (event string, data string) {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && data != "":
+			return
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data += strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestSSEConnection(t *testing.T) {
+	server := newTestVirtualServer(t)
+	received := receiveVirtualMessages(t)
+
+	resp, err := http.Get(server.URL + SSEPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	event, data := readEvent(t, reader)
+	var open VirtualWebSocketOpen
+	if event != "open" || json.Unmarshal([]byte(data), &open) != nil || open.Id == "" || open.Token == "" {
+		t.Fatalf("expected the open event, got %s %s", event, data)
+	}
+	conn := getVirtualConnection(t, open.Id)
+
+	if status := postVirtualMessage(t, server, open.Id, "", `{"hello":1}`); status != http.StatusNotFound {
+		t.Errorf("expected a send without the token to be refused, got %d", status)
+	}
+	if status := postVirtualMessage(t, server, open.Id, "wrong", `{"hello":1}`); status != http.StatusNotFound {
+		t.Errorf("expected a send with a wrong token to be refused, got %d", status)
+	}
+	if status := postVirtualMessage(t, server, open.Id, open.Token, `{"hello":1}`); status != http.StatusAccepted {
+		t.Errorf("expected the send to be accepted, got %d", status)
+	}
+	select {
+	case message := <-received:
+		if message != open.Id+`:{"hello":1}` {
+			t.Errorf("unexpected message %s", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the send to be dispatched")
+	}
+
+	ReplyToWebSocketJSON(conn, map[string]string{"reply": "text"})
+	if event, data = readEvent(t, reader); event != "" || data != `{"reply":"text"}` {
+		t.Errorf("expected a data event, got %s %s", event, data)
+	}
+	conn.enqueue(websocket.BinaryMessage, []byte{1, 2, 3})
+	if event, data = readEvent(t, reader); event != "binary" || data != "AQID" {
+		t.Errorf("expected a base64 binary event, got %s %s", event, data)
+	}
+
+	resp.Body.Close()
+	eventually(t, func() bool {
+		return !isWebSocketStored(conn)
+	}, "expected the connection to be deleted when the client disconnects")
+}
+
+func longPoll(t *testing.T, server *httptest.Server, open VirtualWebSocketOpen, ack uint64) (status int, response LongPollResponse) {
+	t.Helper()
+	resp, err := http.Get(virtualURL(server, LongPollPath, open.Id, open.Token) + "&ack=" + strconv.FormatUint(ack, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	status = resp.StatusCode
+	if status == http.StatusOK {
+		err = json.NewDecoder(resp.Body).Decode(&response)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return
+}
+
+func TestLongPollConnection(t *testing.T) {
+	setWebConfig(t, func() {
+		serverSettings.WebConfig.Application.WebSocket.PongTimeout = 400
+	})
+	server := newTestVirtualServer(t)
+	received := receiveVirtualMessages(t)
+
+	var open VirtualWebSocketOpen
+	resp, err := http.Get(server.URL + LongPollPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&open)
+	resp.Body.Close()
+	if err != nil || open.Id == "" || open.Token == "" {
+		t.Fatalf("expected the long poll to open, got %+v %v", open, err)
+	}
+	conn := getVirtualConnection(t, open.Id)
+
+	if status, _ := longPoll(t, server, VirtualWebSocketOpen{Id: open.Id, Token: "wrong"}, 0); status != http.StatusNotFound {
+		t.Errorf("expected a poll with a wrong token to be refused, got %d", status)
+	}
+	if status := postVirtualMessage(t, server, open.Id, open.Token, "ping"); status != http.StatusAccepted {
+		t.Errorf("expected the send to be accepted, got %d", status)
+	}
+	select {
+	case message := <-received:
+		if message != open.Id+":ping" {
+			t.Errorf("unexpected message %s", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the send to be dispatched")
+	}
+
+	ReplyToWebSocket(conn, []byte("1"))
+	conn.enqueue(websocket.BinaryMessage, []byte{2})
+	first := []LongPollMessage{{Text: "1"}, {Binary: []byte{2}}}
+
+	status, response := longPoll(t, server, open, 0)
+	if status != http.StatusOK || response.Seq != 1 || !reflect.DeepEqual(response.Messages, first) {
+		t.Fatalf("expected the first batch, got %d %+v", status, response)
+	}
+
+	//The batch is delivered again until it is acknowledged.
+	ReplyToWebSocket(conn, []byte("3"))
+	status, response = longPoll(t, server, open, 0)
+	if status != http.StatusOK || response.Seq != 1 || !reflect.DeepEqual(response.Messages, first) {
+		t.Errorf("expected the unacknowledged batch again, got %d %+v", status, response)
+	}
+
+	status, response = longPoll(t, server, open, 1)
+	if status != http.StatusOK || response.Seq != 2 || !reflect.DeepEqual(response.Messages, []LongPollMessage{{Text: "3"}}) {
+		t.Errorf("expected the next batch after the acknowledgement, got %d %+v", status, response)
+	}
+
+	//A poll without messages returns after half the pong timeout, while it waits a second poll is refused.
+	polled := make(chan LongPollResponse, 1)
+	go func() {
+		_, response := longPoll(t, server, open, 2)
+		polled <- response
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if status, _ = longPoll(t, server, open, 2); status != http.StatusConflict {
+		t.Errorf("expected a concurrent poll to be refused, got %d", status)
+	}
+	select {
+	case response = <-polled:
+		if response.Seq != 2 || len(response.Messages) != 0 {
+			t.Errorf("expected an empty poll, got %+v", response)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the poll to time out")
+	}
+
+	//Messages queued while a poll waits are returned at once.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ReplyToWebSocket(conn, []byte("4"))
+	}()
+	start := time.Now()
+	status, response = longPoll(t, server, open, 2)
+	if status != http.StatusOK || response.Seq != 3 || !reflect.DeepEqual(response.Messages, []LongPollMessage{{Text: "4"}}) || time.Since(start) > 150*time.Millisecond {
+		t.Errorf("expected the waiting poll to return the queued message, got %d %+v after %v", status, response, time.Since(start))
+	}
+}
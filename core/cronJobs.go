@@ -1,10 +1,12 @@
 package core
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/DanielRenne/GoCore/core/atomicTypes"
 	"github.com/DanielRenne/GoCore/core/fileCache"
 )
 
@@ -25,6 +27,13 @@ const (
 )
 
 type cronJobs struct {
+	sync.Mutex
+	ticker   *time.Ticker
+	stop     chan struct{}
+	stopped  chan struct{}
+	running  sync.WaitGroup
+	stopping bool
+	last     atomicTypes.AtomicTime
 }
 
 type onDemandJobsSync struct {
@@ -76,8 +85,21 @@ func (jobs *cronJobs) ShouldRunEvery15Minutes(x time.Time) (run bool) {
 }
 
 func (jobs *cronJobs) Start() {
+	jobs.Lock()
+	defer jobs.Unlock()
+	if jobs.ticker != nil {
+		return
+	}
+	jobs.stopping = false
 	ticker := time.NewTicker(time.Millisecond * 100)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	jobs.ticker = ticker
+	jobs.stop = stop
+	jobs.stopped = stopped
+
 	go func() {
+		defer close(stopped)
 
 		callTopMinute := true
 		callTopHour := true
@@ -85,37 +107,44 @@ func (jobs *cronJobs) Start() {
 		callTop30Seconds := true
 		previousSec := 0
 
-		for t := range ticker.C {
+		for {
+			var t time.Time
+			select {
+			case <-stop:
+				return
+			case t = <-ticker.C:
+			}
+			jobs.last.Set(t)
 			tm := t
 			hour, min, sec := t.Clock()
 			if sec == 0 { //Top of the Minute && Top of 30 Seconds
 				if callTopMinute {
-					go callRecurringEvents(CRON_TOP_OF_MINUTE, tm)
+					callRecurringEvents(CRON_TOP_OF_MINUTE, tm)
 					callTopMinute = false
 				}
 
 				if callTop30Seconds {
-					go callRecurringEvents(CRON_TOP_OF_30_SECONDS, tm)
+					callRecurringEvents(CRON_TOP_OF_30_SECONDS, tm)
 					callTop30Seconds = false
 				}
 			}
 
 			if sec == 30 { //Top of the Minute && Top of 30 Seconds
 				if callTop30Seconds {
-					go callRecurringEvents(CRON_TOP_OF_30_SECONDS, tm)
+					callRecurringEvents(CRON_TOP_OF_30_SECONDS, tm)
 					callTop30Seconds = false
 				}
 			}
 
 			if sec == 0 && min == 0 { //Top of the Hour
 				if callTopHour {
-					go callRecurringEvents(CRON_TOP_OF_HOUR, tm)
+					callRecurringEvents(CRON_TOP_OF_HOUR, tm)
 					callTopHour = false
 				}
 			}
 			if sec == 0 && min == 0 && hour == 0 { //Top of the Day
 				if callTopDay {
-					go callRecurringEvents(CRON_TOP_OF_DAY, tm)
+					callRecurringEvents(CRON_TOP_OF_DAY, tm)
 					callTopDay = false
 				}
 			}
@@ -131,13 +160,56 @@ func (jobs *cronJobs) Start() {
 
 			if previousSec != sec {
 				previousSec = sec
-				go callRecurringEvents(CRON_TOP_OF_SECOND, tm)
+				callRecurringEvents(CRON_TOP_OF_SECOND, tm)
 			}
 		}
 	}()
 
 }
 
+//IsRunning returns true between Start and Stop.
+func (jobs *cronJobs) IsRunning() bool {
+	jobs.Lock()
+	defer jobs.Unlock()
+	return jobs.ticker != nil
+}
+
+//LastTick returns the time the engine last ticked.  It ticks every 100 milliseconds while running.
+func (jobs *cronJobs) LastTick() time.Time {
+	return jobs.last.Get()
+}
+
+//Stop stops the cron job engine and waits for the running recurring and one time jobs until ctx is done.
+//Jobs started after Stop are dropped until the engine is started again.
+func (jobs *cronJobs) Stop(ctx context.Context) error {
+	jobs.Lock()
+	jobs.stopping = true
+	stopped := jobs.stopped
+	if jobs.ticker != nil {
+		jobs.ticker.Stop()
+		close(jobs.stop)
+		jobs.ticker = nil
+	}
+	jobs.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		//Recurring jobs are only added by the ticker go routine, so wait for it to return first.
+		if stopped != nil {
+			<-stopped
+		}
+		jobs.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 //Register provides a method to register for a callback that is called at the start of the cron job engine and 5 seconds before each day occures.
 func (jobs *cronJobs) RegisterRecurring(t RecurringType, callback RecurringEvent) {
 	defer func() {
@@ -161,7 +233,12 @@ func (jobs *cronJobs) ExecuteOneTimeJob(jobName string, callback OneTimeEvent) {
 		}
 	}()
 
+	if !jobs.beginJob() {
+		log.Println("Dropped one time job " + jobName + " because the cron job engine is stopping.")
+		return
+	}
 	go func() {
+		defer jobs.running.Done()
 		fileCache.Jobs.Lock()
 		defer func() {
 			fileCache.Jobs.Unlock()
@@ -185,12 +262,27 @@ func processRecurringTick(tm time.Time) {
 
 }
 
+//beginJob adds a running job unless the engine is stopping, so Stop does not wait on jobs started after it.
+func (jobs *cronJobs) beginJob() bool {
+	jobs.Lock()
+	defer jobs.Unlock()
+	if jobs.stopping {
+		return false
+	}
+	jobs.running.Add(1)
+	return true
+}
+
 func callRecurringEvents(t RecurringType, tm time.Time) {
 	recurringJobs.RLock()
 	for _, item := range recurringJobs.items {
 		i := item
 		if i.Type == t {
+			if !CronJobs.beginJob() {
+				break
+			}
 			go func(e RecurringEvent) {
+				defer CronJobs.running.Done()
 				e(tm)
 			}(i.Event)
 		}
@@ -117,9 +117,17 @@ var transactionQueue tQueue
 func init() {
 	transactionQueue.ids = make(map[string][]string)
 	transactionQueue.queue = make(map[string]*transactionsToPersist)
+	dbServices.RegisterTransactionQueue("model", pendingTransactions)
 	go clearTransactionQueue()
 }
 
+//pendingTransactions returns the number of transactions that are neither committed nor rolled back.
+func pendingTransactions() int {
+	transactionQueue.RLock()
+	defer transactionQueue.RUnlock()
+	return len(transactionQueue.queue)
+}
+
 func Q(k string, v interface{}) map[string]interface{} {
 	return map[string]interface{}{k: v}
 }
@@ -330,13 +338,13 @@ func getJoins(x reflect.Value, remainingRecursions string) (joins []join, err er
 		}
 	} else {
 		typeField, ok := joinsField.Type().FieldByName(fieldName)
-		if serverSettings.WebConfig.Application.LogJoinQueries {
+		if serverSettings.GetWebConfig().Application.LogJoinQueries {
 			fmt.Println(fmt.Sprintf("%+v", fields), joinsField.Kind(), ok)
 		}
 
 		if ok == false {
 			msg := "Could not resolve a field (getJoins model): " + fmt.Sprintf("%+v", remainingRecursions) + " on " + x.Type().String() + " object"
-			if serverSettings.WebConfig.Application.LogJoinQueries {
+			if serverSettings.GetWebConfig().Application.LogJoinQueries {
 				fmt.Println(msg)
 			}
 			//new := errors.New(msg)
@@ -438,14 +446,14 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 		if r := recover(); r != nil {
 			msg := "Panic Recovered at model.JoinEntity:  Failed to join " + j.joinSchemaName + " with id:" + id + "  Error:" + fmt.Sprintf("%+v", r)
 			err = errors.New(msg)
-			if serverSettings.WebConfig.Application.LogJoinQueries && err != nil {
+			if serverSettings.GetWebConfig().Application.LogJoinQueries && err != nil {
 				fmt.Println("err Recursion Line 399->" + fmt.Sprintf("%+v", err))
 			}
 			return
 		}
 	}()
 
-	if serverSettings.WebConfig.Application.LogJoinQueries {
+	if serverSettings.GetWebConfig().Application.LogJoinQueries {
 		fmt.Println("!!!!!!!!!!!!ddd!!!!!!!!")
 		fmt.Printf("%+v", fieldToSet)
 		fmt.Println("!!!!!!!!!!!!id!!!!!!!!")
@@ -463,10 +471,10 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 		if j.isMany && id != "" {
 			if remainingRecursions == "Count" {
 				cnt, err := collectionQ.ToggleLogFlag(true).Filter(Q(j.joinForeignFieldName, id)).Count(y)
-				if serverSettings.WebConfig.Application.LogJoinQueries {
+				if serverSettings.GetWebConfig().Application.LogJoinQueries {
 					collectionQ.LogQuery("JoinEntity() Recursion Count Only err?->" + fmt.Sprintf("%+v", err) + " j->" + fmt.Sprintf("%+v", j))
 				}
-				if serverSettings.WebConfig.Application.LogJoinQueries && err != nil {
+				if serverSettings.GetWebConfig().Application.LogJoinQueries && err != nil {
 					fmt.Println("err Recursion Line 413->" + fmt.Sprintf("%+v", err))
 				}
 				if err != nil {
@@ -478,18 +486,18 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 				return err
 			}
 			err = collectionQ.ToggleLogFlag(true).Filter(Q(j.joinForeignFieldName, id)).All(y)
-			if serverSettings.WebConfig.Application.LogJoinQueries {
+			if serverSettings.GetWebConfig().Application.LogJoinQueries {
 				collectionQ.LogQuery("JoinEntity({" + j.joinForeignFieldName + ": " + id + "}) Recursion Many err?->" + fmt.Sprintf("%+v", err) + " j->" + fmt.Sprintf("%+v", j))
 			}
 		} else if id != "" {
 			if j.joinForeignFieldName == "" {
 				err = collectionQ.ToggleLogFlag(true).ById(id, y)
-				if serverSettings.WebConfig.Application.LogJoinQueries {
+				if serverSettings.GetWebConfig().Application.LogJoinQueries {
 					collectionQ.LogQuery("JoinEntity() Recursion Single By Id (" + id + ") err?->" + fmt.Sprintf("%+v", err) + " j->" + fmt.Sprintf("%+v", j))
 				}
 			} else {
 				err = collectionQ.ToggleLogFlag(true).Filter(Q(j.joinForeignFieldName, id)).One(y)
-				if serverSettings.WebConfig.Application.LogJoinQueries {
+				if serverSettings.GetWebConfig().Application.LogJoinQueries {
 					collectionQ.LogQuery("JoinEntity({" + j.joinForeignFieldName + ": " + id + "}) Recursion Single err?->" + fmt.Sprintf("%+v", err) + " j->" + fmt.Sprintf("%+v", j))
 				}
 			}
@@ -519,7 +527,7 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 					err = CallMethod(y, "JoinFields", in)
 				}
 			}
-			if err != nil && serverSettings.WebConfig.Application.LogJoinQueries {
+			if err != nil && serverSettings.GetWebConfig().Application.LogJoinQueries {
 				fmt.Println("err Recursion Line 465->" + fmt.Sprintf("%+v", err))
 			}
 			if err == nil {
@@ -553,7 +561,7 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 
 				if q.renderViews {
 					err = q.processViews(y)
-					if err != nil && serverSettings.WebConfig.Application.LogJoinQueries {
+					if err != nil && serverSettings.GetWebConfig().Application.LogJoinQueries {
 						collectionQ.LogQuery("err Recursion Line 479->" + fmt.Sprintf("%+v", err))
 					}
 					if err != nil {
@@ -563,7 +571,7 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 
 			}
 		} else {
-			if serverSettings.WebConfig.Application.LogJoinQueries && err != nil {
+			if serverSettings.GetWebConfig().Application.LogJoinQueries && err != nil {
 				fmt.Println("err Recursion Line 495->" + fmt.Sprintf("%+v", err))
 			}
 		}
@@ -578,14 +586,14 @@ func JoinEntity(collectionQ *Query, y interface{}, j join, id string, fieldToSet
 			values := method.Call(in)
 			if values[0].Interface() == nil {
 
-				if serverSettings.WebConfig.Application.LogJoinQueries {
+				if serverSettings.GetWebConfig().Application.LogJoinQueries {
 					collectionQ.LogQuery("Recursion returning due to nil values[0] interface")
 				}
 				err = nil
 				return
 			}
 			err = values[0].Interface().(error)
-			if err != nil && serverSettings.WebConfig.Application.LogJoinQueries {
+			if err != nil && serverSettings.GetWebConfig().Application.LogJoinQueries {
 				fmt.Println("err Recursion Line 503->" + fmt.Sprintf("%+v", err))
 			}
 		}
@@ -661,7 +669,7 @@ func BootstrapMongoDump(directoryName string, collectionName string) (err error)
 		return
 	}
 
-	dbName := serverSettings.WebConfig.DbConnection.Database
+	dbName := serverSettings.GetWebConfig().DbConnection.Database
 
 	var commandPath string
 	if runtime.GOOS == "linux" {
@@ -7,6 +7,7 @@ func init() {
 package model
 
 import (
+	"context"
 	"errors"
 	"log"
 	"reflect"
@@ -85,12 +86,14 @@ type Query struct {
 	sort        []string
 	entityName  string
 	collectionName  string
+	connection  string
 	e           error
 	joins       map[string]joinType
 	format      DataFormat
 	renderViews bool
 	whiteListed []QueryFieldFilter
 	blackListed []QueryFieldFilter
+	ctx         context.Context
 }
 
 type QueryIterator struct {
@@ -134,27 +137,31 @@ func (self *Query) ById(objectId interface{}, modelInstance interface{}) error {
 		return err
 	}
 
-	if serverSettings.WebConfig.Application.LogQueries {
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.ById("+objId.Hex()+")"))
 		}()
 	}
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.ById(" + objId.Hex() + ")")
 	}
 
 	foundCache := dbServices.CollectionCache{}.Fetch(self.collectionName, objId.Hex(), modelInstance)
 
 	if foundCache == false {
-		err =  dbServices.BoltDB.One("Id", objId, modelInstance)
+		err =  dbServices.ReadBoltDB(self.connection).One("Id", objId, modelInstance)
 	}
 
 
 	if err != nil {
 		// This callback is used for if the Ethernet port is unplugged
 		callback := func() error {
-			err = dbServices.BoltDB.One("Id", objId, modelInstance)
+			err = dbServices.ReadBoltDB(self.connection).One("Id", objId, modelInstance)
 			if err != nil {
 				return err
 			}
@@ -681,8 +688,21 @@ func (self *Query) Skip(val int) *Query {
 	return self
 }
 
+//WithContext stops the query from running once ctx is cancelled.
+func (self *Query) WithContext(ctx context.Context) *Query {
+	self.ctx = ctx
+	return self
+}
+
+func (self *Query) contextError() error {
+	if self.ctx == nil {
+		return nil
+	}
+	return self.ctx.Err()
+}
+
 func (self *Query) All(x interface{}) error {
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.All()"))
 		}()
@@ -691,9 +711,13 @@ func (self *Query) All(x interface{}) error {
 		return self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.All()")
 	}
 
@@ -715,7 +739,7 @@ func (self *Query) All(x interface{}) error {
 }
 
 func (self *Query) One(x interface{}) error {
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.One()"))
 		}()
@@ -724,9 +748,13 @@ func (self *Query) One(x interface{}) error {
 		return self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.One()")
 	}
 
@@ -818,7 +846,7 @@ func GetInterfaceSlice(obj interface{}) ([]interface{}, error) {
 
 
 func (self *Query) TotalRows() int {
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.TotalRows()"))
 		}()
@@ -829,7 +857,7 @@ func (self *Query) TotalRows() int {
 }
 
 func (self *Query) Count(x interface{}) (int, error) {
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.Count()"))
 		}()
@@ -838,6 +866,10 @@ func (self *Query) Count(x interface{}) (int, error) {
 		return 0, self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return 0, err
+	}
+
 	err := self.All(x)
 	if err != nil {
 		return 0, err
@@ -851,7 +883,7 @@ func (self *Query) Count(x interface{}) (int, error) {
 }
 
 func (self *Query) Distinct(key string, x interface{}) error {
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.Distinct()"))
 		}()
@@ -861,9 +893,13 @@ func (self *Query) Distinct(key string, x interface{}) error {
 		return self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.Distinct()")
 	}
 
@@ -1029,7 +1065,7 @@ func (self *Query) getJoins(x reflect.Value) (joins []join, err error) {
 			fieldName := fields[0]
 
 			typeField, ok := joinsField.Type().FieldByName(fieldName)
-			if serverSettings.WebConfig.Application.LogJoinQueries {
+			if serverSettings.GetWebConfig().Application.LogJoinQueries {
 				fmt.Println("getJoins fieldName")
 				fmt.Println(fieldName)
 			}
@@ -1069,7 +1105,7 @@ func (self *Query) getJoins(x reflect.Value) (joins []join, err error) {
 		}
 	}
 	if !hasJoins {
-		if serverSettings.WebConfig.Application.LogJoinQueries {
+		if serverSettings.GetWebConfig().Application.LogJoinQueries {
 			fmt.Println("Could not resolve a field  (getJoins query): " + " on " + x.Type().String() + " object")
 		}
 		//err = errors.New("Could not resolve a field  (getJoins query): " +  " on " + x.Type().String() + " object")
@@ -1409,7 +1445,7 @@ func (self *Query) substituteDateFormat(dateFormat string) string {
 }
 
 func (self *Query) LogQuery(functionName string) {
-	if serverSettings.WebConfig.Application.LogQueryStackTraces {
+	if serverSettings.GetWebConfig().Application.LogQueryStackTraces {
 		caller := stacktrace.Errorf("GoCore caller:")
 		core.Debug.Dump("Desc-> Called Function query.go#"+functionName, "Desc->Caller for Query:", caller.ErrorStack(), core.Debug.GetDump("Desc->Limit", self.limit, "Desc->Skip", self.skip, "Desc->Sort", self.sort, "Desc->Queryset", self.m, "Desc->Count", self.joins))
 	} else {
@@ -1437,7 +1473,7 @@ func (self *Query) handleQueryError(err error, callback queryError) error {
 		}
 	}
 
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		core.Debug.Dump("Desc->You got a mongo error!!!! ", err.Error())
 		self.LogQuery("handleQueryError")
 	}
@@ -1577,7 +1613,7 @@ func (self *Query) GenerateQuery() storm.Query {
 				}
 			}
 		}
-		q = dbServices.BoltDB.Select(querySet.Or(filters...))
+		q = dbServices.ReadBoltDB(self.connection).Select(querySet.Or(filters...))
 	} else if self.m != nil {
 		var filters []querySet.Matcher
 		if logInfo {
@@ -1685,7 +1721,7 @@ func (self *Query) GenerateQuery() storm.Query {
 		if logInfo {
 			log.Println("end of filter", filters)
 		}
-		q = dbServices.BoltDB.Select(filters...)
+		q = dbServices.ReadBoltDB(self.connection).Select(filters...)
 	} else if self.ao != nil {
 		if logInfo {
 			log.Println("aodave", fmt.Sprintf("%+v", self.ao))
@@ -1892,9 +1928,9 @@ func (self *Query) GenerateQuery() storm.Query {
 				log.Println("aodave-k", fmt.Sprintf("%+v", k))
 			}
 		}
-		q = dbServices.BoltDB.Select(querySet.And(filtersAll...))
+		q = dbServices.ReadBoltDB(self.connection).Select(querySet.And(filtersAll...))
 	} else {
-		q = dbServices.BoltDB.Select(querySet.True())
+		q = dbServices.ReadBoltDB(self.connection).Select(querySet.True())
 	}
 
 	if logInfo {
@@ -256,6 +256,11 @@ func (self *Transaction) Commit() error {
 
 }
 
+//Discard drops the changes queued on a Transaction that was never committed.  Use Rollback to undo a committed Transaction.
+func (self *Transaction) Discard() error {
+	return nil
+}
+
 func (self *Transaction) Rollback(userId string, reason string) error {
 
     /*
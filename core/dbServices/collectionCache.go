@@ -48,7 +48,7 @@ func (cc CollectionCache) Fetch(collection string, id string, value interface{})
 	if found {
 		cv, parsed := cvObj.(*CacheValue)
 		if parsed && cv != nil {
-			if serverSettings.WebConfig.DbConnection.Driver == DATABASE_DRIVER_BOLTDB {
+			if serverSettings.GetWebConfig().DbConnection.Driver == DATABASE_DRIVER_BOLTDB {
 				err := json.Unmarshal(cv.value, value)
 				if err == nil {
 					ok = true
@@ -81,7 +81,7 @@ func (cc CollectionCache) Store(collection string, id string, value interface{})
 
 	data := []byte{}
 
-	if serverSettings.WebConfig.DbConnection.Driver == DATABASE_DRIVER_BOLTDB {
+	if serverSettings.GetWebConfig().DbConnection.Driver == DATABASE_DRIVER_BOLTDB {
 		data, _ = json.Marshal(value)
 	} else {
 		data, _ = bson.Marshal(value)
@@ -0,0 +1,152 @@
+package dbServices
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+	"github.com/asdine/storm"
+	"github.com/fatih/color"
+	"github.com/globalsign/mgo"
+)
+
+//namedConnection holds the handles of a named dbConnections entry.  The default entry uses BoltDB, MongoSession and MongoDB.
+type namedConnection struct {
+	name         string
+	driver       string
+	boltDB       *storm.DB
+	mongoSession *mgo.Session
+	mongoDB      *mgo.Database
+}
+
+var namedConnections sync.Map
+
+//ReadMongoDBConnection returns the Mongo database of the dbConnections entry named name.  The default connection returns ReadMongoDB().
+func ReadMongoDBConnection(name string) (mdb *mgo.Database) {
+	if serverSettings.IsDefaultDbConnection(name) {
+		return ReadMongoDB()
+	}
+
+	value, ok := namedConnections.Load(name)
+	if !ok {
+		return
+	}
+	DBMutex.RLock()
+	mdb = value.(*namedConnection).mongoDB
+	DBMutex.RUnlock()
+	return
+}
+
+//ReadBoltDB returns the bolt database of the dbConnections entry named name.  The default connection returns BoltDB.
+func ReadBoltDB(name string) (db *storm.DB) {
+	DBMutex.RLock()
+	defer DBMutex.RUnlock()
+
+	if serverSettings.IsDefaultDbConnection(name) {
+		return BoltDB
+	}
+
+	value, ok := namedConnections.Load(name)
+	if !ok {
+		return
+	}
+	return value.(*namedConnection).boltDB
+}
+
+//openNamedConnections opens every dbConnections entry other than the default.
+func openNamedConnections() error {
+	for _, name := range serverSettings.GetDbConnectionNames() {
+		if _, ok := namedConnections.Load(name); ok {
+			continue
+		}
+		connection, _ := serverSettings.GetDbConnection(name)
+
+		named := &namedConnection{name: name, driver: connection.Driver}
+		var err error
+		switch connection.Driver {
+		case DATABASE_DRIVER_BOLTDB:
+			myDBDir := serverSettings.APP_LOCATION + "/db/" + connection.ConnectionString
+			os.Mkdir(path.Dir(myDBDir), 0777)
+			named.boltDB, err = storm.Open(myDBDir)
+		case DATABASE_DRIVER_MONGODB:
+			named.mongoSession, err = dialMongoConnection(connection.ConnectionString, connection.EnableTLS)
+			if err == nil {
+				named.mongoSession.SetMode(mgo.Monotonic, true)
+				named.mongoSession.SetSyncTimeout(2000 * time.Millisecond)
+				named.mongoDB = named.mongoSession.DB(connection.Database)
+			}
+		default:
+			err = errors.New("driver " + connection.Driver + " does not support named connections.")
+		}
+
+		if err != nil {
+			color.Red("Failed to open the " + name + " database connection:  " + err.Error())
+			return err
+		}
+		color.Green("Database connection " + name + " opened successfully.")
+		namedConnections.Store(name, named)
+	}
+	return nil
+}
+
+func dialMongoConnection(connectionString string, enableTLS bool) (*mgo.Session, error) {
+	dialInfo, err := mgo.ParseURL(connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	dialInfo.Timeout = 5 * time.Second
+	dialInfo.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+		if enableTLS {
+			return tls.Dial("tcp", addr.String(), &tls.Config{})
+		}
+		return net.Dial("tcp", addr.String())
+	}
+	return mgo.DialWithInfo(dialInfo)
+}
+
+//closeNamedConnections closes and forgets the handles opened by openNamedConnections.  DBMutex must be locked.
+func closeNamedConnections() (err error) {
+	namedConnections.Range(func(key interface{}, value interface{}) bool {
+		named := value.(*namedConnection)
+		if named.boltDB != nil {
+			errBolt := named.boltDB.Close()
+			if err == nil {
+				err = errBolt
+			}
+		}
+		if named.mongoSession != nil {
+			named.mongoSession.Close()
+		}
+		namedConnections.Delete(key)
+		return true
+	})
+	return
+}
+
+//pingNamedConnections returns an error when a named connection is not connected.  DBMutex must be read locked.
+func pingNamedConnections() (err error) {
+	namedConnections.Range(func(key interface{}, value interface{}) bool {
+		named := value.(*namedConnection)
+		if named.mongoSession != nil {
+			session := named.mongoSession.Copy()
+			session.SetSyncTimeout(pingTimeout)
+			session.SetSocketTimeout(pingTimeout)
+			err = session.Ping()
+			session.Close()
+		} else if named.driver == DATABASE_DRIVER_BOLTDB && named.boltDB == nil {
+			err = errors.New("BoltDB " + named.name + " is not open.")
+		}
+		if err != nil {
+			err = errors.New("Database connection " + named.name + ":  " + err.Error())
+			return false
+		}
+		return true
+	})
+	return
+}
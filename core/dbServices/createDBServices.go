@@ -121,9 +121,11 @@ type NOSQLSchema struct {
 	Fields []NOSQLSchemaField `json"fields"`
 }
 
+//NOSQLCollection is a collection of a schema file.  Connection names the dbConnections entry it lives on, the default when empty.
 type NOSQLCollection struct {
 	Name       string      `json:"name"`
 	ClearTable bool        `json:"clearTable"`
+	Connection string      `json:"connection"`
 	Schema     NOSQLSchema `json:"schema"`
 	FieldTypes map[string]FieldType
 }
@@ -161,7 +163,15 @@ type schemasCreatedSync struct {
 
 var allCollections collectionsSet
 
-var modelToWrite string
+//modelPackage is a generated model package.  Collections on the driver of the default connection are generated in models/{version}/model
+//and collections on a connection with another driver in models/{version}/{driver}/{connection}/model.
+type modelPackage struct {
+	dir          string
+	driver       string
+	connection   string
+	modelToWrite string
+	collections  map[string]bool
+}
 
 // AxisSorter sorts planets by axis.
 type SchemaNameSorter []NOSQLCollection
@@ -211,6 +221,9 @@ func RunDBCreate() {
 	// delete model files
 	fmt.Printf("RunDBCreate->Remove Model %v \n", modelPath)
 	os.RemoveAll(modelPath)
+	for _, driver := range []string{DATABASE_DRIVER_MONGODB, DATABASE_DRIVER_BOLTDB} {
+		os.RemoveAll(serverSettings.APP_LOCATION + "/models/" + versionDir + "/" + driver)
+	}
 
 	walkNoSQLSchema()
 }
@@ -263,12 +276,15 @@ func walkNoSQLSchema() {
 
 func walkNoSQLVersion(path string, versionDir string) {
 
-	initializeModelFile()
+	var packages []*modelPackage
 
 	var scs schemasCreatedSync
 
 	scs.schemasCreated = make(map[string]NOSQLSchema, 0)
 
+	//Clean the API Directory
+	extensions.RemoveDirectory(serverSettings.APP_LOCATION + "/webAPIs/" + versionDir + "/webAPI")
+
 	err := filepath.Walk(path, func(path string, f os.FileInfo, errWalk error) error {
 
 		if errWalk != nil {
@@ -291,13 +307,31 @@ func walkNoSQLVersion(path string, versionDir string) {
 				e = errUnmarshal
 			}
 
+			collectionsByPackage := make(map[*modelPackage][]NOSQLCollection)
+			var order []*modelPackage
 			for _, col := range schemaDB.Collections {
+				connection, driver, ok := resolveCollectionConnection(col)
+				if !ok {
+					color.Red("Collection " + col.Name + " in " + path + " is on connection " + col.Connection + " which is not in dbConnections of webConfig.json.")
+					continue
+				}
+				col.Connection = connection
+
+				pkg := getModelPackage(&packages, driver, connection)
+				if _, ok := collectionsByPackage[pkg]; !ok {
+					order = append(order, pkg)
+				}
+				collectionsByPackage[pkg] = append(collectionsByPackage[pkg], col)
+				pkg.collections[col.Name] = true
+
 				allCollections.Lock()
 				allCollections.Collections = append(allCollections.Collections, col)
 				allCollections.Unlock()
 			}
 
-			createNoSQLModel(schemaDB.Collections, serverSettings.WebConfig.DbConnection.Driver, versionDir, &scs)
+			for _, pkg := range order {
+				createNoSQLModel(collectionsByPackage[pkg], pkg, versionDir, &scs)
+			}
 		}
 
 		return e
@@ -306,32 +340,101 @@ func walkNoSQLVersion(path string, versionDir string) {
 		color.Red("Walk of path failed:  " + err.Error())
 	}
 
-	finalizeModelFile(versionDir)
+	for _, pkg := range packages {
+		finalizeModelFile(versionDir, pkg)
+	}
 }
 
-func createNoSQLModel(collections []NOSQLCollection, driver string, versionDir string, scs *schemasCreatedSync) {
+//resolveCollectionConnection returns the connection and driver of a collection.  The connection is "" for the default connection.
+func resolveCollectionConnection(collection NOSQLCollection) (connection string, driver string, ok bool) {
+	if serverSettings.IsDefaultDbConnection(collection.Connection) {
+		driver = serverSettings.GetWebConfig().DbConnection.Driver
+		ok = true
+		return
+	}
 
-	//Clean the Model and API Directory
-	// extensions.RemoveDirectory(serverSettings.APP_LOCATION + "/models/" + versionDir + "/model")
-	extensions.RemoveDirectory(serverSettings.APP_LOCATION + "/webAPIs/" + versionDir + "/webAPI")
+	dbConnection, ok := serverSettings.GetDbConnection(collection.Connection)
+	if !ok {
+		return
+	}
+	connection = collection.Connection
+	driver = dbConnection.Driver
+	return
+}
+
+//getModelPackage returns the package of the collections of a connection, adding it on first use.  Connections on the driver of the
+//default connection share the model package.  Any other connection has its own package so its package level stubs, ie transaction.go,
+//use its handles even when another connection has the same driver.
+func getModelPackage(packages *[]*modelPackage, driver string, connection string) *modelPackage {
+	defaultDriver := serverSettings.GetWebConfig().DbConnection.Driver
+
+	dir := "model"
+	if driver != defaultDriver {
+		dir = driver + "/" + connection + "/model"
+	}
+	for _, pkg := range *packages {
+		if pkg.dir == dir {
+			return pkg
+		}
+	}
+
+	pkg := &modelPackage{dir: dir, driver: driver, collections: make(map[string]bool)}
+	if dir != "model" {
+		pkg.connection = connection
+	}
+	pkg.modelToWrite = initializeModelFile(pkg)
+	*packages = append(*packages, pkg)
+	return pkg
+}
+
+//stub returns a package level stub reading the handles of the package connection.
+func (pkg *modelPackage) stub(value string) string {
+	if pkg.dir != "model" {
+		value = strings.Replace(value, "dbServices.RegisterTransactionQueue(\"model\"", "dbServices.RegisterTransactionQueue(\""+pkg.dir+"\"", -1)
+	}
+	if pkg.connection == "" {
+		return value
+	}
+	value = strings.Replace(value, "dbServices.ReadMongoDB()", "dbServices.ReadMongoDBConnection(\""+pkg.connection+"\")", -1)
+	return strings.Replace(value, "dbServices.BoltDB.", "dbServices.ReadBoltDB(\""+pkg.connection+"\").", -1)
+}
+
+//mongoHandle returns the generated expression reading the Mongo database of the connection of a collection.
+func mongoHandle(collection NOSQLCollection) string {
+	if collection.Connection == "" {
+		return "dbServices.ReadMongoDB()"
+	}
+	return "dbServices.ReadMongoDBConnection(\"" + collection.Connection + "\")"
+}
+
+//boltHandle returns the generated expression of the bolt database of the connection of a collection.
+func boltHandle(collection NOSQLCollection) string {
+	if collection.Connection == "" {
+		return "dbServices.BoltDB"
+	}
+	return "dbServices.ReadBoltDB(\"" + collection.Connection + "\")"
+}
+
+func createNoSQLModel(collections []NOSQLCollection, pkg *modelPackage, versionDir string, scs *schemasCreatedSync) {
+
+	driver := pkg.driver
+	modelPath := serverSettings.APP_LOCATION + "/models/" + versionDir + "/" + pkg.dir
 
 	//Create a NOSQLBucket Model
 	// bucket := generateNoSQLModelBucket(driver)
-	os.Mkdir(serverSettings.APP_LOCATION+"/models/", 0777)
-	os.Mkdir(serverSettings.APP_LOCATION+"/models/"+versionDir, 0777)
-	os.Mkdir(serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/", 0777)
+	os.MkdirAll(modelPath, 0777)
 
-	// writeNOSQLModelBucket(bucket, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/bucket.go")
+	// writeNOSQLModelBucket(bucket, modelPath+"/bucket.go")
 
 	//Copy Stub Files
 	if driver == DATABASE_DRIVER_MONGODB {
-		writeNoSQLStub(mongoStubs.Query, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/query.go")
+		writeNoSQLStub(pkg.stub(mongoStubs.Query), modelPath+"/query.go")
 	} else if driver == DATABASE_DRIVER_BOLTDB {
-		writeNoSQLStub(boltStubs.Query, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/query.go")
+		writeNoSQLStub(pkg.stub(boltStubs.Query), modelPath+"/query.go")
 	}
-	writeNoSQLStub(commonStubs.TimeZone, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/timeZone.go")
-	writeNoSQLStub(commonStubs.TimeZoneLocations, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/timeZoneLocations.go")
-	writeNoSQLStub(commonStubs.Locales, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/locales.go")
+	writeNoSQLStub(commonStubs.TimeZone, modelPath+"/timeZone.go")
+	writeNoSQLStub(commonStubs.TimeZoneLocations, modelPath+"/timeZoneLocations.go")
+	writeNoSQLStub(commonStubs.Locales, modelPath+"/locales.go")
 
 	var histTemplate []byte
 	if driver == DATABASE_DRIVER_MONGODB {
@@ -352,71 +455,86 @@ func createNoSQLModel(collections []NOSQLCollection, driver string, versionDir s
 	} else if driver == DATABASE_DRIVER_BOLTDB {
 		transactionTemplate = []byte(boltStubs.Transaction)
 	}
-	transactionModified := string(transactionTemplate[:])
+	transactionModified := pkg.stub(string(transactionTemplate[:]))
 
-	if serverSettings.WebConfig.DbConnection.TransactionSizeMax > 0 {
-		transactionModified = strings.Replace(transactionModified, "ci := mgo.CollectionInfo{ForceIdIndex: true}", "ci := mgo.CollectionInfo{ForceIdIndex: true, Capped:true, MaxBytes:"+extensions.IntToString(serverSettings.WebConfig.DbConnection.TransactionSizeMax)+"}\n", -1)
+	packageConnection, _ := serverSettings.GetDbConnection(pkg.connection)
+	if packageConnection.TransactionSizeMax > 0 {
+		transactionModified = strings.Replace(transactionModified, "ci := mgo.CollectionInfo{ForceIdIndex: true}", "ci := mgo.CollectionInfo{ForceIdIndex: true, Capped:true, MaxBytes:"+extensions.IntToString(packageConnection.TransactionSizeMax)+"}\n", -1)
 	}
 
-	writeNoSQLStub(transactionModified, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/transaction.go")
+	writeNoSQLStub(transactionModified, modelPath+"/transaction.go")
 
 	//Create the Collection Models
 	for _, collection := range collections {
 		val := generateNoSQLModel(collection.Schema, collection, driver, scs)
-		os.Mkdir(serverSettings.APP_LOCATION+"/models/", 0777)
-		os.Mkdir(serverSettings.APP_LOCATION+"/models/"+versionDir, 0777)
-		os.Mkdir(serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/", 0777)
-		writeNoSQLModelCollection(val, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/"+extensions.MakeFirstLowerCase(collection.Schema.Name)+".go", collection)
+		os.MkdirAll(modelPath, 0777)
+		writeNoSQLModelCollection(val, modelPath+"/"+extensions.MakeFirstLowerCase(collection.Schema.Name)+".go", collection)
 
 		if string(histTemplate) != "" {
 
 			//Create the Transaction History Table for the Collection
 			histName := strings.Title(collection.Name) + "History"
+			histDB := "dbServices.MongoDB"
+			if collection.Connection != "" {
+				histDB = mongoHandle(collection)
+			}
 			histModified := strings.Replace(string(histTemplate[:]), "HistCollection", histName, -1)
+			histModified = strings.Replace(histModified, "dbServices.ReadMongoDB()", mongoHandle(collection), -1)
 			histModified = strings.Replace(histModified, "//CollectionVariable", heredoc.Docf(`
 			collection%sMutex.Lock()
-			mongo%sCollection = dbServices.MongoDB.C("%s")
+			mongo%sCollection = %s.C("%s")
 			collection%sMutex.Unlock()
-			`, histName, histName, histName, histName), -1)
+			`, histName, histName, histDB, histName, histName), -1)
 			histModified = strings.Replace(histModified, "HistEntity", strings.Title(collection.Schema.Name)+"HistoryRecord", -1)
 			histModified = strings.Replace(histModified, "OriginalEntity", strings.Title(collection.Schema.Name), -1)
-			if serverSettings.WebConfig.DbConnection.AuditHistorySizeMax > 0 {
-				histModified = strings.Replace(histModified, "ci := mgo.CollectionInfo{ForceIdIndex: true}", "ci := mgo.CollectionInfo{ForceIdIndex: true, Capped:true, MaxBytes:"+extensions.IntToString(serverSettings.WebConfig.DbConnection.AuditHistorySizeMax)+"}\n", -1)
+			collectionConnection, _ := serverSettings.GetDbConnection(collection.Connection)
+			if collectionConnection.AuditHistorySizeMax > 0 {
+				histModified = strings.Replace(histModified, "ci := mgo.CollectionInfo{ForceIdIndex: true}", "ci := mgo.CollectionInfo{ForceIdIndex: true, Capped:true, MaxBytes:"+extensions.IntToString(collectionConnection.AuditHistorySizeMax)+"}\n", -1)
 			}
 
-			writeNoSQLStub(histModified, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/"+extensions.MakeFirstLowerCase(collection.Schema.Name)+"_Hist.go")
+			writeNoSQLStub(histModified, modelPath+"/"+extensions.MakeFirstLowerCase(collection.Schema.Name)+"_Hist.go")
 
 		}
 		os.Mkdir(serverSettings.APP_LOCATION+"/webAPIs/", 0777)
 		os.Mkdir(serverSettings.APP_LOCATION+"/webAPIs/"+versionDir, 0777)
 		os.Mkdir(serverSettings.APP_LOCATION+"/webAPIs/"+versionDir+"/webAPI/", 0777)
 
-		cWebAPI := genSchemaWebAPI(collection, collection.Schema, strings.Replace(serverSettings.APP_LOCATION, "src/", "", -1)+"/models/"+versionDir+"/model", driver, versionDir)
+		cWebAPI := genSchemaWebAPI(collection, collection.Schema, strings.Replace(serverSettings.APP_LOCATION, "src/", "", -1)+"/models/"+versionDir+"/"+pkg.dir, driver, versionDir)
 		writeNoSQLWebAPI(cWebAPI, serverSettings.APP_LOCATION+"/webAPIs/"+versionDir+"/webAPI/"+extensions.MakeFirstLowerCase(collection.Schema.Name)+".go", collection)
 	}
 
 }
 
-func initializeModelFile() {
-	if serverSettings.WebConfig.DbConnection.Driver == DATABASE_DRIVER_MONGODB {
-		modelToWrite = mongoStubs.Model
+func initializeModelFile(pkg *modelPackage) string {
+	modelToWrite := ""
+	if pkg.driver == DATABASE_DRIVER_MONGODB {
+		modelToWrite = pkg.stub(mongoStubs.Model)
 	} else {
-		modelToWrite = boltStubs.Model
+		modelToWrite = pkg.stub(boltStubs.Model)
 	}
 
 	modelToWrite += "\n"
 
+	return modelToWrite
 }
 
-func finalizeModelFile(versionDir string) {
+func finalizeModelFile(versionDir string, pkg *modelPackage) {
 	allCollections.RLock()
 	sort.Sort(SchemaNameSorter(allCollections.Collections))
+	var collections []NOSQLCollection
+	for _, collection := range allCollections.Collections {
+		if pkg.collections[collection.Name] {
+			collections = append(collections, collection)
+		}
+	}
 	allCollections.RUnlock()
 
+	modelToWrite := pkg.modelToWrite
+
 	modelToWrite += "// Each goCore application should probably call this once on server setup to iterate through all records in the system and re-save it so that new fields can be injected into the data and your javascript always will be able to access any record\n\n"
 	modelToWrite += "func UpdateAllRecordsToLatestSchema() {\n\n"
 
-	for _, collection := range allCollections.Collections {
+	for _, collection := range collections {
 		modelToWrite += "var " + collection.Schema.Name + " []" + strings.Title(collection.Schema.Name) + "\n"
 		modelToWrite += strings.Title(collection.Name) + ".Query().All(& " + collection.Schema.Name + ")\n"
 		modelToWrite += "for _, row := range " + collection.Schema.Name + " {\n"
@@ -429,11 +547,11 @@ func finalizeModelFile(versionDir string) {
 	modelToWrite += "func ResolveEntity(key string) modelEntity{\n\n"
 	modelToWrite += "switch key{\n"
 
-	for _, collection := range allCollections.Collections {
+	for _, collection := range collections {
 		modelToWrite += "case \"" + strings.Title(collection.Schema.Name) + "\":\n"
 		modelToWrite += " return &" + strings.Title(collection.Schema.Name) + "{}\n"
 
-		if serverSettings.WebConfig.DbConnection.Driver == DATABASE_DRIVER_MONGODB {
+		if pkg.driver == DATABASE_DRIVER_MONGODB {
 			modelToWrite += "case \"" + strings.Title(collection.Schema.Name) + "HistoryRecord\":\n"
 			modelToWrite += " return &" + strings.Title(collection.Schema.Name) + "HistoryRecord{}\n"
 		}
@@ -476,15 +594,15 @@ func finalizeModelFile(versionDir string) {
 
 	modelToWrite += "\n"
 	modelToWrite += "func ResolveCollection(key string) (collection, error){\n\n"
-	modelToWrite += " if serverSettings.WebConfig.Application.LogJoinQueries {\n"
+	modelToWrite += " if serverSettings.GetWebConfig().Application.LogJoinQueries {\n"
 	modelToWrite += "fmt.Println(key)\n"
 	modelToWrite += " }\n"
 
 	modelToWrite += "switch key{\n"
 
-	for _, collection := range allCollections.Collections {
+	for _, collection := range collections {
 		modelToWrite += "case \"" + strings.Title(collection.Name) + "\":\n"
-		modelToWrite += " if serverSettings.WebConfig.Application.LogJoinQueries {\n"
+		modelToWrite += " if serverSettings.GetWebConfig().Application.LogJoinQueries {\n"
 		modelToWrite += " fmt.Println(\"in case!! " + strings.Title(collection.Name) + "\")\n"
 		modelToWrite += " }\n"
 		modelToWrite += " return &model" + strings.Title(collection.Name) + "{}, nil\n"
@@ -497,10 +615,10 @@ func finalizeModelFile(versionDir string) {
 	modelToWrite += "\n"
 	modelToWrite += "func ResolveHistoryCollection(key string) modelCollection{\n\n"
 
-	if serverSettings.WebConfig.DbConnection.Driver == DATABASE_DRIVER_MONGODB {
+	if pkg.driver == DATABASE_DRIVER_MONGODB {
 		modelToWrite += "switch key{\n"
 
-		for _, collection := range allCollections.Collections {
+		for _, collection := range collections {
 			modelToWrite += "case \"" + strings.Title(collection.Name) + "History\":\n"
 			modelToWrite += " return &model" + strings.Title(collection.Name) + "History{}\n"
 		}
@@ -510,7 +628,7 @@ func finalizeModelFile(versionDir string) {
 	modelToWrite += "}\n\n"
 	modelToWrite += "func joinField(j join, id string, fieldToSet reflect.Value, remainingRecursions string, q *Query, endRecursion bool, recursionCount int) (err error) {\n\n"
 	modelToWrite += "c, err2 := ResolveCollection(j.collectionName)\n"
-	modelToWrite += " if serverSettings.WebConfig.Application.LogJoinQueries {\n"
+	modelToWrite += " if serverSettings.GetWebConfig().Application.LogJoinQueries {\n"
 	modelToWrite += "fmt.Println(\"joinFieldLogging\")\n"
 	modelToWrite += "fmt.Println(fmt.Sprintf(\"%+v\", j.collectionName))\n"
 	modelToWrite += "fmt.Println(\"c\")\n"
@@ -526,7 +644,7 @@ func finalizeModelFile(versionDir string) {
 
 	modelToWrite += "switch j.joinSchemaName{\n"
 
-	for _, collection := range allCollections.Collections {
+	for _, collection := range collections {
 		modelToWrite += "case \"" + strings.Title(collection.Schema.Name) + "\":\n"
 		modelToWrite += "var y " + strings.Title(collection.Schema.Name) + "\n"
 		modelToWrite += "if j.isMany {\n\n"
@@ -566,7 +684,7 @@ func finalizeModelFile(versionDir string) {
 
 	modelToWrite += ")\n\n"
 
-	writeNoSQLStub(modelToWrite, serverSettings.APP_LOCATION+"/models/"+versionDir+"/model/model.go")
+	writeNoSQLStub(modelToWrite, serverSettings.APP_LOCATION+"/models/"+versionDir+"/"+pkg.dir+"/model.go")
 }
 
 func generateNoSQLModel(schema NOSQLSchema, collection NOSQLCollection, driver string, scs *schemasCreatedSync) string {
@@ -698,9 +816,10 @@ func genNoSQLCollection(collection NOSQLCollection, schema NOSQLSchema, driver s
 		val += "var mongo" + strings.Title(collection.Name) + "Collection *mgo.Collection\n"
 		val += "func init(){\n"
 		val += "collection" + strings.Title(collection.Name) + "Mutex = &sync.RWMutex{}\n\n"
+		val += genNoSQLBootstrapCheck(collection)
 		val += "go func() {\n\n"
 		val += "for{\n"
-		val += "mdb := dbServices.ReadMongoDB()\n"
+		val += "mdb := " + mongoHandle(collection) + "\n"
 		val += "if mdb != nil {\n"
 		val += "init" + strings.Title(collection.Name) + "()\n"
 		val += "return\n"
@@ -712,7 +831,7 @@ func genNoSQLCollection(collection NOSQLCollection, schema NOSQLSchema, driver s
 
 		val += "func init" + strings.Title(collection.Name) + "(){\n"
 		val += "log.Println(\"Building Indexes for MongoDB collection " + collection.Name + ":\")\n"
-		val += "mdb := dbServices.ReadMongoDB()\n"
+		val += "mdb := " + mongoHandle(collection) + "\n"
 
 		val += "collection" + strings.Title(collection.Name) + "Mutex.Lock()\n"
 		val += "mongo" + strings.Title(collection.Name) + "Collection = mdb.C(\"" + collection.Name + "\")\n"
@@ -729,6 +848,7 @@ func genNoSQLCollection(collection NOSQLCollection, schema NOSQLSchema, driver s
 	} else if driver == DATABASE_DRIVER_BOLTDB {
 		val += "func init(){\n"
 		val += "collection" + strings.Title(collection.Name) + "Mutex = &sync.RWMutex{}\n\n"
+		val += genNoSQLBootstrapCheck(collection)
 		val += "//" + strings.Title(collection.Name) + ".Index()\n"
 		val += "go func(){ time.Sleep(time.Second * 5)\n" + strings.Title(collection.Name) + ".Bootstrap()}()\n"
 		val += "store.RegisterStore(" + strings.Title(collection.Name) + ")\n"
@@ -742,6 +862,17 @@ func genNoSQLCollection(collection NOSQLCollection, schema NOSQLSchema, driver s
 	return val
 }
 
+//Generates the registration of the bootstrapped flag reported by the readiness check.
+func genNoSQLBootstrapCheck(collection NOSQLCollection) string {
+	val := ""
+	val += "dbServices.RegisterBootstrapCheck(\"" + collection.Name + "\", func() bool {\n"
+	val += "collection" + strings.Title(collection.Name) + "Mutex.RLock()\n"
+	val += "defer collection" + strings.Title(collection.Name) + "Mutex.RUnlock()\n"
+	val += "return GoCore" + strings.Title(collection.Name) + "HasBootStrapped\n"
+	val += "})\n\n"
+	return val
+}
+
 func checkSchemaForDateTime(schema NOSQLSchema) bool {
 
 	for _, field := range schema.Fields {
@@ -1215,9 +1346,10 @@ func genNOSQLQuery(collection NOSQLCollection, schema NOSQLSchema, driver string
 				}
 				query.collectionName = "%s"
 				query.entityName = "%s"
+				query.connection = "%s"
 				return query
 			}
-		`, strings.Title(collection.Name), strings.Title(collection.Name), strings.Title(schema.Name))
+		`, strings.Title(collection.Name), strings.Title(collection.Name), strings.Title(schema.Name), collection.Connection)
 	}
 	return val
 }
@@ -1413,7 +1545,7 @@ func genNoSQLSchemaSave(collection NOSQLCollection, schema NOSQLSchema, driver s
 		val += "}\n"
 		val += "self.UpdateDate = t \n"
 		val += "dbServices.CollectionCache{}.Remove(\"" + strings.Title(collection.Name) + "\",self.Id.Hex())\n"
-		val += "err := dbServices.BoltDB.Save(self)\n"
+		val += "err := " + boltHandle(collection) + ".Save(self)\n"
 		val += "if err == nil{\n"
 		val += "pubsub.Publish(\"" + strings.Title(collection.Name) + ".Save\", self)\n"
 		val += "}\n"
@@ -1709,7 +1841,7 @@ func genNoSQLSchemaSingle(collection NOSQLCollection, schema NOSQLSchema, driver
 	val += "func (self model" + strings.Title(collection.Name) + ") Single(field string, value interface{}) (retObj " + strings.Title(schema.Name) + ",e error) {\n"
 	switch driver {
 	case DATABASE_DRIVER_BOLTDB:
-		val += "e = dbServices.BoltDB.One(field, value, &retObj)\n"
+		val += "e = " + boltHandle(collection) + ".One(field, value, &retObj)\n"
 		val += "return\n"
 	}
 	val += "}\n\n"
@@ -1723,7 +1855,7 @@ func genNoSQLSchemaSearch(collection NOSQLCollection, schema NOSQLSchema, driver
 	switch driver {
 	case DATABASE_DRIVER_BOLTDB:
 
-		val += "e = dbServices.BoltDB.Find(field, value, &retObj)\n"
+		val += "e = " + boltHandle(collection) + ".Find(field, value, &retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "return\n"
 	}
@@ -1734,22 +1866,22 @@ func genNoSQLSchemaSearch(collection NOSQLCollection, schema NOSQLSchema, driver
 	case DATABASE_DRIVER_BOLTDB:
 
 		val += "if limit == 0 && skip == 0{\n"
-		val += "	e = dbServices.BoltDB.Find(field, value, &retObj)\n"
+		val += "	e = " + boltHandle(collection) + ".Find(field, value, &retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0 && skip > 0{\n"
-		val += "	e = dbServices.BoltDB.Find(field, value, &retObj, storm.Limit(limit), storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".Find(field, value, &retObj, storm.Limit(limit), storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0{\n"
-		val += "	e = dbServices.BoltDB.Find(field, value, &retObj, storm.Limit(limit))\n"
+		val += "	e = " + boltHandle(collection) + ".Find(field, value, &retObj, storm.Limit(limit))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if skip > 0{\n"
-		val += "	e = dbServices.BoltDB.Find(field, value, &retObj, storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".Find(field, value, &retObj, storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
@@ -1766,7 +1898,7 @@ func genNoSQLSchemaAll(collection NOSQLCollection, schema NOSQLSchema, driver st
 	val += "func (obj model" + strings.Title(collection.Name) + ") All() (retObj []" + strings.Title(schema.Name) + ",e error) {\n"
 	switch driver {
 	case "boltDB":
-		val += "e = dbServices.BoltDB.All(&retObj)\n"
+		val += "e = " + boltHandle(collection) + ".All(&retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "return\n"
 	}
@@ -1777,22 +1909,22 @@ func genNoSQLSchemaAll(collection NOSQLCollection, schema NOSQLSchema, driver st
 	case DATABASE_DRIVER_BOLTDB:
 
 		val += "if limit == 0 && skip == 0{\n"
-		val += "	e = dbServices.BoltDB.All(&retObj)\n"
+		val += "	e = " + boltHandle(collection) + ".All(&retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0 && skip > 0{\n"
-		val += "	e = dbServices.BoltDB.All(&retObj, storm.Limit(limit), storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".All(&retObj, storm.Limit(limit), storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0{\n"
-		val += "	e = dbServices.BoltDB.All(&retObj, storm.Limit(limit))\n"
+		val += "	e = " + boltHandle(collection) + ".All(&retObj, storm.Limit(limit))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if skip > 0{\n"
-		val += "	e = dbServices.BoltDB.All(&retObj, storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".All(&retObj, storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
@@ -1809,7 +1941,7 @@ func genNoSQLSchemaAllByIndex(collection NOSQLCollection, schema NOSQLSchema, dr
 	val += "func (obj model" + strings.Title(collection.Name) + ") AllByIndex(index string) (retObj []" + strings.Title(schema.Name) + ",e error) {\n"
 	switch driver {
 	case DATABASE_DRIVER_BOLTDB:
-		val += "e = dbServices.BoltDB.AllByIndex(index, &retObj)\n"
+		val += "e = " + boltHandle(collection) + ".AllByIndex(index, &retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "return\n"
 
@@ -1821,22 +1953,22 @@ func genNoSQLSchemaAllByIndex(collection NOSQLCollection, schema NOSQLSchema, dr
 	case DATABASE_DRIVER_BOLTDB:
 
 		val += "if limit == 0 && skip == 0{\n"
-		val += "	e = dbServices.BoltDB.AllByIndex(index, &retObj)\n"
+		val += "	e = " + boltHandle(collection) + ".AllByIndex(index, &retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0 && skip > 0{\n"
-		val += "	e = dbServices.BoltDB.AllByIndex(index, &retObj, storm.Limit(limit), storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".AllByIndex(index, &retObj, storm.Limit(limit), storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0{\n"
-		val += "	e = dbServices.BoltDB.AllByIndex(index, &retObj, storm.Limit(limit))\n"
+		val += "	e = " + boltHandle(collection) + ".AllByIndex(index, &retObj, storm.Limit(limit))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if skip > 0{\n"
-		val += "	e = dbServices.BoltDB.AllByIndex(index, &retObj, storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".AllByIndex(index, &retObj, storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
@@ -1854,7 +1986,7 @@ func genNoSQLSchemaRange(collection NOSQLCollection, schema NOSQLSchema, driver
 	switch driver {
 	case DATABASE_DRIVER_BOLTDB:
 
-		val += "e = dbServices.BoltDB.Range(field, min, max, &retObj)\n"
+		val += "e = " + boltHandle(collection) + ".Range(field, min, max, &retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "return\n"
 	}
@@ -1865,22 +1997,22 @@ func genNoSQLSchemaRange(collection NOSQLCollection, schema NOSQLSchema, driver
 	case DATABASE_DRIVER_BOLTDB:
 
 		val += "if limit == 0 && skip == 0{\n"
-		val += "	e = dbServices.BoltDB.Range(field, min, max, &retObj)\n"
+		val += "	e = " + boltHandle(collection) + ".Range(field, min, max, &retObj)\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0 && skip > 0{\n"
-		val += "	e = dbServices.BoltDB.Range(field, min, max, &retObj, storm.Limit(limit), storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".Range(field, min, max, &retObj, storm.Limit(limit), storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if limit > 0{\n"
-		val += "	e = dbServices.BoltDB.Range(field, min, max, &retObj, storm.Limit(limit))\n"
+		val += "	e = " + boltHandle(collection) + ".Range(field, min, max, &retObj, storm.Limit(limit))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
 		val += "if skip > 0{\n"
-		val += "	e = dbServices.BoltDB.Range(field, min, max, &retObj, storm.Skip(skip))\n"
+		val += "	e = " + boltHandle(collection) + ".Range(field, min, max, &retObj, storm.Skip(skip))\n"
 		val += genNoSQLSchemaArrayCheck(schema)
 		val += "	return\n"
 		val += "}\n"
@@ -1925,7 +2057,7 @@ func genNoSQLSchemaIndex(collection NOSQLCollection, schema NOSQLSchema, driver
 	val += "func (obj model" + strings.Title(collection.Name) + ") Index() error {\n"
 	switch driver {
 	case DATABASE_DRIVER_BOLTDB:
-		val += "return dbServices.BoltDB.Init(&" + strings.Title(schema.Name) + "{})\n"
+		val += "return " + boltHandle(collection) + ".Init(&" + strings.Title(schema.Name) + "{})\n"
 	case DATABASE_DRIVER_MONGODB:
 
 		val += "for key, value := range dbServices.GetDBIndexes(" + strings.Title(schema.Name) + "{}) {\n"
@@ -1973,7 +2105,7 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 	val += "defer func() {\n"
 	val += "log.Println(logger.TimeTrack(start, \"Bootstraping of " + strings.Title(collection.Name) + " Took\"))\n"
 	val += "}()\n"
-	val += "if serverSettings.WebConfig.Application.BootstrapData == false {\n"
+	val += "if serverSettings.GetWebConfig().Application.BootstrapData == false {\n"
 	val += "	obj.BootStrapComplete()\n"
 	val += "	return nil\n"
 	val += "}\n\n"
@@ -2029,7 +2161,7 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 		var files [][]byte
 		var err error
 		var distDirectoryFound bool
-		err = fileCache.LoadCachedBootStrapFromKeyIntoMemory(serverSettings.WebConfig.Application.ProductName + "%s")
+		err = fileCache.LoadCachedBootStrapFromKeyIntoMemory(serverSettings.GetWebConfig().Application.ProductName + "%s")
 		if err != nil {
 			obj.BootStrapComplete()
 			log.Println("Failed to bootstrap data for %s due to caching issue: " + err.Error())
@@ -2059,7 +2191,7 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 			hash := md5.Sum(file)
 			hexString := hex.EncodeToString(hash[:])
 			err = json.Unmarshal(file, &fileBootstrap)
-			if !fileCache.DoesHashExistInCache(serverSettings.WebConfig.Application.ProductName + "%s", hexString) || cnt == 0 {
+			if !fileCache.DoesHashExistInCache(serverSettings.GetWebConfig().Application.ProductName + "%s", hexString) || cnt == 0 {
 				if err != nil {
 
 					logger.Message("Failed to bootstrap data for %s: " + err.Error(), logger.RED)
@@ -2067,7 +2199,7 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 					continue
 				}
 
-				fileCache.UpdateBootStrapMemoryCache(serverSettings.WebConfig.Application.ProductName + "%s", hexString)
+				fileCache.UpdateBootStrapMemoryCache(serverSettings.GetWebConfig().Application.ProductName + "%s", hexString)
 
 				for i, _ := range fileBootstrap {
 					fb := fileBootstrap[i]
@@ -2075,7 +2207,7 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 				}
 			}
 		}
-		fileCache.WriteBootStrapCacheFile(serverSettings.WebConfig.Application.ProductName + "%s")
+		fileCache.WriteBootStrapCacheFile(serverSettings.GetWebConfig().Application.ProductName + "%s")
 
 `, strings.Title(collection.Name), strings.Title(collection.Name), extensions.MakeFirstLowerCase(collection.Name), strings.Title(collection.Name), strings.Title(collection.Name), strings.Title(schema.Name), strings.Title(schema.Name), strings.Title(collection.Name), strings.Title(collection.Name), strings.Title(collection.Name), strings.Title(collection.Name), strings.Title(collection.Name))
 	val += heredoc.Docf(`
@@ -2101,27 +2233,27 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 					var reason map[string]bool
 					reason = make(map[string]bool, 0)
 
-					if doc.BootstrapMeta != nil && doc.BootstrapMeta.Version > 0 && doc.BootstrapMeta.Version <= serverSettings.WebConfig.Application.VersionNumeric {
+					if doc.BootstrapMeta != nil && doc.BootstrapMeta.Version > 0 && doc.BootstrapMeta.Version <= serverSettings.GetWebConfig().Application.VersionNumeric {
 						valid &= 0x00
 						reason["Version Mismatch"] = true
 					}
-					if doc.BootstrapMeta != nil && doc.BootstrapMeta.Domain != "" && doc.BootstrapMeta.Domain != serverSettings.WebConfig.Application.ServerFQDN {
+					if doc.BootstrapMeta != nil && doc.BootstrapMeta.Domain != "" && doc.BootstrapMeta.Domain != serverSettings.GetWebConfig().Application.ServerFQDN {
 						valid &= 0x00
 						reason["FQDN Mismatch With Domain"] = true
 					}
-					if doc.BootstrapMeta != nil && len(doc.BootstrapMeta.Domains) > 0 && !utils.InArray(serverSettings.WebConfig.Application.ServerFQDN, doc.BootstrapMeta.Domains) {
+					if doc.BootstrapMeta != nil && len(doc.BootstrapMeta.Domains) > 0 && !utils.InArray(serverSettings.GetWebConfig().Application.ServerFQDN, doc.BootstrapMeta.Domains) {
 						valid &= 0x00
 						reason["FQDN Mismatch With Domains"] = true
 					}
-					if doc.BootstrapMeta != nil && doc.BootstrapMeta.ProductName != "" && doc.BootstrapMeta.ProductName != serverSettings.WebConfig.Application.ProductName {
+					if doc.BootstrapMeta != nil && doc.BootstrapMeta.ProductName != "" && doc.BootstrapMeta.ProductName != serverSettings.GetWebConfig().Application.ProductName {
 						valid &= 0x00
 						reason["ProductName does not Match"] = true
 					}
-					if doc.BootstrapMeta != nil && len(doc.BootstrapMeta.ProductNames) > 0 &&  !utils.InArray(serverSettings.WebConfig.Application.ProductName, doc.BootstrapMeta.ProductNames) {
+					if doc.BootstrapMeta != nil && len(doc.BootstrapMeta.ProductNames) > 0 &&  !utils.InArray(serverSettings.GetWebConfig().Application.ProductName, doc.BootstrapMeta.ProductNames) {
 						valid &= 0x00
 						reason["ProductNames does not Match Product"] = true
 					}
-					if doc.BootstrapMeta != nil && doc.BootstrapMeta.ReleaseMode != "" && doc.BootstrapMeta.ReleaseMode != serverSettings.WebConfig.Application.ReleaseMode {
+					if doc.BootstrapMeta != nil && doc.BootstrapMeta.ReleaseMode != "" && doc.BootstrapMeta.ReleaseMode != serverSettings.GetWebConfig().Application.ReleaseMode {
 						valid &= 0x00
 						reason["ReleaseMode does not match"] = true
 					}
@@ -2133,7 +2265,7 @@ func genNoSQLBootstrap(collection NOSQLCollection, schema NOSQLSchema, driver st
 							log.Println("Failed to bootstrap data for %s:  " + doc.Id.Hex() + "  " + err.Error())
 							isError = true
 						}
-					} else if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+					} else if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 						log.Println("%s skipped a row for some reason on " + doc.Id.Hex() + " because of " +  core.Debug.GetDump(reason))
 					}
 				}
@@ -2169,7 +2301,7 @@ func genNoSQLSchemaRunTransaction(collection NOSQLCollection, schema NOSQLSchema
 	switch driver {
 	case DATABASE_DRIVER_BOLTDB:
 
-		val += "tx, err := dbServices.BoltDB.Begin(true)\n\n"
+		val += "tx, err := " + boltHandle(collection) + ".Begin(true)\n\n"
 
 		val += "for _, object := range objects {\n"
 		val += "	err = tx.Save(&object)\n"
@@ -2205,7 +2337,7 @@ func genNoSQLSchemaSetKeyValue(collection NOSQLCollection, schema NOSQLSchema, d
 	switch driver {
 	case "boltDB":
 		{
-			val += "return dbServices.BoltDB.Init(&" + strings.Title(schema.Name) + "{})\n"
+			val += "return " + boltHandle(collection) + ".Init(&" + strings.Title(schema.Name) + "{})\n"
 		}
 	}
 	val += "}\n\n"
@@ -2219,7 +2351,7 @@ func genNoSQLSchemaDelete(collection NOSQLCollection, schema NOSQLSchema, driver
 	switch driver {
 	case "boltDB":
 		val += "dbServices.CollectionCache{}.Remove(\"" + strings.Title(collection.Name) + "\",self.Id.Hex())\n"
-		val += "err := dbServices.BoltDB.Delete(\"" + strings.Title(schema.Name) + "\", self.Id.Hex())\n"
+		val += "err := " + boltHandle(collection) + ".Delete(\"" + strings.Title(schema.Name) + "\", self.Id.Hex())\n"
 		val += "if err == nil{\n"
 		val += "pubsub.Publish(\"" + strings.Title(collection.Name) + ".Delete\", self)\n"
 		val += "}\n"
@@ -2243,7 +2375,7 @@ func genNoSQLSchemaDeleteWithTran(collection NOSQLCollection, schema NOSQLSchema
 	switch driver {
 	case "boltDB":
 		val += "dbServices.CollectionCache{}.Remove(\"" + strings.Title(collection.Name) + "\",self.Id.Hex())\n"
-		val += "return dbServices.BoltDB.Delete(\"" + strings.Title(collection.Name) + "\", self.Id.Hex())\n"
+		val += "return " + boltHandle(collection) + ".Delete(\"" + strings.Title(collection.Name) + "\", self.Id.Hex())\n"
 	case "mongoDB":
 		val += "transactionQueue.Lock()\n"
 		val += "defer func() {\n"
@@ -2325,7 +2457,7 @@ func genNoSQLSchemaJoinFields(collection NOSQLCollection, schema NOSQLSchema, dr
 	val += "	joinsField := s.FieldByName(\"Joins\")\n"
 	val += "	setField := joinsField.FieldByName(j.joinFieldName)\n\n"
 	val += " endRecursion := false\n"
-	val += " if serverSettings.WebConfig.Application.LogJoinQueries {\n"
+	val += " if serverSettings.GetWebConfig().Application.LogJoinQueries {\n"
 	val += " fmt.Print(\"Remaining Recursions\")\n"
 	val += " fmt.Println(fmt.Sprintf(\"%+v\", remainingRecursions))\n"
 	val += " fmt.Println(fmt.Sprintf(\"%+v\", j.collectionName))\n"
@@ -51,23 +51,51 @@ func ReadMongoDB() (mdb *mgo.Database) {
 	return mdb
 }
 
+//Close closes the SQL, Bolt and Mongo handles opened by Initialize, including the named connections.
+func Close() (err error) {
+	DBMutex.Lock()
+	defer DBMutex.Unlock()
+
+	if DB != nil {
+		err = DB.Close()
+	}
+	if BoltDB != nil {
+		errBolt := BoltDB.Close()
+		if err == nil {
+			err = errBolt
+		}
+	}
+	if MongoSession != nil {
+		MongoSession.Close()
+	}
+	errNamed := closeNamedConnections()
+	if err == nil {
+		err = errNamed
+	}
+	return
+}
+
 func Initialize() error {
 
 	fmt.Println("core dbServices initialized.")
 
-	switch serverSettings.WebConfig.DbConnection.Driver {
+	var err error
+	switch serverSettings.GetWebConfig().DbConnection.Driver {
 	case DATABASE_DRIVER_BOLTDB:
-		return openBolt()
+		err = openBolt()
 	case DATABASE_DRIVER_MONGODB:
-		return openMongo()
+		err = openMongo()
 	}
-	return nil
+	if err != nil {
+		return err
+	}
+	return openNamedConnections()
 }
 
 func openSQLDriver() error {
 	var err error
 	DBMutex.Lock()
-	DB, err = sql.Open(serverSettings.WebConfig.DbConnection.Driver, serverSettings.WebConfig.DbConnection.ConnectionString)
+	DB, err = sql.Open(serverSettings.GetWebConfig().DbConnection.Driver, serverSettings.GetWebConfig().DbConnection.ConnectionString)
 	DBMutex.Unlock()
 
 	if err != nil {
@@ -83,7 +111,7 @@ func openSQLDriver() error {
 
 func openBolt() error {
 
-	myDBDir := serverSettings.APP_LOCATION + "/db/" + serverSettings.WebConfig.DbConnection.ConnectionString
+	myDBDir := serverSettings.APP_LOCATION + "/db/" + serverSettings.GetWebConfig().DbConnection.ConnectionString
 
 	os.Mkdir(path.Dir(myDBDir), 0777)
 
@@ -105,13 +133,13 @@ func openBolt() error {
 
 func openMongo() error {
 
-	if serverSettings.WebConfig.DbConnection.Replication.Enabled {
+	if serverSettings.GetWebConfig().DbConnection.Replication.Enabled {
 		info := new(mgo.DialInfo)
 		info.Direct = true
 		info.Timeout = time.Millisecond * 3000
 
 		var addresses []string
-		addresses = append(addresses, serverSettings.WebConfig.DbConnection.Replication.Master)
+		addresses = append(addresses, serverSettings.GetWebConfig().DbConnection.Replication.Master)
 		// for i, _ := range serverSettings.WebConfig.DbConnection.Replication.Slaves {
 		// 	slave := serverSettings.WebConfig.DbConnection.Replication.Slaves[i]
 		// 	addresses = append(addresses, slave)
@@ -121,7 +149,7 @@ func openMongo() error {
 		session, err := mgo.DialWithInfo(info)
 		// session, err := mgo.Dial(serverSettings.WebConfig.DbConnection.Replication.SessionConnection)
 		if err != nil { //  if you have a
-			color.Red("Failed to create or open mongo Database to initialize replicaSet at " + serverSettings.WebConfig.DbConnection.Replication.Master + "\n\t" + err.Error())
+			color.Red("Failed to create or open mongo Database to initialize replicaSet at " + serverSettings.GetWebConfig().DbConnection.Replication.Master + "\n\t" + err.Error())
 		} else {
 			time.Sleep(time.Millisecond * 500)
 			result := Mongo_Result_Repl_Conf{}
@@ -131,12 +159,13 @@ func openMongo() error {
 			} else {
 
 				result.Config.Version = result.Config.Version + 1
-				result.Config.Members[0].Host = serverSettings.WebConfig.DbConnection.Replication.Master
+				result.Config.Members[0].Host = serverSettings.GetWebConfig().DbConnection.Replication.Master
 				result.Config.Members[0].Priority = 1
 				result.Config.Members[0].Votes = 1
 
-				for i, _ := range serverSettings.WebConfig.DbConnection.Replication.Slaves {
-					slaveAddress := serverSettings.WebConfig.DbConnection.Replication.Slaves[i]
+				slaves := serverSettings.GetWebConfig().DbConnection.Replication.Slaves
+				for i, _ := range slaves {
+					slaveAddress := slaves[i]
 					if len(result.Config.Members) < i+2 {
 						var slave Mongo_Replica_Member
 						slave.Id = i + 1
@@ -163,7 +192,7 @@ func openMongo() error {
 	}
 
 	var err error
-	connectionString := serverSettings.WebConfig.DbConnection.ConnectionString
+	connectionString := serverSettings.GetWebConfig().DbConnection.ConnectionString
 	if mongoDBOverride != "" {
 		connectionString = mongoDBOverride
 	}
@@ -181,7 +210,7 @@ func openMongo() error {
 	}
 
 	dialInfo.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
-		if serverSettings.WebConfig.DbConnection.EnableTLS || mgoTLSEnabled == "1" {
+		if serverSettings.GetWebConfig().DbConnection.EnableTLS || mgoTLSEnabled == "1" {
 			tlsConfig := &tls.Config{}
 			conn, err := tls.Dial("tcp", addr.String(), tlsConfig)
 			if err != nil {
@@ -228,7 +257,7 @@ func connectMongoDB() error {
 	MongoSession.SetMode(mgo.Monotonic, true) // Optional. Switch the session to a monotonic behavior.
 	MongoSession.SetSyncTimeout(2000 * time.Millisecond)
 
-	dbName := serverSettings.WebConfig.DbConnection.Database
+	dbName := serverSettings.GetWebConfig().DbConnection.Database
 	if mongoDBNameOverride != "" {
 		dbName = mongoDBNameOverride
 	}
@@ -0,0 +1,96 @@
+package dbServices
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/serverSettings"
+)
+
+const pingTimeout = 2 * time.Second
+
+var bootstrapChecks sync.Map
+var transactionQueues sync.Map
+
+//RegisterBootstrapCheck is called by the generated model of each collection with a func returning its GoCore<Name>HasBootStrapped flag.
+func RegisterBootstrapCheck(collection string, bootstrapped func() bool) {
+	bootstrapChecks.Store(collection, bootstrapped)
+}
+
+//GetBootstrapStatus returns the bootstrapped flag of every registered collection.
+func GetBootstrapStatus() (status map[string]bool) {
+	status = make(map[string]bool)
+	bootstrapChecks.Range(func(key interface{}, value interface{}) bool {
+		status[key.(string)] = value.(func() bool)()
+		return true
+	})
+	return
+}
+
+//GetPendingBootstraps returns the sorted names of the collections that have not finished bootstrapping.
+func GetPendingBootstraps() (collections []string) {
+	for collection, bootstrapped := range GetBootstrapStatus() {
+		if !bootstrapped {
+			collections = append(collections, collection)
+		}
+	}
+	sort.Strings(collections)
+	return
+}
+
+//RegisterTransactionQueue is called by the generated model package with a func returning the length of its transactionQueue.
+func RegisterTransactionQueue(name string, pending func() int) {
+	transactionQueues.Store(name, pending)
+}
+
+//PendingTransactions returns the number of transactions that are neither committed nor rolled back.
+func PendingTransactions() (count int) {
+	transactionQueues.Range(func(key interface{}, value interface{}) bool {
+		count += value.(func() int)()
+		return true
+	})
+	return
+}
+
+//Ping returns an error when the default or a named database connection is not connected.  It returns nil when no driver is configured.
+func Ping() error {
+	driver := serverSettings.GetWebConfig().DbConnection.Driver
+
+	DBMutex.RLock()
+	defer DBMutex.RUnlock()
+
+	err := pingDefaultConnection(driver)
+	if err != nil {
+		return err
+	}
+	return pingNamedConnections()
+}
+
+//pingDefaultConnection pings the handles of the default connection.  DBMutex must be read locked.
+func pingDefaultConnection(driver string) error {
+	switch driver {
+	case "":
+		return nil
+	case DATABASE_DRIVER_BOLTDB:
+		if BoltDB == nil {
+			return errors.New("BoltDB is not open.")
+		}
+		return nil
+	case DATABASE_DRIVER_MONGODB:
+		if MongoSession == nil {
+			return errors.New("MongoDB is not connected.")
+		}
+		session := MongoSession.Copy()
+		defer session.Close()
+		session.SetSyncTimeout(pingTimeout)
+		session.SetSocketTimeout(pingTimeout)
+		return session.Ping()
+	}
+
+	if DB == nil {
+		return errors.New(driver + " is not connected.")
+	}
+	return DB.Ping()
+}
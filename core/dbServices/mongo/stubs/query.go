@@ -7,6 +7,7 @@ func init() {
 package model
 
 import (
+	"context"
 	"errors"
 	"log"
 	"reflect"
@@ -75,22 +76,23 @@ type view struct {
 }
 
 type Query struct {
-	q           *mgo.Query
-	m           bson.M
-	o           []bson.M
-	ao          map[string][]map[string][]bson.M
-	stopLog     bool
-	limit       int
-	skip        int
-	sort        []string
-	collection  *mgo.Collection
-	entityName  string
-	e           error
-	joins       map[string]joinType
-	format      DataFormat
-	renderViews bool
-	whiteListed []QueryFieldFilter
-	blackListed []QueryFieldFilter
+	q              *mgo.Query
+	m              bson.M
+	o              []bson.M
+	ao             map[string][]map[string][]bson.M
+	stopLog        bool
+	limit          int
+	skip           int
+	sort           []string
+	collection     *mgo.Collection
+	entityName     string
+	e              error
+	joins          map[string]joinType
+	format         DataFormat
+	renderViews    bool
+	whiteListed    []QueryFieldFilter
+	blackListed    []QueryFieldFilter
+	ctx            context.Context
 	filteredFields bool
 }
 
@@ -162,17 +164,21 @@ func (self *Query) ById(objectId interface{}, modelInstance interface{}) error {
 		return err
 	}
 
-	if !serverSettings.WebConfig.Application.LogQueries && serverSettings.WebConfig.Application.LogQueryTimes {
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
+	if !serverSettings.GetWebConfig().Application.LogQueries && serverSettings.GetWebConfig().Application.LogQueryTimes {
 		defer func() {
 			log.Println(logger.TimeTrackQuery(time.Now(), "q.ById("+objId.Hex()+")", self.collection, self.m, self.q))
 		}()
-	} else if serverSettings.WebConfig.Application.LogQueries {
+	} else if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.ById("+objId.Hex()+")"))
 		}()
 	}
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.ById(" + objId.Hex() + ")")
 	}
 	q := self.collection.FindId(objId)
@@ -818,12 +824,25 @@ func (self *Query) Skip(val int) *Query {
 	return self
 }
 
+//WithContext stops the query from running once ctx is cancelled.  Deadlines are also passed to mongo as the max query time.
+func (self *Query) WithContext(ctx context.Context) *Query {
+	self.ctx = ctx
+	return self
+}
+
+func (self *Query) contextError() error {
+	if self.ctx == nil {
+		return nil
+	}
+	return self.ctx.Err()
+}
+
 func (self *Query) All(x interface{}) error {
-	if !serverSettings.WebConfig.Application.LogQueries && serverSettings.WebConfig.Application.LogQueryTimes {
+	if !serverSettings.GetWebConfig().Application.LogQueries && serverSettings.GetWebConfig().Application.LogQueryTimes {
 		defer func() {
 			log.Println(logger.TimeTrackQuery(time.Now(), "q.All()", self.collection, self.m, self.q))
 		}()
-	} else if serverSettings.WebConfig.Application.LogQueries {
+	} else if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.All()"))
 		}()
@@ -832,9 +851,13 @@ func (self *Query) All(x interface{}) error {
 		return self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.All()")
 	}
 
@@ -856,11 +879,11 @@ func (self *Query) All(x interface{}) error {
 }
 
 func (self *Query) One(x interface{}) error {
-	if !serverSettings.WebConfig.Application.LogQueries && serverSettings.WebConfig.Application.LogQueryTimes {
+	if !serverSettings.GetWebConfig().Application.LogQueries && serverSettings.GetWebConfig().Application.LogQueryTimes {
 		defer func() {
 			log.Println(logger.TimeTrackQuery(time.Now(), "q.One()", self.collection, self.m, self.q))
 		}()
-	} else if serverSettings.WebConfig.Application.LogQueries {
+	} else if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.One()"))
 		}()
@@ -869,9 +892,13 @@ func (self *Query) One(x interface{}) error {
 		return self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.One()")
 	}
 
@@ -953,11 +980,11 @@ func (self *Query) GetOrCreate(x interface{}, t *Transaction) (err error) {
 }
 
 func (self *Query) TotalRows() int {
-	if !serverSettings.WebConfig.Application.LogQueries && serverSettings.WebConfig.Application.LogQueryTimes {
+	if !serverSettings.GetWebConfig().Application.LogQueries && serverSettings.GetWebConfig().Application.LogQueryTimes {
 		defer func() {
 			log.Println(logger.TimeTrackQuery(time.Now(), "q.TotalRows()", self.collection, self.m, self.q))
 		}()
-	} else if serverSettings.WebConfig.Application.LogQueries {
+	} else if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.TotalRows()"))
 		}()
@@ -965,7 +992,7 @@ func (self *Query) TotalRows() int {
 
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.Length()")
 	}
 	count, _ := q.Count()
@@ -974,11 +1001,11 @@ func (self *Query) TotalRows() int {
 }
 
 func (self *Query) Count(x interface{}) (int, error) {
-	if !serverSettings.WebConfig.Application.LogQueries && serverSettings.WebConfig.Application.LogQueryTimes {
+	if !serverSettings.GetWebConfig().Application.LogQueries && serverSettings.GetWebConfig().Application.LogQueryTimes {
 		defer func() {
 			log.Println(logger.TimeTrackQuery(time.Now(), "q.Count()", self.collection, self.m, self.q))
 		}()
-	} else if serverSettings.WebConfig.Application.LogQueries {
+	} else if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.Count()"))
 		}()
@@ -987,6 +1014,10 @@ func (self *Query) Count(x interface{}) (int, error) {
 		return 0, self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return 0, err
+	}
+
 	q := self.GenerateQuery()
 
 	count, err := q.Count()
@@ -1005,11 +1036,11 @@ func (self *Query) Count(x interface{}) (int, error) {
 }
 
 func (self *Query) Distinct(key string, x interface{}) error {
-	if !serverSettings.WebConfig.Application.LogQueries && serverSettings.WebConfig.Application.LogQueryTimes {
+	if !serverSettings.GetWebConfig().Application.LogQueries && serverSettings.GetWebConfig().Application.LogQueryTimes {
 		defer func() {
 			log.Println(logger.TimeTrackQuery(time.Now(), "q.Distinct()", self.collection, self.m, self.q))
 		}()
-	} else if serverSettings.WebConfig.Application.LogQueries {
+	} else if serverSettings.GetWebConfig().Application.LogQueries {
 		defer func() {
 			log.Println(logger.TimeTrack(time.Now(), "q.Distinct()"))
 		}()
@@ -1019,9 +1050,13 @@ func (self *Query) Distinct(key string, x interface{}) error {
 		return self.e
 	}
 
+	if err := self.contextError(); err != nil {
+		return err
+	}
+
 	q := self.GenerateQuery()
 
-	if !self.stopLog && serverSettings.WebConfig.Application.LogQueries {
+	if !self.stopLog && serverSettings.GetWebConfig().Application.LogQueries {
 		self.LogQuery("q.Distinct()")
 	}
 
@@ -1187,7 +1222,7 @@ func (self *Query) getJoins(x reflect.Value) (joins []join, err error) {
 			fieldName := fields[0]
 
 			typeField, ok := joinsField.Type().FieldByName(fieldName)
-			if serverSettings.WebConfig.Application.LogJoinQueries {
+			if serverSettings.GetWebConfig().Application.LogJoinQueries {
 				fmt.Println("getJoins fieldName")
 				fmt.Println(fieldName)
 			}
@@ -1227,7 +1262,7 @@ func (self *Query) getJoins(x reflect.Value) (joins []join, err error) {
 		}
 	}
 	if !hasJoins {
-		if serverSettings.WebConfig.Application.LogJoinQueries {
+		if serverSettings.GetWebConfig().Application.LogJoinQueries {
 			fmt.Println("Could not resolve a field  (getJoins query): " + " on " + x.Type().String() + " object")
 		}
 		//err = errors.New("Could not resolve a field  (getJoins query): " +  " on " + x.Type().String() + " object")
@@ -1567,7 +1602,7 @@ func (self *Query) substituteDateFormat(dateFormat string) string {
 }
 
 func (self *Query) LogQuery(functionName string) {
-	if serverSettings.WebConfig.Application.LogQueryStackTraces {
+	if serverSettings.GetWebConfig().Application.LogQueryStackTraces {
 		caller := stacktrace.Errorf("GoCore caller:")
 		core.Debug.Dump("Desc-> Called Function query.go#"+functionName, "Desc->Caller for Query:", caller.ErrorStack(), core.Debug.GetDump("Desc->Collection", self.collection, "Desc->Limit", self.limit, "Desc->Skip", self.skip, "Desc->Sort", self.sort, "Desc->mgo Query", self.q, "Desc->Queryset", self.m , "Desc->Joins", self.joins))
 	} else {
@@ -1595,7 +1630,7 @@ func (self *Query) handleQueryError(err error, callback queryError) error {
 		}
 	}
 
-	if serverSettings.WebConfig.Application.LogQueries {
+	if serverSettings.GetWebConfig().Application.LogQueries {
 		core.Debug.Dump("Desc->You got a mongo error!!!! ", err.Error())
 		self.LogQuery("handleQueryError")
 	}
@@ -1697,6 +1732,13 @@ func (self *Query) GenerateQuery() *mgo.Query {
 		}
 	}
 
+	if self.ctx != nil {
+		deadline, ok := self.ctx.Deadline()
+		if ok {
+			q = q.SetMaxTime(time.Until(deadline))
+		}
+	}
+
 	return q
 }
 
@@ -349,6 +349,14 @@ func (self *Transaction) Commit() error {
 
 }
 
+//Discard drops the changes queued on a Transaction that was never committed.  Use Rollback to undo a committed Transaction.
+func (self *Transaction) Discard() error {
+	transactionQueue.Lock()
+	delete(transactionQueue.queue, self.Id.Hex())
+	transactionQueue.Unlock()
+	return nil
+}
+
 func (self *Transaction) Rollback(userId string, reason string) error {
 
 	for _, collection := range self.Collections {
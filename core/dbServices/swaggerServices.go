@@ -114,6 +114,7 @@ type Swagger2Parameter struct {
 	Schema           *Swagger2Schema `json:"schema,omitempty"`
 	Type             string          `json:"type,omitempty"`
 	Format           string          `json:"format,omitempty"`
+	Enum             []string        `json:"enum,omitempty"`
 	AllowEmptyValue  bool            `json:"allowEmptyValue,omitempty"`
 	Items            *Swagger2Item   `json:"items,omitempty"`
 	CollectionFormat string          `json:"collectionFormat,omitempty"`
@@ -225,6 +226,7 @@ func AddSwaggerGETPath(path string, swaggerPath Swagger2Path) error {
 
 	if val, ok := SwaggerDefinition.Paths[path]; ok {
 		val.GET = swaggerPath.GET
+		SwaggerDefinition.Paths[path] = val
 	} else {
 		SwaggerDefinition.Paths[path] = swaggerPath
 	}
@@ -241,6 +243,7 @@ func AddSwaggerPOSTPath(path string, swaggerPath Swagger2Path) error {
 
 	if val, ok := SwaggerDefinition.Paths[path]; ok {
 		val.POST = swaggerPath.POST
+		SwaggerDefinition.Paths[path] = val
 	} else {
 		SwaggerDefinition.Paths[path] = swaggerPath
 	}
@@ -265,6 +268,23 @@ func AddSwaggerPUTPath(path string, swaggerPath Swagger2Path) error {
 	return nil
 }
 
+func AddSwaggerPATCHPath(path string, swaggerPath Swagger2Path) error {
+	SwaggerDefinition.Lock()
+
+	if SwaggerDefinition.Paths == nil {
+		SwaggerDefinition.Paths = make(map[string]Swagger2Path)
+	}
+
+	if val, ok := SwaggerDefinition.Paths[path]; ok {
+		val.PATCH = swaggerPath.PATCH
+		SwaggerDefinition.Paths[path] = val
+	} else {
+		SwaggerDefinition.Paths[path] = swaggerPath
+	}
+	SwaggerDefinition.Unlock()
+	return nil
+}
+
 func AddSwaggerDELETEPath(path string, swaggerPath Swagger2Path) error {
 	SwaggerDefinition.Lock()
 
@@ -323,26 +343,26 @@ func writeSwaggerConfiguration(verisonPath string, version string) {
 
 	//Save Application Meta Data and Version information to Swagger
 	contact := Swagger2Contact{
-		Email: serverSettings.WebConfig.Application.Info.Contact.Email,
-		Name:  serverSettings.WebConfig.Application.Info.Contact.Name,
-		URL:   serverSettings.WebConfig.Application.Info.Contact.URL,
+		Email: serverSettings.GetWebConfig().Application.Info.Contact.Email,
+		Name:  serverSettings.GetWebConfig().Application.Info.Contact.Name,
+		URL:   serverSettings.GetWebConfig().Application.Info.Contact.URL,
 	}
 
 	license := Swagger2License{
-		Name: serverSettings.WebConfig.Application.Info.License.Name,
-		URL:  serverSettings.WebConfig.Application.Info.License.URL,
+		Name: serverSettings.GetWebConfig().Application.Info.License.Name,
+		URL:  serverSettings.GetWebConfig().Application.Info.License.URL,
 	}
 
 	info := Swagger2Info{
-		Title:       serverSettings.WebConfig.Application.Info.Title,
-		Description: serverSettings.WebConfig.Application.Info.Description,
+		Title:       serverSettings.GetWebConfig().Application.Info.Title,
+		Description: serverSettings.GetWebConfig().Application.Info.Description,
 		Contact:     &contact,
 		License:     &license,
 		Version:     version,
 	}
 
 	SwaggerDefinition.BasePath = verisonPath
-	SwaggerDefinition.Host = serverSettings.WebConfig.Application.Domain
+	SwaggerDefinition.Host = serverSettings.GetWebConfig().Application.Domain
 	SwaggerDefinition.Info = &info
 
 	addErrorResponseSwaggerDefinition()
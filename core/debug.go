@@ -127,43 +127,35 @@ func (self *core_debug) Dump(valuesOriginal ...interface{}) {
 	l := "!!!!!!!!!!!!! DEBUG " + t.Format("2006-01-02 15:04:05.000000") + "!!!!!!!!!!!!!\n\n"
 	Logger.Println(l)
 
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += l
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 	for _, value := range valuesOriginal {
 		l := self.DumpBase(value)
 		Logger.Print(l)
-		serverSettings.WebConfigMutex.RLock()
-		if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+		if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 			TransactionLogMutex.Lock()
 			TransactionLog += l
 			TransactionLogMutex.Unlock()
 		}
-		serverSettings.WebConfigMutex.RUnlock()
 	}
 	l = self.ThrowAndPrintError()
 	Logger.Print(l)
 
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += l
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 	l = "!!!!!!!!!!!!! ENDDEBUG " + t.Format("2006-01-02 15:04:05.000000") + "!!!!!!!!!!!!!"
 	Logger.Println(l)
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += l
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 }
 
 func (self *core_debug) GetDump(valuesOriginal ...interface{}) (output string) {
@@ -183,44 +175,36 @@ func (self *core_debug) GetDumpWithInfoAndTimeString(timeStr string, valuesOrigi
 	l := "\n!!!!!!!!!!!!! DEBUG " + timeStr + "!!!!!!!!!!!!!\n\n"
 	output += l
 
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += l
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 
 	for _, value := range valuesOriginal {
 		output += self.DumpBase(value) + "\n"
 	}
 
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += output
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 
 	l = self.ThrowAndPrintError()
 	output += l
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += l
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 	l = "!!!!!!!!!!!!! ENDDEBUG " + timeStr + "!!!!!!!!!!!!!\n"
 	output += l
-	serverSettings.WebConfigMutex.RLock()
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		TransactionLogMutex.Lock()
 		TransactionLog += l
 		TransactionLogMutex.Unlock()
 	}
-	serverSettings.WebConfigMutex.RUnlock()
 	return output
 }
 
@@ -284,9 +268,7 @@ func (self *core_debug) DumpBase(values ...interface{}) (output string) {
 
 func (self *core_debug) ThrowAndPrintError() (output string) {
 
-	serverSettings.WebConfigMutex.RLock()
-	ok := serverSettings.WebConfig.Application.CoreDebugStackTrace
-	serverSettings.WebConfigMutex.RUnlock()
+	ok := serverSettings.GetWebConfig().Application.CoreDebugStackTrace
 	if ok {
 		output += "\n"
 		errorInfo := self.ThrowError()
@@ -48,6 +48,12 @@ var peers *groupcache.HTTPPool
 var htmlFileCache *groupcache.Group
 var stringCache *groupcache.Group
 
+// contains the servers of the group cache http pool.
+var groupCachePeersSynced = struct {
+	sync.RWMutex
+	servers []string
+}{}
+
 // contains the temporary string cache used to cache large strings.
 var tempStringCacheSynced = struct {
 	sync.RWMutex
@@ -71,8 +77,8 @@ func init() {
 
 //Call Initialize in main before any calls to this package are performed.  serverSettings package must be initialized before fileCache.
 func Initialize() {
-	if serverSettings.WebConfig.Application.Domain != "" {
-		initializeGroupCache(serverSettings.WebConfig.Application.Domain)
+	if serverSettings.GetWebConfig().Application.Domain != "" {
+		initializeGroupCache(serverSettings.GetWebConfig().Application.Domain)
 	}
 	LoadJobsFile()
 }
@@ -89,6 +95,13 @@ func WriteJobCacheFile() (err error) {
 	return nil
 }
 
+// Writes the jobs file under the jobs lock.  Called on shutdown so completed one time jobs are not run again.
+func Flush() (err error) {
+	Jobs.Lock()
+	defer Jobs.Unlock()
+	return WriteJobCacheFile()
+}
+
 func LoadJobsFile() (err error) {
 	fname := CACHE_JOBS + "/jobs.json"
 	if extensions.DoesFileExist(fname) {
@@ -377,7 +390,20 @@ func SetString(key string, value string) error {
 
 // Will update the group cache http pool.  Use for dynamic systems that update at runtime.
 func SetGroupCache(servers []string) {
-	peers.Set(servers...)
+	if peers != nil {
+		peers.Set(servers...)
+	}
+	groupCachePeersSynced.Lock()
+	groupCachePeersSynced.servers = append([]string{}, servers...)
+	groupCachePeersSynced.Unlock()
+}
+
+// Returns the servers last set with SetGroupCache.  The app cluster bus posts to the same peers.
+func GetGroupCachePeers() (servers []string) {
+	groupCachePeersSynced.RLock()
+	servers = append(servers, groupCachePeersSynced.servers...)
+	groupCachePeersSynced.RUnlock()
+	return
 }
 
 // Creates the Peers for group cache and creates caches for multiple types.
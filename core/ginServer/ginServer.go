@@ -3,6 +3,7 @@ package ginServer
 import (
 	"sync"
 
+	"github.com/DanielRenne/GoCore/core/pubsub"
 	"github.com/DanielRenne/GoCore/core/serverSettings"
 	"github.com/davidrenne/professor"
 	"github.com/fatih/color"
@@ -46,26 +47,36 @@ func Initialize(mode string, cookieDomain string) {
 
 	ginCookieDomain = cookieDomain
 
-	if serverSettings.WebConfig.Application.CustomGinLogger {
+	if serverSettings.GetWebConfig().Application.CustomGinLogger {
 		Router = gin.New()
 		Router.Use(gin.Recovery())
 	} else {
 		Router = gin.Default()
 	}
 
-	store := sessions.NewCookieStore([]byte(serverSettings.WebConfig.Application.SessionKey))
-	store.Options(sessions.Options{MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays,
-		Secure: serverSettings.WebConfig.Application.SessionSecureCookie})
-
-	if serverSettings.WebConfig.Application.SessionName != "" {
-		Router.Use(sessions.Sessions(serverSettings.WebConfig.Application.SessionName, store))
+	store := sessions.NewCookieStore([]byte(serverSettings.GetWebConfig().Application.SessionKey))
+	store.Options(sessions.Options{MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays,
+		Secure: serverSettings.GetWebConfig().Application.SessionSecureCookie})
+
+	//Session expiration and secure cookies apply to the sessions saved after webConfig.json is reloaded.
+	pubsub.Subscribe(serverSettings.WebConfigChangeTopic, func(key string, x interface{}) {
+		change, ok := x.(serverSettings.WebConfigChange)
+		if !ok || !(change.Changed("application.sessionExpirationDays") || change.Changed("application.sessionSecureCookie")) {
+			return
+		}
+		application := serverSettings.GetWebConfig().Application
+		store.Options(sessions.Options{MaxAge: 86400 * application.SessionExpirationDays, Secure: application.SessionSecureCookie})
+	})
+
+	if serverSettings.GetWebConfig().Application.SessionName != "" {
+		Router.Use(sessions.Sessions(serverSettings.GetWebConfig().Application.SessionName, store))
 	} else {
 		Router.Use(sessions.Sessions("defaultSession", store))
 	}
 
 	//Protect from CSRF Hacking
 	Router.Use(csrf.Middleware(csrf.Options{
-		Secret: serverSettings.WebConfig.Application.CSRFSecret,
+		Secret: serverSettings.GetWebConfig().Application.CSRFSecret,
 		ErrorFunc: func(c *gin.Context) {
 			c.String(400, "CSRF token mismatch")
 			c.Abort()
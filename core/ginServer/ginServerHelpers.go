@@ -38,11 +38,11 @@ func GetSessionKey(c *gin.Context, key string) (sessionKey string) {
 	}()
 	session := sessions.Default(c)
 	if strings.Contains(c.Request.Host, ".com") {
-		session.Options(sessions.Options{MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays,
-			Secure: serverSettings.WebConfig.Application.SessionSecureCookie,
+		session.Options(sessions.Options{MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays,
+			Secure: serverSettings.GetWebConfig().Application.SessionSecureCookie,
 			Domain: ginCookieDomain})
 	} else {
-		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays})
+		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays})
 	}
 	value := session.Get(key)
 	if value == nil {
@@ -57,11 +57,11 @@ func GetSessionKey(c *gin.Context, key string) (sessionKey string) {
 func SetSessionKey(c *gin.Context, key string, value string) {
 	session := sessions.Default(c)
 	if strings.Contains(c.Request.Host, ".com") {
-		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays,
-			Secure: serverSettings.WebConfig.Application.SessionSecureCookie,
+		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays,
+			Secure: serverSettings.GetWebConfig().Application.SessionSecureCookie,
 			Domain: ginCookieDomain})
 	} else {
-		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays})
+		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays})
 	}
 	session.Set(key, value)
 	session.Save()
@@ -70,11 +70,11 @@ func SetSessionKey(c *gin.Context, key string, value string) {
 func SaveSession(c *gin.Context) {
 	session := sessions.Default(c)
 	if strings.Contains(c.Request.Host, ".com") {
-		session.Options(sessions.Options{MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays,
-			Secure: serverSettings.WebConfig.Application.SessionSecureCookie,
+		session.Options(sessions.Options{MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays,
+			Secure: serverSettings.GetWebConfig().Application.SessionSecureCookie,
 			Domain: ginCookieDomain})
 	} else {
-		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays})
+		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays})
 	}
 	session.Save()
 }
@@ -82,11 +82,11 @@ func SaveSession(c *gin.Context) {
 func ClearSession(c *gin.Context) {
 	session := sessions.Default(c)
 	if strings.Contains(c.Request.Host, ".com") {
-		session.Options(sessions.Options{MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays,
-			Secure: serverSettings.WebConfig.Application.SessionSecureCookie,
+		session.Options(sessions.Options{MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays,
+			Secure: serverSettings.GetWebConfig().Application.SessionSecureCookie,
 			Domain: ginCookieDomain})
 	} else {
-		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.WebConfig.Application.SessionExpirationDays})
+		session.Options(sessions.Options{Path: "/", MaxAge: 86400 * serverSettings.GetWebConfig().Application.SessionExpirationDays})
 	}
 	session.Clear()
 }
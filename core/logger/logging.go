@@ -43,11 +43,9 @@ func init() {
 	go func() {
 		time.Sleep(time.Second * 15)
 		for {
-			serverSettings.WebConfigMutex.RLock()
-			if serverSettings.WebConfig.Application.LogGophers {
+			if serverSettings.GetWebConfig().Application.LogGophers {
 				ViewRunningGophers()
 			}
-			serverSettings.WebConfigMutex.RUnlock()
 			time.Sleep(time.Second * 15)
 		}
 	}()
@@ -97,6 +95,13 @@ func getGopherGender() string {
 	}
 }
 
+//RunningGopherCount returns the number of go routines started with GoRoutineLogger that are still running.
+func RunningGopherCount() int {
+	gopherMutex.RLock()
+	defer gopherMutex.RUnlock()
+	return len(RunningGophers)
+}
+
 func ViewRunningGophers() {
 	gopherMutex.RLock()
 	if len(RunningGophers) > 0 {
@@ -129,7 +134,7 @@ at ` + time.Now().String() + " " + extensions.IntToString(len(RunningGophers)) +
 }
 
 func GoRoutineLoggerWithId(fn func(), routineDesc string, Id string) {
-	if serverSettings.WebConfig.Application.LogGophers {
+	if serverSettings.GetWebConfig().Application.LogGophers {
 		id := getGopherGender()
 		if Id == "" {
 			id += utils.RandStringRunes(5)
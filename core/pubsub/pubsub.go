@@ -1,6 +1,7 @@
 package pubsub
 
 import (
+	"context"
 	"sync"
 )
 
@@ -51,12 +52,45 @@ func Subscribe(key string, callback SubscriptionCallback) {
 	}
 }
 
-//Publish a message with a payload.
+var workers = struct {
+	sync.RWMutex
+	running  sync.WaitGroup
+	stopping bool
+}{}
+
+//Publish a message with a payload.  Messages published after Shutdown are dropped.
 func Publish(key string, x interface{}) {
+	workers.RLock()
+	defer workers.RUnlock()
+	if workers.stopping {
+		return
+	}
+	workers.running.Add(1)
 	go pub(key, x)
 }
 
+//Shutdown stops publishing and waits for the running subscription callbacks until ctx is done.
+func Shutdown(ctx context.Context) error {
+	workers.Lock()
+	workers.stopping = true
+	workers.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		workers.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func pub(key string, x interface{}) {
+	defer workers.running.Done()
 	defer func() {
 		if r := recover(); r != nil {
 			return
@@ -0,0 +1,484 @@
+package serverSettings
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//EnvironmentVariable names the environment whose configuration file is layered over the base file, ie production reads webConfig.production.json.
+const EnvironmentVariable = "GOCORE_ENV"
+
+//StrictVariable turns the validation warnings into errors when set to true, as does the -gocoreStrict flag.
+const StrictVariable = "GOCORE_STRICT"
+
+//EnvironmentPrefix starts the environment variables that override a key of webConfig.json.  The rest of the name is the key path
+//with underscores between the keys in any case, ie GOCORE_APPLICATION_HTTPPORT=8080 or GOCORE_DBCONNECTION_CONNECTIONSTRING.
+//Every underscore separates keys, so keys holding an underscore or a dot, ie the rateLimits actions Users.Get, are set through
+//their parent object, ie GOCORE_APPLICATION_RATELIMITS_ACTIONS={"Users.Get":{"rate":5}}, which replaces the whole object.
+//Variables that do not match a key are skipped with a warning.
+const EnvironmentPrefix = "GOCORE_"
+
+type overrideFlags []string
+
+func (obj *overrideFlags) String() string {
+	return strings.Join(*obj, ",")
+}
+
+func (obj *overrideFlags) Set(value string) error {
+	*obj = append(*obj, value)
+	return nil
+}
+
+var flagOverrides overrideFlags
+var flagEnvironment string
+var flagStrict bool
+var flagSet *flag.FlagSet
+
+/*RegisterFlags adds -gocore, -gocoreEnv and -gocoreStrict to fs so they show in its usage and parse with the other flags of the application.
+Without it the flags are read from os.Args, which requires an application that calls flag.Parse to register them.
+Implementation example-----------
+serverSettings.RegisterFlags(flag.CommandLine)
+flag.Parse()
+---------------------------------
+*/
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(&flagOverrides, "gocore", "Overrides a webConfig.json key path.  Ex...  -gocore application.httpPort=8080")
+	fs.StringVar(&flagEnvironment, "gocoreEnv", "", "Environment configuration file to layer over webConfig.json.  Ex...  -gocoreEnv production")
+	fs.BoolVar(&flagStrict, "gocoreStrict", false, "Fails to load webConfig.json on validation warnings.")
+	flagSet = fs
+}
+
+//flagsParsed returns true when the flags were registered with RegisterFlags and parsed.
+func flagsParsed() bool {
+	return flagSet != nil && flagSet.Parsed()
+}
+
+//ConfigErrors lists every problem found while loading and validating the configuration.
+type ConfigErrors []string
+
+func (obj ConfigErrors) Error() string {
+	return "Invalid configuration:\n\t" + strings.Join(obj, "\n\t")
+}
+
+type configOverride struct {
+	source      string
+	path        string
+	value       string
+	skipUnknown bool
+}
+
+/*loadWebConfig layers the configuration and validates it.  The layers from lowest to highest are the base file, the environment file
+named by -gocoreEnv or GOCORE_ENV, the GOCORE_* environment variables and the -gocore flags.  Files are merged key by key and arrays are replaced.
+Invalid settings are returned as warnings so existing files keep loading, unless -gocoreStrict or GOCORE_STRICT=true makes them errors.
+Implementation example-----------
+GOCORE_ENV=production GOCORE_APPLICATION_LOGQUERIES=true ./myApp -gocore application.httpPort=8080
+---------------------------------
+*/
+func loadWebConfig() (config webConfigObj, warnings ConfigErrors, err error) {
+	var problems ConfigErrors
+
+	merged, err := readConfigFile(configurationPath)
+	if err != nil {
+		return
+	}
+
+	environmentFile := environmentConfigPath()
+	if environmentFile != "" {
+		var layer map[string]interface{}
+		layer, err = readConfigFile(environmentFile)
+		if err == nil {
+			mergeKeys(merged, layer)
+		} else if !os.IsNotExist(err) {
+			return
+		}
+		err = nil
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		problems = append(problems, configurationPath+":  "+err.Error())
+		err = problems
+		return
+	}
+	config.setDefaultDbConnection()
+
+	overrides := environmentOverrides()
+	overrides = append(overrides, flagOverridesFromCommandLine()...)
+	if len(overrides) > 0 {
+		skipped, overrideProblems := applyOverrides(&config, overrides)
+		warnings = append(warnings, skipped...)
+		problems = append(problems, overrideProblems...)
+	}
+
+	if strictValidation() {
+		problems = append(problems, config.validate()...)
+	} else {
+		warnings = append(warnings, config.validate()...)
+	}
+	if len(problems) > 0 {
+		err = problems
+	}
+	return
+}
+
+func readConfigFile(path string) (m map[string]interface{}, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		err = ConfigErrors{path + ":  " + err.Error()}
+	}
+	return
+}
+
+//environmentConfigPath returns the path of the environment file, ie webConfig.production.json, or "" without an environment.
+func environmentConfigPath() string {
+	environment := ""
+	if flagsParsed() {
+		environment = flagEnvironment
+	}
+	if environment == "" {
+		environment = commandLineValue("gocoreEnv")
+	}
+	if environment == "" {
+		environment = os.Getenv(EnvironmentVariable)
+	}
+	if environment == "" {
+		return ""
+	}
+
+	extension := filepath.Ext(configurationPath)
+	return strings.TrimSuffix(configurationPath, extension) + "." + environment + extension
+}
+
+//strictValidation returns true when -gocoreStrict or GOCORE_STRICT=true turns the validation warnings into errors.
+func strictValidation() bool {
+	if flagsParsed() && flagStrict {
+		return true
+	}
+	for _, arg := range os.Args[1:] {
+		switch strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-") {
+		case "gocoreStrict", "gocoreStrict=true":
+			return true
+		}
+	}
+	strict, _ := strconv.ParseBool(os.Getenv(StrictVariable))
+	return strict
+}
+
+//configFiles returns the files of the layered configuration.
+func configFiles() (files []string) {
+	files = append(files, configurationPath)
+	environmentFile := environmentConfigPath()
+	if environmentFile != "" {
+		files = append(files, environmentFile)
+	}
+	return
+}
+
+//mergeKeys merges layer into base.  Objects are merged key by key and any other value replaces the value of base.
+func mergeKeys(base map[string]interface{}, layer map[string]interface{}) {
+	for key, value := range layer {
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		layerMap, layerIsMap := value.(map[string]interface{})
+		if baseIsMap && layerIsMap {
+			mergeKeys(baseMap, layerMap)
+			continue
+		}
+		base[key] = value
+	}
+}
+
+func environmentOverrides() (overrides []configOverride) {
+	environ := os.Environ()
+	sort.Strings(environ)
+	for _, variable := range environ {
+		if !strings.HasPrefix(variable, EnvironmentPrefix) {
+			continue
+		}
+		parts := strings.SplitN(variable, "=", 2)
+		if parts[0] == EnvironmentVariable || parts[0] == StrictVariable || len(parts) != 2 {
+			continue
+		}
+		overrides = append(overrides, configOverride{
+			source:      parts[0],
+			path:        strings.Replace(strings.TrimPrefix(parts[0], EnvironmentPrefix), "_", ".", -1),
+			value:       parts[1],
+			skipUnknown: true,
+		})
+	}
+	return
+}
+
+//flagOverridesFromCommandLine returns the -gocore flags.  They are read from os.Args unless the flags were registered with RegisterFlags and parsed.
+func flagOverridesFromCommandLine() (overrides []configOverride) {
+	values := commandLineValues("gocore")
+	if flagsParsed() {
+		values = []string(flagOverrides)
+	}
+
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			overrides = append(overrides, configOverride{source: "-gocore " + value})
+			continue
+		}
+		overrides = append(overrides, configOverride{source: "-gocore " + parts[0], path: parts[0], value: parts[1]})
+	}
+	return
+}
+
+func commandLineValue(name string) string {
+	values := commandLineValues(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+//commandLineValues returns the values of -name value, -name=value, --name value and --name=value in os.Args.
+func commandLineValues(name string) (values []string) {
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-")
+		if arg == args[i] {
+			continue
+		}
+		if arg == name && i+1 < len(args) {
+			i++
+			values = append(values, args[i])
+		} else if strings.HasPrefix(arg, name+"=") {
+			values = append(values, strings.TrimPrefix(arg, name+"="))
+		}
+	}
+	return
+}
+
+//applyOverrides sets the key paths of the overrides.  Keys match in any case.  String keys take the value as is and any other
+//key takes a json value, ie 8080, true or [{"driver":"boltDB"}].  Environment variables that do not match a key are returned as
+//skipped since other tools may share the GOCORE_ prefix, while a -gocore flag that does not match a key is a problem.
+func applyOverrides(config *webConfigObj, overrides []configOverride) (skipped ConfigErrors, problems ConfigErrors) {
+	keys, err := toKeyMap(*config)
+	if err != nil {
+		problems = append(problems, err.Error())
+		return
+	}
+
+	var setDbConnections, setDbConnection bool
+	for _, override := range overrides {
+		if override.path == "" {
+			problems = append(problems, override.source+" must be a key path and value.  Ex...  application.httpPort=8080")
+			continue
+		}
+
+		parent, key, ok := resolveKeyPath(keys, strings.Split(override.path, "."))
+		if !ok && override.skipUnknown {
+			skipped = append(skipped, override.source+" does not match a key of webConfig.json and is skipped.")
+			continue
+		}
+		if !ok {
+			problems = append(problems, override.source+" does not match a key of webConfig.json.")
+			continue
+		}
+
+		var value interface{}
+		if _, isString := parent[key].(string); isString {
+			value = override.value
+		} else if json.Unmarshal([]byte(override.value), &value) != nil {
+			if parent[key] != nil {
+				problems = append(problems, override.source+" "+strconv.Quote(override.value)+" is not a valid json value.")
+				continue
+			}
+			value = override.value
+		}
+		parent[key] = value
+
+		if key == "dbConnections" {
+			setDbConnections = true
+		} else if strings.EqualFold(strings.Split(override.path, ".")[0], "DbConnection") {
+			setDbConnection = true
+		}
+	}
+
+	overridden := webConfigObj{}
+	err = fromKeyMap(keys, &overridden)
+	if err != nil {
+		problems = append(problems, "Overrides do not match the types of webConfig.json:  "+err.Error())
+		return
+	}
+	if setDbConnections && !setDbConnection {
+		overridden.setDefaultDbConnection()
+	}
+	*config = overridden
+	return
+}
+
+//resolveKeyPath finds the object holding the last key of path, matching keys in any case.
+func resolveKeyPath(keys map[string]interface{}, path []string) (parent map[string]interface{}, key string, ok bool) {
+	parent = keys
+	for i, part := range path {
+		key, ok = matchKey(parent, part)
+		if !ok {
+			return
+		}
+		if i == len(path)-1 {
+			return
+		}
+		parent, ok = parent[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+	}
+	return
+}
+
+func matchKey(keys map[string]interface{}, name string) (string, bool) {
+	if _, ok := keys[name]; ok {
+		return name, true
+	}
+	for key := range keys {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+//validate returns every invalid setting.  loadWebConfig reports them as warnings unless validation is strict.
+func (obj *webConfigObj) validate() (problems ConfigErrors) {
+	app := obj.Application
+
+	for _, port := range []struct {
+		name  string
+		value int
+	}{{"httpPort", app.HttpPort}, {"httpsPort", app.HttpsPort}} {
+		if port.value < 0 || port.value > 65535 {
+			problems = append(problems, "application."+port.name+" "+strconv.Itoa(port.value)+" must be between 0 and 65535.")
+		}
+	}
+	if app.GitWebHookPort != "" {
+		port, err := strconv.Atoi(app.GitWebHookPort)
+		if err != nil || port < 0 || port > 65535 {
+			problems = append(problems, "application.gitWebHookServerPort "+app.GitWebHookPort+" must be a port between 0 and 65535.")
+		}
+	}
+	if app.MountGitWebHooks && app.GitWebHookSecretKey == "" {
+		problems = append(problems, "application.gitWebHookSecretKey is required when application.mountGitWebHooks is true.")
+	}
+
+	if app.SessionName != "" || app.SessionExpirationDays > 0 {
+		if app.SessionKey == "" {
+			problems = append(problems, "application.sessionKey is required when sessions are enabled by application.sessionName or application.sessionExpirationDays.")
+		}
+		if app.CSRFSecret == "" {
+			problems = append(problems, "application.csrfSecret is required when sessions are enabled by application.sessionName or application.sessionExpirationDays.")
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"sessionExpirationDays", app.SessionExpirationDays},
+		{"shutdownTimeout", app.ShutdownTimeout},
+		{"idempotency.windowSeconds", app.Idempotency.WindowSeconds},
+		{"maxBatchSize", app.MaxBatchSize},
+		{"webSocket.pingInterval", app.WebSocket.PingInterval},
+		{"webSocket.pongTimeout", app.WebSocket.PongTimeout},
+		{"webSocket.sendQueueSize", app.WebSocket.SendQueueSize},
+		{"webSocket.resumeGraceWindow", app.WebSocket.ResumeGraceWindow},
+		{"webSocket.resumeBufferSize", app.WebSocket.ResumeBufferSize},
+	} {
+		if setting.value < 0 {
+			problems = append(problems, "application."+setting.name+" cannot be negative.")
+		}
+	}
+
+	switch app.WebSocket.SendQueuePolicy {
+	case "", "dropOldest", "dropNewest", "disconnect":
+	default:
+		problems = append(problems, "application.webSocket.sendQueuePolicy "+app.WebSocket.SendQueuePolicy+" must be dropOldest, dropNewest or disconnect.")
+	}
+
+	limits := app.RateLimits
+	for _, limit := range []struct {
+		name  string
+		value rateLimit
+	}{{"connection", limits.Connection}, {"session", limits.Session.rateLimit}, {"ip", limits.IP}} {
+		if limit.value.Rate < 0 || limit.value.Burst < 0 {
+			problems = append(problems, "application.rateLimits."+limit.name+" cannot be negative.")
+		}
+	}
+	actions := make([]string, 0, len(limits.Actions))
+	for action := range limits.Actions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		limit := limits.Actions[action]
+		if limit.Rate < 0 || limit.Burst < 0 || limit.MaxInFlight < 0 || limit.QueueTimeout < 0 {
+			problems = append(problems, "application.rateLimits.actions."+action+" cannot be negative.")
+		}
+	}
+
+	problems = append(problems, validateDbConnection("dbConnections default", obj.DbConnection, true)...)
+	defaultIndex := obj.defaultDbConnectionIndex()
+	names := make(map[string]bool)
+	for i, connection := range obj.DbConnections {
+		if connection.Name != "" {
+			if names[connection.Name] {
+				problems = append(problems, "dbConnections name "+connection.Name+" is used more than once.")
+			}
+			names[connection.Name] = true
+		}
+		if i == defaultIndex {
+			continue
+		}
+		if connection.Name == "" {
+			problems = append(problems, "dbConnections["+strconv.Itoa(i)+"] needs a name, only the default connection may omit it.")
+			continue
+		}
+		problems = append(problems, validateDbConnection("dbConnections "+connection.Name, connection, false)...)
+	}
+	return
+}
+
+//validateDbConnection checks a connection.  MGO_CONNECTION_STRING may provide the connection string of the default connection.
+func validateDbConnection(name string, connection dbConnection, isDefault bool) (problems ConfigErrors) {
+	if !isValidDriver(connection.Driver) {
+		problems = append(problems, name+" driver "+connection.Driver+" must be boltDB, mongoDB or a registered sql driver.")
+	}
+	if connection.Driver != "" && connection.ConnectionString == "" && !(isDefault && connection.Driver == "mongoDB" && os.Getenv("MGO_CONNECTION_STRING") != "") {
+		problems = append(problems, name+" connectionString is required for the "+connection.Driver+" driver.")
+	}
+	if connection.TransactionSizeMax < 0 || connection.AuditHistorySizeMax < 0 {
+		problems = append(problems, name+" transactionSizeMax and auditHistorySizeMax cannot be negative.")
+	}
+	return
+}
+
+func isValidDriver(driver string) bool {
+	switch driver {
+	case "", "boltDB", "mongoDB":
+		return true
+	}
+	for _, registered := range sql.Drivers() {
+		if driver == registered {
+			return true
+		}
+	}
+	return false
+}
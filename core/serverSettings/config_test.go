@@ -0,0 +1,174 @@
+package serverSettings
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testBaseConfig = `{
+	"application": {"domain": "127.0.0.1", "httpPort": 80, "info": {"title": "base"}},
+	"dbConnections": [
+		{"name": "local", "driver": "boltDB", "connectionString": "db/local.db"},
+		{"driver": "mongoDB", "connectionString": "mongodb://127.0.0.1/base"}
+	]
+}`
+
+const testProductionConfig = `{
+	"application": {"httpPort": 81, "info": {"title": "production"}},
+	"dbConnections": [{"driver": "boltDB", "connectionString": "db/production.db"}]
+}`
+
+//loadTestConfig writes the configuration files to a temporary directory and loads them with args parsed by a flag set.
+func loadTestConfig(t *testing.T, files map[string]string, env map[string]string, args []string) (config webConfigObj, warnings ConfigErrors, err error) {
+	dir := t.TempDir()
+	for name, data := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	path := configurationPath
+	configurationPath = filepath.Join(dir, "webConfig.json")
+	flagOverrides, flagEnvironment, flagStrict = nil, "", false
+	t.Cleanup(func() {
+		configurationPath = path
+		flagOverrides, flagEnvironment, flagStrict, flagSet = nil, "", false, nil
+	})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	err = fs.Parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loadWebConfig()
+}
+
+func TestLoadWebConfig(t *testing.T) {
+	layers := map[string]string{"webConfig.json": testBaseConfig, "webConfig.production.json": testProductionConfig}
+
+	tests := []struct {
+		description string
+		files       map[string]string
+		env         map[string]string
+		args        []string
+		check       func(config webConfigObj) bool
+	}{
+		{"the base file", layers, nil, nil, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 80 && config.Application.Info.Title == "base"
+		}},
+		{"the default connection is the entry without a name", layers, nil, nil, func(config webConfigObj) bool {
+			return len(config.DbConnections) == 2 && config.DbConnection.Driver == "mongoDB" && config.DbConnection.ConnectionString == "mongodb://127.0.0.1/base"
+		}},
+		{"GOCORE_ENV layers its file over the base file key by key", layers, map[string]string{"GOCORE_ENV": "production"}, nil, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 81 && config.Application.Info.Title == "production" && config.Application.Domain == "127.0.0.1"
+		}},
+		{"-gocoreEnv layers its file over the base file", layers, nil, []string{"-gocoreEnv", "production"}, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 81
+		}},
+		{"a missing environment file is skipped", layers, map[string]string{"GOCORE_ENV": "staging"}, nil, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 80
+		}},
+		{"the environment file replaces arrays and the default connection", layers, map[string]string{"GOCORE_ENV": "production"}, nil, func(config webConfigObj) bool {
+			return len(config.DbConnections) == 1 && config.DbConnection.ConnectionString == "db/production.db"
+		}},
+		{"variables override the environment file", layers, map[string]string{"GOCORE_ENV": "production", "GOCORE_APPLICATION_HTTPPORT": "82"}, nil, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 82 && config.Application.Info.Title == "production"
+		}},
+		{"flags override variables", layers, map[string]string{"GOCORE_ENV": "production", "GOCORE_APPLICATION_HTTPPORT": "82"}, []string{"-gocore", "application.httpPort=83"}, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 83
+		}},
+		{"the last flag wins", layers, nil, []string{"-gocore", "application.httpPort=83", "-gocore=application.httpPort=84"}, func(config webConfigObj) bool {
+			return config.Application.HttpPort == 84
+		}},
+		{"keys match in any case", layers, map[string]string{"GOCORE_application_Info_TITLE": "variable"}, []string{"-gocore", "APPLICATION.DOMAIN=example.com"}, func(config webConfigObj) bool {
+			return config.Application.Info.Title == "variable" && config.Application.Domain == "example.com"
+		}},
+		{"string keys take the value as is", layers, map[string]string{"GOCORE_APPLICATION_DOMAIN": "8080"}, nil, func(config webConfigObj) bool {
+			return config.Application.Domain == "8080"
+		}},
+		{"an override replaces arrays and the default connection", layers, nil, []string{"-gocore", `dbConnections=[{"driver":"boltDB","connectionString":"db/flag.db"}]`}, func(config webConfigObj) bool {
+			return len(config.DbConnections) == 1 && config.DbConnection.ConnectionString == "db/flag.db"
+		}},
+		{"the default connection may be overridden by itself", layers, map[string]string{"GOCORE_DBCONNECTION_CONNECTIONSTRING": "mongodb://127.0.0.1/variable"}, nil, func(config webConfigObj) bool {
+			return config.DbConnection.ConnectionString == "mongodb://127.0.0.1/variable"
+		}},
+		{"keys holding a dot are set through their parent object", layers, map[string]string{"GOCORE_APPLICATION_RATELIMITS_ACTIONS": `{"Users.Get":{"rate":5,"burst":10}}`}, nil, func(config webConfigObj) bool {
+			limit := config.Application.RateLimits.Actions["Users.Get"]
+			return limit.Rate == 5 && limit.Burst == 10
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			config, warnings, err := loadTestConfig(t, test.files, test.env, test.args)
+			if err != nil {
+				t.Fatalf("expected the configuration to load, got %v", err)
+			}
+			if len(warnings) > 0 {
+				t.Errorf("expected no warnings, got %v", warnings)
+			}
+			if !test.check(config) {
+				t.Errorf("unexpected configuration %+v", config)
+			}
+		})
+	}
+}
+
+func TestLoadWebConfigProblems(t *testing.T) {
+	const invalidConfig = `{"application": {"httpPort": 70000, "sessionName": "session"}}`
+	invalidWarnings := ConfigErrors{
+		"application.httpPort 70000 must be between 0 and 65535.",
+		"application.sessionKey is required when sessions are enabled by application.sessionName or application.sessionExpirationDays.",
+		"application.csrfSecret is required when sessions are enabled by application.sessionName or application.sessionExpirationDays.",
+	}
+
+	tests := []struct {
+		description string
+		config      string
+		env         map[string]string
+		args        []string
+		warnings    ConfigErrors
+		err         string
+	}{
+		{"unknown variables are skipped with a warning", testBaseConfig, map[string]string{"GOCORE_UNKNOWN_KEY": "1"}, nil,
+			ConfigErrors{"GOCORE_UNKNOWN_KEY does not match a key of webConfig.json and is skipped."}, ""},
+		{"invalid settings are warnings", invalidConfig, nil, nil, invalidWarnings, ""},
+		{"GOCORE_STRICT makes invalid settings errors", invalidConfig, map[string]string{"GOCORE_STRICT": "true"}, nil, nil,
+			"Invalid configuration:\n\t" + strings.Join(invalidWarnings, "\n\t")},
+		{"-gocoreStrict makes invalid settings errors", invalidConfig, nil, []string{"-gocoreStrict"}, nil,
+			"Invalid configuration:\n\t" + strings.Join(invalidWarnings, "\n\t")},
+		{"flag problems and invalid settings are aggregated", invalidConfig, map[string]string{"GOCORE_STRICT": "true"},
+			[]string{"-gocore", "application.unknownKey=1", "-gocore", "application.httpsPort=abc", "-gocore", "application.domain"}, nil,
+			"Invalid configuration:\n\t" + strings.Join(append(ConfigErrors{
+				"-gocore application.unknownKey does not match a key of webConfig.json.",
+				`-gocore application.httpsPort "abc" is not a valid json value.`,
+				"-gocore application.domain must be a key path and value.  Ex...  application.httpPort=8080",
+			}, invalidWarnings...), "\n\t")},
+		{"values of the wrong type are errors", testBaseConfig, map[string]string{"GOCORE_APPLICATION_HTTPPORT": `"80"`}, nil, nil,
+			"Invalid configuration:\n\tOverrides do not match the types of webConfig.json:  json: cannot unmarshal string into Go struct field"},
+		{"a malformed file is an error", `{"application": `, nil, nil, nil, "Invalid configuration:\n\t"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, warnings, err := loadTestConfig(t, map[string]string{"webConfig.json": test.config}, test.env, test.args)
+			if test.err == "" && err != nil {
+				t.Errorf("expected the configuration to load, got %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)) {
+				t.Errorf("expected the error %q, got %v", test.err, err)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(test.warnings, "\n") {
+				t.Errorf("expected the warnings %q, got %q", test.warnings, warnings)
+			}
+		})
+	}
+}
@@ -1,9 +1,7 @@
 package serverSettings
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"sync"
 )
 
@@ -13,7 +11,9 @@ type htmlTemplates struct {
 	DirectoryLevels int    `json:"directoryLevels"`
 }
 
+//dbConnection is an entry of dbConnections.  Collections name the entry they live on in their schema, see GetDbConnection.
 type dbConnection struct {
+	Name                string `json:"name"`
 	ConnectionString    string `json:"connectionString"`
 	EnableTLS           bool   `json:"enableTLS"`
 	Driver              string `json:"driver"`
@@ -48,6 +48,49 @@ type info struct {
 	TermsOfService string  `json:termsOfService"`
 }
 
+type rateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+type sessionRateLimit struct {
+	rateLimit
+	SessionKey string `json:"sessionKey"`
+}
+
+type actionLimit struct {
+	rateLimit
+	MaxInFlight  int `json:"maxInFlight"`
+	QueueTimeout int `json:"queueTimeout"`
+}
+
+//RateLimits configures the api router token buckets.  Rate is tokens per second and burst is the bucket size, a rate of 0 disables the limit.
+//Actions are keyed by "Controller.Action" or "Controller" and may also cap the calls in flight.  Calls over maxInFlight wait up to
+//queueTimeout milliseconds for a slot, 0 rejects them immediately.
+type RateLimits struct {
+	Connection rateLimit              `json:"connection"`
+	Session    sessionRateLimit       `json:"session"`
+	IP         rateLimit              `json:"ip"`
+	Actions    map[string]actionLimit `json:"actions"`
+}
+
+type idempotency struct {
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+//webSocket configures heartbeats and the outbound queue.  A ping control frame is sent every pingInterval milliseconds and
+//the connection is closed when neither a pong nor a message arrives within pongTimeout milliseconds.
+//Each connection queues up to sendQueueSize messages and applies sendQueuePolicy when full:  "dropOldest", "dropNewest" or "disconnect".
+//A resumeGraceWindow in milliseconds enables resume tokens, keeping the last resumeBufferSize pub sub messages of a session.
+type webSocket struct {
+	PingInterval      int    `json:"pingInterval"`
+	PongTimeout       int    `json:"pongTimeout"`
+	SendQueueSize     int    `json:"sendQueueSize"`
+	SendQueuePolicy   string `json:"sendQueuePolicy"`
+	ResumeGraceWindow int    `json:"resumeGraceWindow"`
+	ResumeBufferSize  int    `json:"resumeBufferSize"`
+}
+
 type Application struct {
 	Name                     string        `json:"name"`
 	Domain                   string        `json:"domain"`
@@ -82,6 +125,12 @@ type Application struct {
 	LogGophers               bool          `json:"logGophers"`
 	CoreDebugStackTrace      bool          `json:"coreDebugStackTrace"`
 	AllowCrossOriginRequests bool          `json:"allowCrossOriginRequests"`
+	RateLimits               RateLimits    `json:"rateLimits"`
+	Idempotency              idempotency   `json:"idempotency"`
+	MaxBatchSize             int           `json:"maxBatchSize"`
+	WebSocket                webSocket     `json:"webSocket"`
+	ShutdownTimeout          int           `json:"shutdownTimeout"`
+	DebugToken               string        `json:"debugToken"`
 }
 
 type webConfigObj struct {
@@ -90,34 +139,110 @@ type webConfigObj struct {
 	DbConnection  dbConnection
 }
 
+//DefaultDbConnectionName names the default dbConnections entry.  The default is the first entry named default or without a name,
+//otherwise the first entry.  It is copied to WebConfig.DbConnection and used by collections that do not name a connection.
+const DefaultDbConnectionName = "default"
+
 var WebConfig webConfigObj
 var WebConfigMutex sync.RWMutex
 var APP_LOCATION string
 var SWAGGER_UI_PATH string
+var configurationPath string
+
+//GetWebConfig returns a copy of WebConfig read under WebConfigMutex.  ReloadWebConfig replaces WebConfig while the server runs,
+//so read settings through GetWebConfig or while holding WebConfigMutex.
+func GetWebConfig() webConfigObj {
+	WebConfigMutex.RLock()
+	defer WebConfigMutex.RUnlock()
+	return WebConfig
+}
+
+func (obj *webConfigObj) defaultDbConnectionIndex() int {
+	for i := range obj.DbConnections {
+		if obj.DbConnections[i].Name == "" || obj.DbConnections[i].Name == DefaultDbConnectionName {
+			return i
+		}
+	}
+	if len(obj.DbConnections) > 0 {
+		return 0
+	}
+	return -1
+}
+
+func (obj *webConfigObj) setDefaultDbConnection() {
+	i := obj.defaultDbConnectionIndex()
+	if i >= 0 {
+		obj.DbConnection = obj.DbConnections[i]
+	}
+}
 
+//IsDefaultDbConnection returns true when name is empty or names the default dbConnections entry.
+func IsDefaultDbConnection(name string) bool {
+	if name == "" || name == DefaultDbConnectionName {
+		return true
+	}
+	WebConfigMutex.RLock()
+	defer WebConfigMutex.RUnlock()
+	return name == WebConfig.DbConnection.Name
+}
+
+//GetDbConnection returns the dbConnections entry named name.  The default connection is returned for an empty name.
+func GetDbConnection(name string) (connection dbConnection, ok bool) {
+	if IsDefaultDbConnection(name) {
+		WebConfigMutex.RLock()
+		defer WebConfigMutex.RUnlock()
+		return WebConfig.DbConnection, WebConfig.DbConnection.Driver != ""
+	}
+
+	WebConfigMutex.RLock()
+	defer WebConfigMutex.RUnlock()
+	for _, connection = range WebConfig.DbConnections {
+		if connection.Name == name {
+			ok = true
+			return
+		}
+	}
+	connection = dbConnection{}
+	return
+}
+
+//GetDbConnectionNames returns the names of the dbConnections entries other than the default.
+func GetDbConnectionNames() (names []string) {
+	WebConfigMutex.RLock()
+	defer WebConfigMutex.RUnlock()
+	defaultIndex := WebConfig.defaultDbConnectionIndex()
+	for i, connection := range WebConfig.DbConnections {
+		if i != defaultIndex && connection.Name != WebConfig.DbConnection.Name {
+			names = append(names, connection.Name)
+		}
+	}
+	return
+}
+
+//Initialize loads the layered configuration of configurationFile in path, see loadWebConfig.  WebConfig is not changed when the
+//configuration fails to load, and the error lists every problem.  Invalid settings are printed as warnings unless validation is strict.
 func Initialize(path string, configurationFile string) (err error) {
 
 	APP_LOCATION = path
 	SWAGGER_UI_PATH = APP_LOCATION + "/web/swagger/dist"
 	fmt.Println("core serverSettings initialized.")
 
-	jsonData, err := ioutil.ReadFile(APP_LOCATION + "/" + configurationFile)
+	configurationPath = APP_LOCATION + "/" + configurationFile
+	config, warnings, err := loadWebConfig()
 	if err != nil {
-		fmt.Println("Reading of webConfig.json failed:  " + err.Error())
+		fmt.Println("Loading of " + configurationFile + " failed:  " + err.Error())
 		return
 	}
+	printConfigWarnings(warnings)
 
 	WebConfigMutex.Lock()
-	errUnmarshal := json.Unmarshal(jsonData, &WebConfig)
-	if errUnmarshal != nil {
-		fmt.Println("Parsing / Unmarshaling of webConfig.json failed:  " + errUnmarshal.Error())
-		return
-	}
-
-	for _, dbConnection := range WebConfig.DbConnections {
-		WebConfig.DbConnection = dbConnection
-	}
+	WebConfig = config
 	WebConfigMutex.Unlock()
-
 	return
 }
+
+func printConfigWarnings(warnings ConfigErrors) {
+	for _, warning := range warnings {
+		fmt.Println("webConfig.json warning:  " + warning)
+	}
+}
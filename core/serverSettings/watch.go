@@ -0,0 +1,297 @@
+package serverSettings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DanielRenne/GoCore/core/pubsub"
+)
+
+//WebConfigChangeTopic is the pubsub key a WebConfigChange is published on after webConfig.json is reloaded.
+const WebConfigChangeTopic = "WebConfig.Change"
+
+const webConfigPollInterval = time.Second
+
+//RestartRequiredKeys are the key paths of webConfig.json that are read once at startup.  A reload keeps their running values
+//and reports them in WebConfigChange.RestartRequired.  A key path also covers every key below it.
+var RestartRequiredKeys = []string{
+	"application.httpPort",
+	"application.httpsPort",
+	"application.releaseMode",
+	"application.webServiceOnly",
+	"application.mountGitWebHooks",
+	"application.gitWebHookSecretKey",
+	"application.gitWebHookServerPort",
+	"application.gitWebHookPath",
+	"application.htmlTemplates",
+	"application.rootIndexPath",
+	"application.disableRootIndex",
+	"application.customGinLogger",
+	"application.cookieDomain",
+	"application.sessionKey",
+	"application.sessionName",
+	"application.csrfSecret",
+	"dbConnections",
+	"DbConnection",
+}
+
+//WebConfigChange lists the key paths that changed on a reload of webConfig.json, ie application.logQueries.
+//RestartRequired lists the changed keys that keep their running values until the application restarts.
+type WebConfigChange struct {
+	Keys            []string
+	RestartRequired []string
+}
+
+//Changed returns true when the key path or a key below it changed.
+func (obj WebConfigChange) Changed(key string) bool {
+	for _, k := range obj.Keys {
+		if isKeyPath(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+var watchSynced = struct {
+	sync.Mutex
+	stop   chan struct{}
+	stamps map[string]fileStamp
+}{}
+
+/*WatchWebConfig checks the files of the configuration passed to Initialize every second and reloads it when one changes.
+The files are polled rather than watched with fsnotify so layered files created after startup and editors that save by renaming are seen.
+A configuration that fails to load or validate is logged and the running configuration is kept.  Otherwise WebConfig is swapped under
+WebConfigMutex, so read it with GetWebConfig, and a WebConfigChange is published on WebConfigChangeTopic.
+Implementation example-----------
+pubsub.Subscribe(serverSettings.WebConfigChangeTopic, func(key string, x interface{}) {
+	change := x.(serverSettings.WebConfigChange)
+	if change.Changed("application.logQueries") {
+		log.Println("Query logging changed.")
+	}
+})
+---------------------------------
+*/
+func WatchWebConfig() (err error) {
+	watchSynced.Lock()
+	defer watchSynced.Unlock()
+
+	if watchSynced.stop != nil {
+		return
+	}
+	if configurationPath == "" {
+		err = errors.New("serverSettings must be initialized before watching webConfig.json.")
+		return
+	}
+	watchSynced.stamps = stampConfigFiles()
+
+	stop := make(chan struct{})
+	watchSynced.stop = stop
+	go func() {
+		ticker := time.NewTicker(webConfigPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				checkWebConfig()
+			}
+		}
+	}()
+	return
+}
+
+//StopWatchingWebConfig stops the reloading started by WatchWebConfig.
+func StopWatchingWebConfig() {
+	watchSynced.Lock()
+	defer watchSynced.Unlock()
+
+	if watchSynced.stop != nil {
+		close(watchSynced.stop)
+		watchSynced.stop = nil
+	}
+}
+
+func stampConfigFiles() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, file := range configFiles() {
+		info, err := os.Stat(file)
+		if err == nil {
+			stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func checkWebConfig() {
+	watchSynced.Lock()
+	defer watchSynced.Unlock()
+
+	if watchSynced.stop == nil {
+		return
+	}
+
+	stamps := stampConfigFiles()
+	if reflect.DeepEqual(stamps, watchSynced.stamps) {
+		return
+	}
+	watchSynced.stamps = stamps
+
+	change, err := ReloadWebConfig()
+	if err != nil {
+		fmt.Println("Reloading of webConfig.json failed, keeping the running configuration:  " + err.Error())
+		return
+	}
+	if len(change.RestartRequired) > 0 {
+		fmt.Println("webConfig.json changes require a restart:  " + strings.Join(change.RestartRequired, ", "))
+	}
+}
+
+//ReloadWebConfig loads the layered configuration again and swaps WebConfig.  The changed keys are published on
+//WebConfigChangeTopic unless nothing changed.
+func ReloadWebConfig() (change WebConfigChange, err error) {
+	config, warnings, err := loadWebConfig()
+	if err != nil {
+		return
+	}
+	printConfigWarnings(warnings)
+
+	WebConfigMutex.Lock()
+	running, err := toKeyMap(WebConfig)
+	if err != nil {
+		WebConfigMutex.Unlock()
+		return
+	}
+	reloaded, err := toKeyMap(config)
+	if err != nil {
+		WebConfigMutex.Unlock()
+		return
+	}
+
+	diffKeys("", running, reloaded, &change.Keys)
+	sort.Strings(change.Keys)
+	if len(change.Keys) == 0 {
+		WebConfigMutex.Unlock()
+		return
+	}
+
+	for _, key := range change.Keys {
+		if isRestartRequired(key) {
+			change.RestartRequired = append(change.RestartRequired, key)
+			copyKey(key, running, reloaded)
+		}
+	}
+
+	if len(change.RestartRequired) > 0 {
+		config = webConfigObj{}
+		err = fromKeyMap(reloaded, &config)
+		if err != nil {
+			WebConfigMutex.Unlock()
+			return
+		}
+	}
+	WebConfig = config
+	WebConfigMutex.Unlock()
+
+	pubsub.Publish(WebConfigChangeTopic, change)
+	return
+}
+
+func isKeyPath(key string, path string) bool {
+	return key == path || strings.HasPrefix(key, path+".")
+}
+
+func isRestartRequired(key string) bool {
+	for _, path := range RestartRequiredKeys {
+		if isKeyPath(key, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func toKeyMap(config webConfigObj) (m map[string]interface{}, err error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &m)
+	return
+}
+
+func fromKeyMap(m map[string]interface{}, config *webConfigObj) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+//diffKeys appends the paths of the values that differ.  Objects are compared key by key and anything else as a whole.
+func diffKeys(prefix string, running map[string]interface{}, reloaded map[string]interface{}, keys *[]string) {
+	for key, value := range reloaded {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		runningValue, ok := running[key]
+		runningMap, runningIsMap := runningValue.(map[string]interface{})
+		reloadedMap, reloadedIsMap := value.(map[string]interface{})
+		if ok && runningIsMap && reloadedIsMap {
+			diffKeys(path, runningMap, reloadedMap, keys)
+			continue
+		}
+		if !ok || !reflect.DeepEqual(runningValue, value) {
+			*keys = append(*keys, path)
+		}
+	}
+
+	for key := range running {
+		if _, ok := reloaded[key]; !ok {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			*keys = append(*keys, path)
+		}
+	}
+}
+
+//copyKey sets the value of the key path in to to its value in from.
+func copyKey(key string, from map[string]interface{}, to map[string]interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		fromChild, ok := from[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		toChild, ok := to[part].(map[string]interface{})
+		if !ok {
+			toChild = make(map[string]interface{})
+			to[part] = toChild
+		}
+		from = fromChild
+		to = toChild
+	}
+
+	last := parts[len(parts)-1]
+	value, ok := from[last]
+	if !ok {
+		delete(to, last)
+		return
+	}
+	to[last] = value
+}
@@ -46,19 +46,19 @@ func ReplaceTokenInFile(file string, find string, replaceWith string) {
 }
 
 func TalkDirtyToMe(sayWhat string) {
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		go exec.Command("say", sayWhat).Output()
 	}
 }
 
 func TalkDirty(sayWhat string) {
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		go exec.Command("say", sayWhat).Output()
 	}
 }
 
 func TalkDirtySlowly(sayWhat string) {
-	if serverSettings.WebConfig.Application.ReleaseMode == "development" {
+	if serverSettings.GetWebConfig().Application.ReleaseMode == "development" {
 		exec.Command("say", sayWhat).Output()
 	}
 }
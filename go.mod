@@ -9,13 +9,13 @@ require (
 	github.com/MakeNowJust/heredoc v0.0.0-20140704152643-1d91351acdc1 // indirect
 	github.com/Masterminds/semver v1.2.2 // indirect
 	github.com/altipla-consulting/i18n-dateformatter v0.0.0-20150925092426-d7d6ed4b87fb // indirect
-	github.com/asaskevich/govalidator v0.0.0-20171002085717-ca5f9e638c83 // indirect
+	github.com/asaskevich/govalidator v0.0.0-20171002085717-ca5f9e638c83
 	github.com/asdine/storm v0.0.0-20160730105259-c9a194eaf968
 	github.com/aws/aws-sdk-go v1.10.40-0.20170906173017-58370dfb7321 // indirect
 	github.com/blang/semver v3.5.1+incompatible // indirect
 	github.com/bogdanovich/dns_resolver v0.0.0-20170211073258-a8e42bc6a5b6 // indirect
 	github.com/boj/redistore v0.0.0-20160128113310-fc113767cd6b // indirect
-	github.com/boltdb/bolt v1.2.2-0.20160730144416-94c8db596809 // indirect
+	github.com/boltdb/bolt v1.2.2-0.20160730144416-94c8db596809
 	github.com/cenkalti/backoff v1.0.1-0.20170329104900-5d150e7eec02 // indirect
 	github.com/cloudfoundry/gosigar v0.0.0-20170626175820-d9ee2f6269ae // indirect
 	github.com/coreos/bbolt v1.3.1-coreos.6.0.20180318001526-af9db2027c98 // indirect
@@ -82,7 +82,7 @@ require (
 	github.com/tatsushid/go-fastping v0.0.0-20160109021039-d7bb493dee3e // indirect
 	github.com/ttacon/builder v0.0.0-20141230023516-7f152c3cf471 // indirect
 	github.com/ttacon/libphonenumber v0.0.0-20160629174823-33d0ea1f9ace // indirect
-	github.com/ugorji/go v0.0.0-20170918222552-54210f4e076c // indirect
+	github.com/ugorji/go v0.0.0-20170918222552-54210f4e076c
 	github.com/utrack/gin-csrf v0.0.0-20150831070702-63c0ef5eca6c
 	github.com/ziutek/utils v0.0.0-20131202123950-d8fe304b0db2 // indirect
 	golang.org/x/crypto v0.0.0-20190829043050-9756ffdc2472 // indirect